@@ -339,6 +339,26 @@ declare namespace Spicetify {
          * Set new value for key
          */
         function set(key: string, value: string): void;
+        /**
+         * Storage bucket whose keys cannot collide with other extensions'.
+         */
+        interface Bucket {
+            /** Extension id the bucket belongs to */
+            id: string;
+            get(key: string): string | null;
+            set(key: string, value: string): void;
+            remove(key: string): void;
+            /** Keys stored in this bucket */
+            keys(): string[];
+            /** Delete every key of this bucket only */
+            clear(): void;
+        }
+        /**
+         * Get storage bucket of an extension. `id` is extension file name, or
+         * `import.meta.url` in modules. It can be omitted when called while
+         * extension script loads, not later in callbacks.
+         */
+        function scoped(id?: string): Bucket;
     }
     /**
      * To create and prepend custom menu item in profile menu.
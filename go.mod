@@ -5,7 +5,9 @@ go 1.16
 require (
 	github.com/go-ini/ini v1.62.0
 	github.com/mattn/go-colorable v0.1.8
+	github.com/mattn/go-isatty v0.0.12
 	github.com/smartystreets/goconvey v1.6.4 // indirect
 	golang.org/x/net v0.0.0-20210226172049-e18ecbb05110
+	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68
 	gopkg.in/ini.v1 v1.62.0 // indirect
 )
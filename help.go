@@ -0,0 +1,277 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// helpExample is a command line with what it does
+type helpExample struct {
+	about   string
+	command string
+}
+
+// commandExamples are concrete examples "spicetify help <command>" prints
+// after description and flags of command, which come from help text
+var commandExamples = map[string][]helpExample{
+	"backup": {
+		{"Back up stock Spotify and apply for the first time", "spicetify backup apply"},
+		{"Back up without the large media package", `spicetify backup --exclude "*-media.spa"`},
+		{"Back up with a note, then list backups", `spicetify backup --incremental --note "before trying patch X" && spicetify backup list`},
+	},
+	"apply": {
+		{"Apply config, only redoing what changed since last apply", "spicetify apply"},
+		{"Apply to every Spotify install declared in config", "spicetify --all-installs apply"},
+		{"Reprocess everything and check result", "spicetify apply --full --verify"},
+		{"Apply another theme once, without changing config", "spicetify apply --theme Dribbblish --color-scheme nord-dark"},
+		{"See how much mods add to Spotify frontend", "spicetify apply --measure-size"},
+	},
+	"update": {
+		{"Push theme CSS and colors to applied Spotify", "spicetify update"},
+		{"Push extensions to applied Spotify", "spicetify -e update"},
+	},
+	"restore": {
+		{"Revert Spotify to stock", "spicetify restore"},
+		{"Restore from a backup copied from another machine", "spicetify restore --backup /path/to/Backup"},
+	},
+	"auto": {
+		{"Back up and reapply if Spotify has updated itself", "spicetify auto"},
+	},
+	"check": {
+		{"Report whether Spotify has reverted spicetify changes", "spicetify check"},
+		{"Only report whether backup matches Spotify, for scripts", "spicetify check --backup-version-check-only"},
+	},
+	"verify": {
+		{"Check that applied files are unchanged since last apply", "spicetify verify"},
+		{"Bring back missing or modified ones if it finds any", "spicetify verify || spicetify apply --repair"},
+	},
+	"refresh-raw": {
+		{"Regenerate stock assets, then reapply from them", "spicetify refresh-raw restore apply"},
+	},
+	"watch": {
+		{"Update CSS and colors while editing theme, live", "spicetify watch -l"},
+		{"Reload extensions while editing them", "spicetify watch -e -l"},
+	},
+	"config": {
+		{"Switch theme and color scheme", "spicetify config current_theme Dribbblish color_scheme nord-dark"},
+		{"Add an extension, or remove it with a trailing minus", "spicetify config extensions fullAppDisplay.js"},
+		{"Revert last config change", "spicetify config undo"},
+	},
+	"themes": {
+		{"List installed themes", "spicetify themes list"},
+		{"Install a theme from a GitHub repository folder, pinned to a tag", "spicetify themes install gh:owner/repo/Theme@v1.0"},
+	},
+	"extensions": {
+		{"Create a starter extension and enable it", "spicetify extensions scaffold myExtension --register"},
+		{"Install an extension file from GitHub and enable it", "spicetify extensions install gh:owner/repo/dist/ext.js --register"},
+		{"Check an installed extension for common mistakes", "spicetify extensions test myExtension.js"},
+	},
+	"apps": {
+		{"Check files and route spicetify resolves for a custom app", "spicetify apps inspect myApp"},
+	},
+	"patches": {
+		{"List patches and whether they still match Spotify code", "spicetify patches list"},
+	},
+	"collect-logs": {
+		{"Bundle config, state and recent output for a bug report", "spicetify collect-logs spicetify-report.zip"},
+	},
+	"uninstall": {
+		{"Revert Spotify and delete spicetify config and backup", "spicetify uninstall --purge"},
+	},
+}
+
+// workflows are common tasks "spicetify examples" walks through
+var workflows = []struct {
+	title string
+	steps []helpExample
+}{
+	{"First-time setup", []helpExample{
+		{"Back up stock Spotify and apply default config", "spicetify backup apply"},
+		{"Pick a theme from user Themes folder", "spicetify config current_theme <theme>"},
+		{"Apply it", "spicetify apply"},
+	}},
+	{"Updating a theme", []helpExample{
+		{"Install or replace theme with its latest version", "spicetify themes install gh:owner/repo --force"},
+		{"Switch color scheme", "spicetify config color_scheme <scheme>"},
+		{"Push CSS and colors without a full apply", "spicetify update"},
+		{"Or keep updating while editing theme files", "spicetify watch -l"},
+	}},
+	{"Adding an extension", []helpExample{
+		{"Install extension file and add it to config", "spicetify extensions install gh:owner/repo/ext.js --register"},
+		{"Or enable one already in Extensions folder", "spicetify config extensions <name>.js"},
+		{"Apply it", "spicetify apply"},
+	}},
+	{"Recovering after a Spotify update", []helpExample{
+		{"Check whether Spotify has reverted spicetify changes", "spicetify check"},
+		{"Back up new Spotify version and apply again", "spicetify backup apply"},
+		{"Or let spicetify decide what is needed", "spicetify auto"},
+		{"If applied Spotify still shows old stock files", "spicetify refresh-raw restore apply"},
+	}},
+	{"Keeping a second Spotify install themed", []helpExample{
+		{"Add [Install beta] section with its spotify_path and prefs_path", "spicetify config edit"},
+		{"Back it up and apply to it alone", "spicetify --install beta backup apply"},
+		{"After updates, apply to every install at once", "spicetify --all-installs backup apply"},
+	}},
+}
+
+// helpCommand prints description of `command` from help text, flags whose
+// description mentions it and its examples
+func helpCommand(command string) {
+	description := getHelpEntry(command)
+	if len(description) == 0 {
+		utils.PrintError(`Command "` + command + `" not found.`)
+		utils.PrintInfo("Commands: " + strings.Join(getHelpCommands(), ", "))
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	log.Println(utils.Bold("COMMAND") + "\n" + strings.Join(description, "\n"))
+
+	if flagEntries := getCommandFlags(command); len(flagEntries) > 0 {
+		log.Println("\n" + utils.Bold("FLAGS"))
+		log.Println(strings.Join(flagEntries, "\n\n"))
+	}
+
+	if examples := commandExamples[command]; len(examples) > 0 {
+		log.Println("\n" + utils.Bold("EXAMPLES"))
+		printExamples(examples)
+	}
+
+	log.Println("\nGlobal flags like \"-q\" and \"-n\" work with every command, see \"spicetify -h\".")
+}
+
+// printWorkflows prints common tasks step by step
+func printWorkflows() {
+	for i, workflow := range workflows {
+		if i > 0 {
+			log.Println()
+		}
+		utils.PrintBold(workflow.title)
+		printExamples(workflow.steps)
+	}
+	log.Println("\nRun \"spicetify help <command>\" for details of a command.")
+}
+
+func printExamples(examples []helpExample) {
+	for _, example := range examples {
+		log.Println("    " + example.about + ":")
+		log.Println("        " + utils.Green(example.command))
+	}
+}
+
+// helpEntryRegex matches first line of a command or flag entry in help
+// text, e.g. "apply               Apply customization." or
+// "-c, --config        Print config file path and quit"
+var helpEntryRegex = regexp.MustCompile(`^([a-z-][^ ]*(?:,? [^ ]+)*?)(?:\s{2,}\S.*)?$`)
+
+// getHelpEntries splits command and flag sections of help text into
+// entries, keyed by command name or long flag name, e.g. "apply" or
+// "--full", and names in order they appear. Flags listed on consecutive lines share
+// description below them. First entry of a name wins.
+func getHelpEntries() (map[string][]string, []string) {
+	text := helpText()
+	text = text[strings.Index(text, "CHAINABLE COMMANDS"):]
+
+	entries := map[string][]string{}
+	order := []string{}
+	group := []string{}
+	lines := []string{}
+	described := false
+	flush := func() {
+		for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+			lines = lines[:len(lines)-1]
+		}
+		for i, name := range group {
+			if _, ok := entries[name]; ok {
+				continue
+			}
+			entries[name] = lines
+			// Grouped flags appear in order once
+			if i == 0 {
+				order = append(order, name)
+			}
+		}
+		group, lines, described = []string{}, []string{}, false
+	}
+
+	for _, line := range strings.Split(text, "\n")[1:] {
+		if len(strings.TrimSpace(line)) == 0 || line[0] == ' ' {
+			if len(group) > 0 {
+				lines = append(lines, strings.TrimRight(line, " "))
+				described = true
+			}
+			continue
+		}
+
+		match := helpEntryRegex.FindStringSubmatch(line)
+		if match == nil {
+			flush()
+			continue
+		}
+		if described {
+			flush()
+		}
+
+		fields := strings.Fields(strings.Replace(match[1], ",", "", -1))
+		name := fields[0]
+		for _, field := range fields {
+			if strings.HasPrefix(field, "--") {
+				name = field
+			}
+		}
+		group = append(group, name)
+		lines = append(lines, line)
+		described = described || len(line) > len(match[1])
+	}
+	flush()
+
+	return entries, order
+}
+
+// getHelpEntry returns help text of command or flag `name`
+func getHelpEntry(name string) []string {
+	entries, _ := getHelpEntries()
+	return entries[name]
+}
+
+// flagCommandsRegex matches commands a flag description says it works
+// with, e.g. `Use with "apply" or "update"`
+var flagCommandsRegex = regexp.MustCompile(`(?:Use|Works|,)\s+with\s+((?:"[^"]+"(?:,\s+|\s+or\s+|\s+and\s+)?)+)`)
+
+// getCommandFlags returns help text of flags whose description says they
+// work with `command`
+func getCommandFlags(command string) []string {
+	mention := regexp.MustCompile(`"` + regexp.QuoteMeta(command) + `["\s]`)
+	entries, order := getHelpEntries()
+
+	flagEntries := []string{}
+	for _, name := range order {
+		if !strings.HasPrefix(name, "-") {
+			continue
+		}
+		text := strings.Join(entries[name], "\n")
+		for _, match := range flagCommandsRegex.FindAllStringSubmatch(text, -1) {
+			if mention.MatchString(match[1]) {
+				flagEntries = append(flagEntries, text)
+				break
+			}
+		}
+	}
+	return flagEntries
+}
+
+// getHelpCommands returns names of commands in help text
+func getHelpCommands() []string {
+	_, order := getHelpEntries()
+	names := []string{}
+	for _, name := range order {
+		if !strings.HasPrefix(name, "-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -219,6 +219,39 @@ Spicetify.LocalStorage = {
     get: (key) => localStorage.getItem(key),
     remove: (key) => localStorage.removeItem(key),
     set: (key, value) => localStorage.setItem(key, value),
+    // Storage bucket of an extension, whose keys cannot collide with other
+    // extensions'. Extension id is its file name, which spicetify puts in
+    // "data-extension-id" of its script tag, so it can be omitted when called
+    // synchronously while extension script loads. Modules can pass
+    // `import.meta.url` instead.
+    scoped: (id) => {
+        id = id || document.currentScript?.dataset.extensionId;
+        if (!id) {
+            throw new Error("Spicetify.LocalStorage.scoped: extension id is unknown. Call it when script loads or pass extension file name.");
+        }
+        id = id.split(/[?#]/)[0].split("/").pop();
+
+        const prefix = `spicetify:extension:${id}:`;
+        const keys = () => {
+            const list = [];
+            for (let i = 0; i < localStorage.length; i++) {
+                const key = localStorage.key(i);
+                if (key.startsWith(prefix)) {
+                    list.push(key.slice(prefix.length));
+                }
+            }
+            return list;
+        };
+
+        return {
+            id,
+            get: (key) => localStorage.getItem(prefix + key),
+            set: (key, value) => localStorage.setItem(prefix + key, value),
+            remove: (key) => localStorage.removeItem(prefix + key),
+            keys,
+            clear: () => keys().forEach((key) => localStorage.removeItem(prefix + key)),
+        };
+    },
 };
 
 (function waitMouseTrap() {
@@ -825,7 +858,7 @@ Spicetify.ContextMenu = (function () {
     return { Item, SubMenu, _addItems };
 })();
 
-Spicetify._cloneSidebarItem = function(list) {
+Spicetify._cloneSidebarItem = function(list, routes) {
     function findChild(parent, key, value) {
         if (!parent.props) return null;
 
@@ -862,41 +895,51 @@ Spicetify._cloneSidebarItem = function(list) {
         const appProper = manifest.name || (app[0].toUpperCase() + app.slice(1));
         const icon = manifest.icon || "";
         const activeIcon = manifest["active-icon"] || icon;
-
-        const appLink = "/" + app;
-        const link = findChild(Spicetify._sidebarItemToClone, "className", "link-subtle main-navBar-navBarLink");
-        const span = findChild(link, "as", "span");
-        const obj = React.cloneElement(
-            Spicetify._sidebarItemToClone,
-            null,
-            React.cloneElement(
-                link,
-                {
-                    to: appLink,
-                    isActive: (e, {pathname: t})=> t.startsWith(appLink),
-                },
-                React.createElement(
-                    "div",
+        const route = (routes && routes[app]) || app;
+
+        // Without "nav", app has one entry linking to its route
+        let entries = Array.isArray(manifest.nav) && manifest.nav.length ? manifest.nav : [{}];
+        entries = entries.filter(entry => !entry.path || /^\/*[\w-]+(\/[\w-]+)*\/*$/.test(entry.path));
+
+        for (const entry of entries) {
+            const path = (entry.path || "").replace(/^\/+|\/+$/g, "");
+            const appLink = "/" + route + (path ? "/" + path : "");
+            const entryIcon = entry.icon || icon;
+            const entryActiveIcon = entry["active-icon"] || entry.icon || activeIcon;
+            const link = findChild(Spicetify._sidebarItemToClone, "className", "link-subtle main-navBar-navBarLink");
+            const span = findChild(link, "as", "span");
+            const obj = React.cloneElement(
+                Spicetify._sidebarItemToClone,
+                null,
+                React.cloneElement(
+                    link,
                     {
-                        className: "icon collection-icon",
-                        dangerouslySetInnerHTML: {
-                            __html: icon,
-                        }
+                        to: appLink,
+                        isActive: (e, {pathname: t})=> t === appLink || t.startsWith(appLink + "/"),
                     },
-                ),
-                React.createElement(
-                    "div",
-                    {
-                        className: "icon collection-active-icon",
-                        dangerouslySetInnerHTML: {
-                            __html: activeIcon,
-                        }
-                    },
-                ),
-                React.cloneElement(span, null, appProper)
+                    React.createElement(
+                        "div",
+                        {
+                            className: "icon collection-icon",
+                            dangerouslySetInnerHTML: {
+                                __html: entryIcon,
+                            }
+                        },
+                    ),
+                    React.createElement(
+                        "div",
+                        {
+                            className: "icon collection-active-icon",
+                            dangerouslySetInnerHTML: {
+                                __html: entryActiveIcon,
+                            }
+                        },
+                    ),
+                    React.cloneElement(span, null, entry.name || appProper)
+                )
             )
-        )
-        reactObjs.push(obj);
+            reactObjs.push(obj);
+        }
     }
     return reactObjs;
 }
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/khanhas/spicetify-cli/src/cmd"
 	"github.com/khanhas/spicetify-cli/src/utils"
@@ -20,18 +23,83 @@ var (
 	flags          = []string{}
 	commands       = []string{}
 	quiet          = false
+	verbose        = false
+	waitLock       = false
 	extensionFocus = false
 	appFocus       = false
 	noRestart      = false
 	liveUpdate     = false
+	cmdFlags       = cmd.Flag{}
+	flagValues     = map[string][]string{}
+	// valueFlags lists flags that take a value
+	valueFlags = map[string]bool{
+		"--from":              true,
+		"--exclude":           true,
+		"--app-args":          true,
+		"--timeout":           true,
+		"--spotify-version":   true,
+		"--extensions-from":   true,
+		"--apps-from":         true,
+		"--theme":             true,
+		"--print-injected-js": true,
+		"--json-report":       true,
+		"--backup":            true,
+		"--color-scheme":      true,
+		"--compare-version":   true,
+		"--note":              true,
+		"--install":           true,
+	}
 )
 
+// lockedCommands modify Spotify or backup, so only one spicetify process may
+// run them at a time
+var lockedCommands = map[string]bool{
+	"backup":      true,
+	"clear":       true,
+	"apply":       true,
+	"update":      true,
+	"restore":     true,
+	"auto":        true,
+	"check":       true,
+	"patch":       true,
+	"uninstall":   true,
+	"refresh-raw": true,
+}
+
+// chainableCommands can run in order in one invocation, so they can also
+// run against every configured Spotify install with "--all-installs"
+var chainableCommands = map[string]bool{
+	"backup":          true,
+	"clear":           true,
+	"refresh-raw":     true,
+	"apply":           true,
+	"update":          true,
+	"restore":         true,
+	"enable-devtool":  true,
+	"disable-devtool": true,
+	"restart":         true,
+	"auto":            true,
+	"check":           true,
+	"patch":           true,
+	"diff-backup":     true,
+	"verify":          true,
+	"uninstall":       true,
+}
+
+// backupOverrideCommands only read backup, so they can use one given with
+// "--backup" instead of configured backup folder
+var backupOverrideCommands = map[string]bool{
+	"restore":     true,
+	"check":       true,
+	"diff-backup": true,
+}
+
 func init() {
 	if runtime.GOOS != "windows" &&
 		runtime.GOOS != "darwin" &&
 		runtime.GOOS != "linux" {
 		utils.PrintError("Unsupported OS.")
-		os.Exit(1)
+		utils.Exit(1)
 	}
 
 	log.SetFlags(0)
@@ -39,25 +107,58 @@ func init() {
 	log.SetOutput(colorable.NewColorableStdout())
 
 	// Separates flags and commands
-	for _, v := range os.Args[1:] {
-		if v[0] == '-' && v != "-1" {
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		v := args[i]
+		if len(v) > 1 && v[0] == '-' && v != "-1" {
 			if v[1] != '-' && len(v) > 2 {
 				for _, char := range v[1:] {
 					flags = append(flags, "-"+string(char))
 				}
-			} else {
-				flags = append(flags, v)
+				continue
+			}
+
+			// Value flags accept both "--flag value" and "--flag=value"
+			name, value, hasValue := v, "", false
+			if index := strings.Index(v, "="); index > -1 {
+				name, value, hasValue = v[:index], v[index+1:], true
 			}
+
+			if valueFlags[name] {
+				if !hasValue && i+1 < len(args) {
+					i++
+					value, hasValue = args[i], true
+				}
+
+				if !hasValue {
+					utils.PrintError(`Flag "` + name + `" requires a value.`)
+					utils.Exit(utils.ExitConfigError)
+				}
+
+				flagValues[name] = append(flagValues[name], value)
+			}
+
+			flags = append(flags, name)
 		} else {
 			commands = append(commands, v)
 		}
 	}
 
+	// Color flags go first so that they also affect help text
+	for _, v := range flags {
+		switch v {
+		case "--no-color":
+			utils.SetColor(false)
+		case "--force-color":
+			utils.SetColor(true)
+		}
+	}
+
 	for _, v := range flags {
 		switch v {
 		case "-c", "--config":
 			fmt.Println(cmd.GetConfigPath())
-			os.Exit(0)
+			utils.Exit(0)
 		case "-h", "--help":
 			kind := ""
 			if len(commands) > 0 {
@@ -65,53 +166,293 @@ func init() {
 			}
 			if kind == "config" {
 				helpConfig()
+			} else if len(kind) > 0 && kind != "help" {
+				helpCommand(kind)
 			} else {
 				help()
 			}
 
-			os.Exit(0)
+			utils.Exit(0)
 		case "-v", "--version":
 			fmt.Println(version)
-			os.Exit(0)
+			utils.Exit(0)
 		case "-e", "--extension":
 			extensionFocus = true
 		case "-a", "--app":
 			appFocus = true
 		case "-q", "--quiet":
 			quiet = true
+		case "--verbose":
+			verbose = true
 		case "-n", "--no-restart":
 			noRestart = true
 		case "-l", "--live-update":
 			liveUpdate = true
+		case "--full":
+			cmdFlags.Full = true
+		case "--verify":
+			cmdFlags.Verify = true
+		case "--wait":
+			waitLock = true
+		case "--fail-on-warning":
+			utils.SetFailOnWarning(true)
+		case "--interactive":
+			cmdFlags.Interactive = true
+		case "--assets-only":
+			cmdFlags.AssetsOnly = true
+		case "--repair":
+			cmdFlags.Repair = true
+		case "--measure-size":
+			cmdFlags.MeasureSize = true
+		case "--locked":
+			cmdFlags.Locked = true
+		case "--backup-version-check-only":
+			cmdFlags.BackupVersionCheckOnly = true
+		case "--no-backup-check":
+			cmdFlags.NoBackupCheck = true
+		case "--strict-extensions":
+			cmdFlags.StrictExtensions = true
+		case "--strict-apps":
+			cmdFlags.StrictApps = true
+		case "--strict-colors":
+			cmdFlags.StrictColors = true
+		case "--keep-going":
+			cmdFlags.KeepGoing = true
+		case "--incremental":
+			cmdFlags.Incremental = true
+		case "--force":
+			cmdFlags.Force = true
+		case "--purge":
+			cmdFlags.Purge = true
+		case "--accent-follow-system":
+			cmdFlags.AccentFollowSystem = true
+		case "--json":
+			cmdFlags.JSON = true
+		case "--unsafe":
+			cmdFlags.Unsafe = true
+		case "--mjs":
+			cmdFlags.MJS = true
+		case "--js":
+			cmdFlags.MJS = false
+		case "--typescript":
+			cmdFlags.TypeScript = true
+		case "--register":
+			cmdFlags.Register = true
+		case "--no-raw-copy":
+			cmdFlags.NoRawCopy = true
+		case "--assume-applied":
+			cmdFlags.AssumeApplied = true
+		case "--assume-fresh":
+			cmdFlags.AssumeFresh = true
+		case "--watch-spotify":
+			cmdFlags.WatchSpotify = true
+		case "--from":
+			cmdFlags.From = lastValue(v)
+		case "--exclude":
+			cmdFlags.Exclude = flagValues[v]
+		case "--app-args":
+			cmdFlags.AppArgs = flagValues[v]
+		case "--spotify-version":
+			utils.SetSpotifyVersionOverride(lastValue(v))
+		case "--extensions-from":
+			cmdFlags.ExtensionsFrom = lastValue(v)
+		case "--apps-from":
+			cmdFlags.AppsFrom = lastValue(v)
+		case "--theme":
+			cmdFlags.Theme = lastValue(v)
+		case "--color-scheme":
+			cmdFlags.ColorScheme = lastValue(v)
+		case "--compare-version":
+			cmdFlags.CompareVersion = lastValue(v)
+		case "--note":
+			cmdFlags.Note = lastValue(v)
+		case "--install":
+			cmdFlags.Install = lastValue(v)
+		case "--all-installs":
+			cmdFlags.AllInstalls = true
+		case "--print-injected-js":
+			cmdFlags.PrintInjectedJS = lastValue(v)
+		case "--json-report":
+			utils.StartReport(lastValue(v), commands)
+		case "--backup":
+			cmdFlags.Backup = lastValue(v)
+			for _, command := range commands {
+				if !backupOverrideCommands[command] {
+					utils.PrintError(`"--backup" can only be used with "restore", "check" or "diff-backup".`)
+					utils.Exit(utils.ExitConfigError)
+				}
+			}
+		case "--timeout":
+			timeout, err := parseTimeout(lastValue(v))
+			if err != nil {
+				utils.PrintError(`Invalid "--timeout": ` + err.Error())
+				utils.Exit(utils.ExitConfigError)
+			}
+			utils.SetHTTPTimeout(timeout)
 		}
 	}
 
 	if quiet {
-		log.SetOutput(ioutil.Discard)
-		os.Stdout = nil
+		utils.SetVerbosity(utils.VerbosityQuiet)
+	} else if verbose {
+		utils.SetVerbosity(utils.VerbosityVerbose)
+	}
+
+	if override := utils.GetSpotifyVersionOverride(); len(override) > 0 {
+		utils.PrintWarning(`Spotify version is overridden to "` + override + `" for compatibility checks.`)
+	}
+
+	// Help commands need no config
+	if len(commands) > 0 {
+		switch commands[0] {
+		case "help":
+			if len(commands) == 1 {
+				help()
+			} else {
+				helpCommand(commands[1])
+			}
+			utils.Exit(0)
+		case "examples":
+			printWorkflows()
+			utils.Exit(0)
+		}
 	}
 
 	cmd.InitConfig(quiet)
+	cmd.InitFlags(cmdFlags)
 
 	if len(commands) < 1 {
 		utils.PrintInfo(`Run "spicetify -h" for commands list.`)
-		os.Exit(0)
+		utils.Exit(0)
+	}
+
+	if cmdFlags.AllInstalls {
+		for _, command := range commands {
+			if !chainableCommands[command] {
+				utils.PrintError(`"--all-installs" cannot be used with "` + command + `".`)
+				utils.Exit(utils.ExitConfigError)
+			}
+		}
+		if cmdFlags.WatchSpotify {
+			utils.PrintError(`"--all-installs" cannot be used with "--watch-spotify".`)
+			utils.Exit(utils.ExitConfigError)
+		}
 	}
 }
 
 func main() {
+	run()
+	utils.Exit(utils.ExitCode())
+}
+
+// run executes commands. Commands that cannot continue exit by themselves,
+// otherwise exit code is decided by main after run returns.
+func run() {
 	// Non-chainable commands
 	switch commands[0] {
 	case "config":
 		commands = commands[1:]
 		if len(commands) == 0 {
 			cmd.DisplayAllConfig()
+		} else if len(commands) == 1 && commands[0] == "edit" {
+			cmd.OpenConfigEditor()
+		} else if len(commands) == 1 && commands[0] == "undo" {
+			cmd.UndoConfig()
+		} else if len(commands) == 1 && commands[0] == "dump" {
+			cmd.DumpConfig(version)
 		} else if len(commands) == 1 {
 			cmd.DisplayConfig(commands[0])
 		} else {
 			cmd.EditConfig(commands)
 		}
 		return
+	case "lock":
+		cmd.Lock()
+		return
+	case "apply":
+		// Only prints, so it needs neither Spotify nor lock
+		if len(cmdFlags.PrintInjectedJS) > 0 {
+			cmd.PrintInjectedJS(cmdFlags.PrintInjectedJS)
+			return
+		}
+	case "cache":
+		if len(commands) == 2 && commands[1] == "info" {
+			cmd.CacheInfo()
+		} else if len(commands) == 2 && commands[1] == "clean" {
+			cmd.CleanCache()
+		} else {
+			utils.PrintError(`Usage: "spicetify cache info" or "spicetify cache clean".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		return
+	case "validate-manifest":
+		if len(commands) != 2 {
+			utils.PrintError(`Usage: "spicetify validate-manifest <path>".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		cmd.ValidateManifest(commands[1])
+		return
+	case "extensions":
+		commands = commands[1:]
+		if len(commands) == 2 && commands[0] == "scaffold" {
+			cmd.ScaffoldExtension(commands[1])
+		} else if len(commands) == 2 && commands[0] == "test" {
+			cmd.TestExtension(commands[1])
+		} else if (len(commands) == 2 || len(commands) == 3) && commands[0] == "install" {
+			name := ""
+			if len(commands) == 3 {
+				name = commands[2]
+			}
+			cmd.InstallExtension(commands[1], name)
+		} else {
+			utils.PrintError(`Usage: "spicetify extensions scaffold <name>", "spicetify extensions test <file>" or "spicetify extensions install <source> [<name>]".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		return
+	case "apps":
+		if len(commands) != 3 || commands[1] != "inspect" {
+			utils.PrintError(`Usage: "spicetify apps inspect <app>".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		cmd.InspectApp(commands[2])
+		return
+	case "backup":
+		if len(commands) == 2 && commands[1] == "list" {
+			// Only reads, so it needs no lock
+			cmd.InitPaths()
+			cmd.ListBackups()
+			return
+		}
+	case "patches":
+		if len(commands) != 2 || commands[1] != "list" {
+			utils.PrintError(`Usage: "spicetify patches list".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		// Only reads, so it needs no lock
+		cmd.InitPaths()
+		cmd.ListPatches()
+		return
+	case "themes":
+		commands = commands[1:]
+		if len(commands) == 1 && commands[0] == "list" {
+			cmd.ListThemes()
+		} else if (len(commands) == 2 || len(commands) == 3) && commands[0] == "preview" {
+			output := ""
+			if len(commands) == 3 {
+				output = commands[2]
+			}
+			cmd.ThemePreview(commands[1], output)
+		} else if (len(commands) == 2 || len(commands) == 3) && commands[0] == "install" {
+			name := ""
+			if len(commands) == 3 {
+				name = commands[2]
+			}
+			cmd.InstallTheme(commands[1], name)
+		} else {
+			utils.PrintError(`Usage: "spicetify themes list", "spicetify themes preview <name> [<output>]" or "spicetify themes install <url> [<name>]".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		return
 	case "color":
 		commands = commands[1:]
 		if len(commands) == 0 {
@@ -152,15 +493,61 @@ func main() {
 	case "upgrade":
 		cmd.Upgrade(version)
 		return
+
+	case "collect-logs":
+		if len(commands) != 2 {
+			utils.PrintError(`Usage: "spicetify collect-logs <output>".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		cmd.CollectLogs(commands[1], version)
+		return
+
+	case "check":
+		// Only reads, so it skips upgrade check and lock to stay cheap
+		if cmdFlags.BackupVersionCheckOnly && !cmdFlags.AllInstalls {
+			cmd.InitPaths()
+			cmd.CheckBackupVersion()
+			return
+		}
 	}
 
+	utils.StartLogFile(cmd.GetLogPath(), os.Args[1:])
 	utils.PrintBold("spicetify v" + version)
 	cmd.CheckUpgrade(version)
 
+	if cmdFlags.AllInstalls {
+		utils.Exit(runAllInstalls())
+	}
+
 	cmd.InitPaths()
 
 	// Unchainable commands
 	switch commands[0] {
+	case "prefs":
+		if len(commands) == 2 && commands[1] == "backup" {
+			cmd.BackupPrefs()
+		} else if len(commands) <= 3 && len(commands) >= 2 && commands[1] == "restore" {
+			name := ""
+			if len(commands) == 3 {
+				name = commands[2]
+			}
+			cmd.RestorePrefs(name)
+		} else {
+			utils.PrintError(`Usage: "spicetify prefs backup" or "spicetify prefs restore [<name>]".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		return
+	case "css-history":
+		if len(commands) == 1 {
+			cmd.CSSHistory()
+		} else if len(commands) == 4 && commands[1] == "diff" {
+			cmd.CSSHistoryDiff(commands[2], commands[3])
+		} else {
+			utils.PrintError(`Usage: "spicetify css-history" or "spicetify css-history diff <a> <b>".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		return
+
 	case "watch":
 		var name []string
 		if len(commands) >  1 {
@@ -176,7 +563,102 @@ func main() {
 		return
 	}
 
-	// Chainable commands
+	checkChainFlags()
+
+	if needsLock() {
+		cmd.AcquireLock(waitLock)
+		defer cmd.ReleaseLock()
+	}
+
+	runChain()
+
+	if cmdFlags.WatchSpotify {
+		// Lock is only held while reapplying, so other commands can run
+		cmd.ReleaseLock()
+		cmd.WatchSpotify(restartSpotify)
+	}
+}
+
+// checkChainFlags exits when flags are given without commands they alter
+func checkChainFlags() {
+	if len(cmdFlags.Note) > 0 && !containsCommand(commands, "backup") {
+		utils.PrintError(`"--note" can only be used with "backup".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	if cmdFlags.WatchSpotify && !containsCommand(commands, "apply") {
+		utils.PrintError(`"--watch-spotify" can only be used with "apply".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+}
+
+func needsLock() bool {
+	for _, v := range commands {
+		if lockedCommands[v] {
+			return true
+		}
+	}
+	return false
+}
+
+// runAllInstalls runs chainable commands against every configured Spotify
+// install in turn, carrying on when one fails, and returns exit code of
+// them all: code of every install when they agree, otherwise partial
+// failure when some succeeded.
+func runAllInstalls() int {
+	checkChainFlags()
+
+	if needsLock() {
+		cmd.AcquireLock(waitLock)
+		defer cmd.ReleaseLock()
+	}
+
+	names := cmd.GetInstallNames()
+	codes := make([]int, len(names))
+	for i, name := range names {
+		if err := cmd.UseInstall(name); err != nil {
+			utils.PrintError(err.Error())
+			codes[i] = utils.ExitConfigError
+			continue
+		}
+		utils.PrintBold("Spotify install: " + cmd.GetInstallLabel())
+		codes[i] = utils.CatchExit(func() {
+			cmd.InitPaths()
+			runChain()
+		})
+	}
+
+	utils.PrintBold("Summary:")
+	succeeded := 0
+	for i, code := range codes {
+		label := names[i]
+		if len(label) == 0 {
+			label = "main"
+		}
+		if code == 0 || code == utils.ExitWarning {
+			utils.PrintSuccess(label)
+			succeeded++
+		} else {
+			utils.PrintError(label + " (exit code " + strconv.Itoa(code) + ")")
+		}
+	}
+
+	switch {
+	case succeeded == len(codes):
+		return utils.ExitCode()
+	case succeeded > 0:
+		return utils.ExitPartialFailure
+	}
+	for _, code := range codes[1:] {
+		if code != codes[0] {
+			return utils.ExitFailure
+		}
+	}
+	return codes[0]
+}
+
+// runChain runs chainable commands in order against current Spotify install
+func runChain() {
 	for _, v := range commands {
 		switch v {
 		case "backup":
@@ -185,6 +667,9 @@ func main() {
 		case "clear":
 			cmd.Clear()
 
+		case "refresh-raw":
+			cmd.RefreshRaw()
+
 		case "apply":
 			cmd.Apply()
 			restartSpotify()
@@ -215,12 +700,66 @@ func main() {
 			cmd.Auto()
 			restartSpotify()
 
+		case "check":
+			if cmdFlags.BackupVersionCheckOnly {
+				cmd.CheckBackupVersion()
+			} else {
+				cmd.Check()
+			}
+
+		case "patch":
+			cmd.ReapplyPatches()
+			restartSpotify()
+
+		case "diff-backup":
+			cmd.DiffBackup()
+
+		case "verify":
+			cmd.Verify()
+
+		case "uninstall":
+			cmd.Uninstall(cmdFlags.Purge)
+			restartSpotify()
+
 		default:
 			utils.PrintError(`Command "` + v + `" not found.`)
 			utils.PrintInfo(`Run "spicetify -h" for list of valid commands.`)
-			os.Exit(1)
+			utils.Exit(utils.ExitConfigError)
+		}
+	}
+}
+
+func containsCommand(commands []string, name string) bool {
+	for _, v := range commands {
+		if v == name {
+			return true
 		}
 	}
+	return false
+}
+
+// lastValue returns the last value given to value flag `name`
+func lastValue(name string) string {
+	values := flagValues[name]
+	return values[len(values)-1]
+}
+
+// parseTimeout parses "--timeout" value, a Go duration like "90s" or "2m",
+// or plain number of seconds.
+func parseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		value = strconv.FormatFloat(seconds, 'f', -1, 64) + "s"
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, errors.New("must be positive")
+	}
+
+	return timeout, nil
 }
 
 func restartSpotify() {
@@ -231,23 +770,55 @@ func restartSpotify() {
 
 func help() {
 	utils.PrintBold("spicetify v" + version)
-	log.Println(utils.Bold("USAGE") + "\n" +
-		"spicetify [-q] [-e] [-a] \x1B[4mcommand\033[0m...\n" +
+	log.Println(helpText())
+}
+
+func helpText() string {
+	return utils.Bold("USAGE") + "\n" +
+		"spicetify [-q] [-e] [-a] " + utils.Underline("command") + "...\n" +
 		"spicetify {-c | --config} | {-v | --version} | {-h | --help}\n\n" +
 		utils.Bold("DESCRIPTION") + "\n" +
 		"Customize Spotify client UI and functionality\n\n" +
 		utils.Bold("CHAINABLE COMMANDS") + `
 backup              Start backup and preprocessing app files.
+                    Use "--note <text>" to record why backup is made.
+                    List current backup and previous ones kept with
+                    "--incremental", with Spotify version, date and note.
+                    Use "--json" for JSON output:
+                    spicetify backup list
 
 apply               Apply customization.
 
 update              On default, update theme CSS and colors.
                     Use with flag "-e" to update extensions.
 
+patch               Run patches of [Patch] config section again on applied
+                    Spotify, without a full apply, e.g. after editing them.
+                    Spotify must have been applied before.
+
 restore             Restore Spotify to original state.
+                    Use "--backup <dir>" to restore from a backup folder
+                    other than configured one, e.g. copied from another
+                    machine.
+
+uninstall           Restore Spotify to original state, from backup if it
+                    matches Spotify version, otherwise by removing files
+                    "apply" added. Config, backup and user Themes,
+                    Extensions and CustomApps are kept, unless "--purge" is
+                    used. Spotify user data is never touched.
+
+auto                Check Spotify state, back up and apply again if needed,
+                    e.g. after Spotify updated itself, then launch Spotify.
+                    Meant to run unattended, e.g. from a shortcut, so every
+                    prompt takes its quiet mode answer.
 
 clear               Clear current backup files.
 
+refresh-raw         Extract and preprocess current backup again, replacing
+                    stock assets that "apply" copies to Spotify, e.g. when
+                    they are left from a backup of older Spotify version.
+                    Backup must match installed Spotify version.
+
 enable-devtool      Enable Spotify's developer tools.
                     Hit Ctrl + Shift + I in the client to start using.
 
@@ -257,9 +828,36 @@ watch               Enter watch mode.
                     On default, update CSS on color.ini or user.css's changes.
                     Use with flag "-e" to update extensions on changes.
 
-restart             Restart Spotify client.
+check               Check whether Spotify has overwritten spicetify changes,
+                    e.g. by updating itself. If so, reapply when config
+                    "reapply_on_revert" is 1, otherwise exit with code 6.
+                    With "--backup-version-check-only", only report whether
+                    backup matches Spotify version, see below.
+
+diff-backup         Compare files in Spotify Apps folder against backup by
+                    checksum and print number of added, removed and changed
+                    files of each app, e.g. to see what a Spotify update
+                    changed. Use "--verbose" to list every file.
+
+verify              Check that every file last "apply" added or modified in
+                    Spotify is still in place and unchanged, by checksum,
+                    without changing anything. Missing or modified files are
+                    listed and make spicetify exit with code 6. Bring them
+                    back with "spicetify apply --repair".
+
+restart             Restart Spotify client. Handles normal, Windows Store,
+                    Flatpak and Snap installs. Can be chained after other
+                    commands, e.g. "spicetify -n apply restart".
 
 ` + utils.Bold("NON-CHAINABLE COMMANDS") + `
+help                Print description, flags and examples of <command>:
+                    spicetify help <command>
+                    Same as "spicetify <command> -h".
+
+examples            Print common workflows step by step: first-time setup,
+                    updating a theme, adding an extension and recovering
+                    after a Spotify update.
+
 path                Print path of color, css, extension file or
                     custom app directory and quit.
                     1. Print all theme's assests:
@@ -306,6 +904,22 @@ config              1. Print all config fields and values:
                     - Disable "inject_css" and enable "song_page"
                     spicetify config inject_css 0 song_page 1
 
+                    4. Open config file in $EDITOR, then validate it
+                    after editor exits.
+                    spicetify config edit
+
+                    5. Print effective configuration, including defaults,
+                    resolved color scheme, paths and versions, e.g. to
+                    attach to bug reports. Use "--json" for JSON output.
+                    Sensitive values are redacted unless "--unsafe" is used.
+                    spicetify config dump
+
+                    6. Restore config file to the version before last change
+                    made by spicetify, e.g. by "config" command, after
+                    showing what is restored. Up to 10 previous versions are
+                    kept, so it can be repeated.
+                    spicetify config undo
+
 color               1. Print all color fields and values. 
                     spicetify color
 
@@ -323,12 +937,127 @@ color               1. Print all color fields and values.
                     - Change slider_bg to 00ff00 and pressing_fg to 0000ff
                     spicetify color slider_bg 00ff00 pressing_fg 0000ff
 
-upgrade             Upgrade spicetify latest version
+prefs               1. Back up Spotify "prefs" file to spicetify config
+                    folder, separately from app files backup:
+                    spicetify prefs backup
+
+                    2. Restore latest, or named, "prefs" backup. Backup is
+                    checked to be a valid "prefs" file first:
+                    spicetify prefs restore [<name>]
+
+css-history         1. List previous versions of user.css kept when config
+                    "css_history" is enabled, newest first:
+                    spicetify css-history
+
+                    2. Print line changes between two versions, each a
+                    number from the list or "current":
+                    spicetify css-history diff 2 current
+
+cache               Downloads, compatibility index and compiled SCSS are
+                    cached, and cached downloads are used while offline.
+
+                    1. Print cache folder location, number of entries and
+                    total size:
+                    spicetify cache info
+
+                    2. Remove every cached file and print reclaimed space:
+                    spicetify cache clean
+
+lock                Write "spicetify.lock" in spicetify folder, pinning
+                    content of configured extensions and custom apps by
+                    sha256, to commit with config for reproducible setups.
+                    "source" of an entry can be edited to a URL (".zip" for
+                    folders) that "apply --locked" downloads it from when
+                    missing. Run again to accept changes.
+
+validate-manifest   Check custom app manifest against its schema and print
+                    every problem found. <path> is manifest.json or custom
+                    app folder.
+                    spicetify validate-manifest <path>
+
+extensions          Generate a starter extension in user Extensions folder:
+                    spicetify extensions scaffold <name>
+                    Use "--mjs" for a module, "--typescript" for TypeScript
+                    source (compile it to <name>.js before applying) or
+                    "--js" (default). Use "--register" to also add it to
+                    config "extensions".
+
+                    Check an extension file, folder or installed extension
+                    name for common mistakes without applying it: file
+                    type, Javascript syntax (with Node.js if installed),
+                    missing check that Spicetify APIs are ready, malformed
+                    "spicetify_map" comments and "spicetify_platform"
+                    header:
+                    spicetify extensions test <file>
+
+                    Download an extension file to user Extensions folder,
+                    named <name> or after its file. <source> is
+                    "gh:owner/repo/path/ext.js", optionally pinned to a
+                    branch or tag with "@<ref>", or URL of a ".js" or
+                    ".mjs" file. It is checked like "extensions test" and
+                    shown for confirmation first, so in quiet mode it is
+                    only installed with "--force". Use "--force" to replace
+                    an installed extension of the same name and
+                    "--register" to also add it to config "extensions":
+                    spicetify extensions install <source> [<name>]
+
+apps                Print how a custom app would be applied, as JSON,
+                    without writing anything: its folder, manifest, route,
+                    files concatenated into its main chunk in order, subfiles
+                    left out by "disabled_subfiles", additional chunks and
+                    manifest problems. Exits with error code 2 when there
+                    are problems:
+                    spicetify apps inspect <app>
+
+patches             List patches of [Patch] config section with file each
+                    one modifies, which of its find RegExps matches
+                    installed Spotify code and whether it is applied,
+                    changed in config since, or reverted by Spotify. Use
+                    "--json" for JSON output:
+                    spicetify patches list
+
+themes              1. List installed themes. Current themes are marked
+                    with "*". Use "--json" for JSON output, which includes
+                    path and preview image of each theme.
+                    spicetify themes list
+
+                    2. Print path of theme preview image ("preview.png" or
+                    "screenshot.png" in theme folder), or copy it to
+                    <output> file or folder:
+                    spicetify themes preview <name> [<output>]
+
+                    3. Download and install a theme to user Themes folder,
+                    named <name> or after <url>. <url> is a ".spicetify"
+                    package or zip archive, a GitHub repository or folder
+                    (".../tree/<branch>/<folder>"), its shorthand
+                    "gh:owner/repo[/folder][@<ref>]" with optional branch or
+                    tag <ref>, or a git repository. A local file or folder
+                    also works. Its content is shown for confirmation
+                    first. Use "--force" to replace an installed theme of
+                    the same name. Its "theme.js", if any, runs in Spotify
+                    whenever it is applied, so in quiet mode a theme with
+                    Javascript is only installed with "--force":
+                    spicetify themes install <url> [<name>]
+
+collect-logs        Bundle what is needed to triage a bug report into zip
+                    file <output>, to attach to an issue: effective config
+                    with paths and versions (as "config dump"), backup and
+                    Spotify state, record of files last "apply" changed and
+                    latest output of commands that change Spotify, which is
+                    kept in "spicetify.log" in spicetify folder. Sensitive
+                    values are redacted.
+                    spicetify collect-logs <output>
+
+upgrade             Upgrade spicetify latest version and update list of
+                    extensions and custom apps known to be broken on
+                    specific Spotify versions, which "apply" warns about.
 
 ` + utils.Bold("FLAGS") + `
--q, --quiet         Quiet mode (no output). Be careful, dangerous operations
-                    like clear backup, restore will proceed without prompting
-                    permission.
+-q, --quiet         Quiet mode: only print errors, warnings and final result.
+                    Be careful, dangerous operations like clear backup,
+                    restore will proceed without prompting permission.
+
+--verbose           Print more details, e.g. every file in "diff-backup".
 
 -e, --extension     Use with "update", "watch" or "path" command to
                     focus on extensions.
@@ -340,14 +1069,240 @@ upgrade             Upgrade spicetify latest version
 
 -l, --live-update   Use with "watch" command to auto-reload Spotify on change
 
+--from <dir>        Use Spotify Apps folder at <dir> instead of detected or
+                    configured Spotify location. Works with "backup",
+                    "apply" and "restore".
+
+--install <name>    Run chainable commands against Spotify install declared
+                    in "[Install <name>]" config section instead of main one.
+                    Use with "backup", "apply", "restore" or other command
+                    that works on Spotify.
+
+--all-installs      Run chainable commands against main and every declared
+                    Spotify install in turn, continuing when one fails, then
+                    print result of each. Use with "backup", "apply",
+                    "restore" or other chainable command. Exit code is that
+                    of every install when they agree, otherwise 5 if some
+                    succeeded or 1.
+
+--exclude <glob>    Use with "backup" to skip app packages whose file name
+                    matches <glob> (e.g. "*-media.spa"). Repeatable. Packages
+                    needed for a clean restore are always backed up. Excluded
+                    files are left as-is on "apply" and "restore".
+
+--app-args <args>   Pass extra arguments to Spotify when spicetify launches
+                    it, e.g. --app-args="--remote-debugging-port=9222".
+                    Quote arguments containing spaces. Repeatable. Only
+                    affects this run; use config "spotify_launch_flags" to
+                    always pass arguments.
+
+--timeout <time>    Give up network operations, e.g. in "upgrade", after
+                    <time>, which is a number of seconds or a duration like
+                    "2m". Default is 60 seconds. Cached data is used instead
+                    where there is one.
+
+--spotify-version <version>
+                    Pretend Spotify is at <version>, e.g. "1.1.70", instead
+                    of detected version, to test version dependent behavior
+                    like compatibility warnings. Also set by
+                    "SPICETIFY_SPOTIFY_VERSION" environment variable. Only
+                    compatibility checks use it: backups, apply records and
+                    "--compare-version" keep using installed version.
+
+--extensions-from <file>
+--apps-from <file>  Use with "apply", "update", "watch" or "path" to take
+                    extensions or custom apps from <file>, one per line,
+                    instead of config "extensions" or "custom_apps". Use "-"
+                    to read from stdin. Config is not changed.
+
+--theme <name>      Use <name> instead of config "current_theme" for this
+                    run. Separate theme layers with "|". Config is not
+                    changed.
+
+--color-scheme <name>
+                    Use color scheme <name> of current theme instead of
+                    config "color_scheme" for this run. Config is not
+                    changed.
+
+--full              Use with "apply" to reprocess everything. By default,
+                    when nothing but theme CSS, extensions or custom apps
+                    changed since last apply, only changed ones are updated.
+
+--no-color          Do not color output. Color is also disabled when output
+                    is not a terminal or "NO_COLOR" environment variable
+                    is set.
+
+--force-color       Always color output, even when it is not a terminal.
+
+--force             Use with "themes install" or "extensions install" to
+                    replace an installed theme or extension of the same
+                    name, and to install an extension or a theme with
+                    Javascript in quiet mode. Use with "--compare-version" to continue on
+                    another Spotify version, with a warning.
+
+--register          Use with "extensions scaffold" or "extensions install"
+                    to also add the extension to config "extensions".
+
+--purge             Use with "uninstall" to also delete spicetify folder:
+                    config, backup, cache and user Themes, Extensions and
+                    CustomApps. Asks for confirmation, so it is skipped in
+                    quiet mode.
+
+--note <text>       Use with "backup" to record <text> in backup, e.g.
+                    "before trying patch X", shown by "backup list".
+
+--incremental       Use with "backup" to keep replaced backup in history
+                    (3 latest) and hardlink files unchanged since then
+                    instead of copying them, saving space. Files are copied
+                    where hardlinks are not supported.
+
+--strict-extensions
+--strict-apps       Use with "apply" to fail with exit code 1 when any
+                    extension or custom app is missing or broken, checked
+                    before anything is modified. By default they are skipped
+                    and spicetify exits with code 5.
+
+--compare-version <version>
+                    Use with "apply", "update" or "patch" to refuse to run,
+                    with exit code 4, unless Spotify is at <version>, e.g.
+                    the one a theme was validated against in a managed
+                    setup. "1.1.70" matches "1.1.70.610.g4585142b". Unlike
+                    compatibility warnings, this is a hard gate, only
+                    skipped with "--force".
+
+--strict-colors     Use with "apply" or "update" to fail with exit code 1
+                    when a color scheme value is not a color. By default a
+                    warning names the color and its default is used.
+
+--keep-going        Use with "apply" to not stop when a stage fails, e.g.
+                    copying files: stages that need the failed one are
+                    skipped and the rest still run. Unapplied patches and
+                    failed stages are summarized at the end and spicetify
+                    exits with code 5. Next "apply" is a full one.
+
+--no-backup-check   Use with "apply" to continue even though there is no
+                    backup, e.g. for recovery. Changes cannot be undone with
+                    "restore" then. Without raw assets from a backup, it
+                    implies "--no-raw-copy". Not recommended.
+
+--no-raw-copy       Use with "apply" when Spotify is not applied yet to skip
+                    clearing Spotify Apps folder and copying raw assets into
+                    it. Modifications are applied on top of existing files,
+                    which may leave stale stock files behind.
+
+--assume-applied    Use with "apply" to treat Spotify as already applied,
+                    without checking. Apps folder is not cleared and raw
+                    assets are not copied, so if Spotify is actually stock,
+                    e.g. after an update, modifications land on top of stock
+                    files and parts of Spotify may break. Use it only when
+                    detection wrongly wants to wipe Apps folder.
+
+--assume-fresh      Use with "apply" to treat Spotify as not applied,
+                    without checking. Apps folder is cleared and raw assets
+                    from backup are copied again, discarding any manual
+                    edits in it. Needs a backup of current Spotify version.
+
+--watch-spotify     Use with "apply" to keep running after applying, checking
+                    Spotify every 30 seconds. Whenever Spotify overwrites
+                    spicetify changes, e.g. by updating itself, it waits for
+                    Spotify to finish, then backs up the new version if
+                    needed and applies again, like "auto". It stops
+                    reapplying a Spotify install that reverted changes 3
+                    times in a row, until Spotify changes again.
+
+--accent-follow-system
+                    Use with "apply" or "update" to color buttons with OS
+                    accent color, same as config "accent_follow_system".
+
+--interactive       Use with "apply" to pick extensions and custom apps to
+                    enable from installed ones in a checklist first. Picks
+                    are saved to config.
+
+--assets-only       Use with "apply" to only copy "assets" folder of theme to
+                    Spotify, skipping CSS, extensions, custom apps and
+                    patches. Quickest way to try images and fonts. Requires
+                    a full "apply" to have been done before.
+
+--print-injected-js <app>
+                    Use with "apply" to only print JS injected for custom
+                    app <app>: its main chunk, with index.js and subfiles
+                    concatenated, then its additional chunks. Nothing is
+                    written, so it does not need Spotify.
+
+--measure-size      Use with "apply" to report size of Spotify frontend
+                    folder before and after, and how much extensions,
+                    custom apps, assets, theme and patched stock files
+                    add to it. Use "--verbose" to list every file.
+
+--locked            Use with "apply" to fail when configured extensions or
+                    custom apps differ from "spicetify.lock", before
+                    anything is changed. Missing ones with URL source are
+                    downloaded and checked first.
+
+--repair            Use with "apply" to only bring back applied files that
+                    are missing or changed, e.g. after Spotify overwrote
+                    some of them, leaving the rest untouched. Files are
+                    checked against record of last apply, so a full "apply"
+                    must have been done before.
+
+--backup-version-check-only
+                    Use with "check" to only print whether backup is
+                    current, outdated, empty or corrupt, exiting with code
+                    0, 9, 3 or 10. Nothing else is checked, e.g. for a
+                    timer to run before "auto".
+
+--json-report <path>
+                    Write a JSON summary of the run to <path> when it ends,
+                    whether it succeeds or not, e.g. for dashboards. It has
+                    "version", "commands", "status" ("success",
+                    "partial-failure", "warning" or "failure"),
+                    "exit_code", "started_at", "duration_ms", "stages" (each
+                    with "name", "duration_ms" and "status" "ok" or
+                    "failed"), "pushed" ("extensions" and "custom_apps"
+                    transferred), "files" ("total", "added", "modified" and
+                    "by_kind" counts of applied files, or null),
+                    "warnings" and "errors".
+
+--backup <dir>      Use backup in <dir> instead of configured backup folder,
+                    with "restore", "check" or "diff-backup". Backups record
+                    their Spotify version inside, ones made before that are
+                    treated as mismatched.
+
+--wait              When another spicetify process is running "backup",
+                    "apply", "restore" or other command that modifies
+                    Spotify, wait for it to finish instead of exiting.
+
+--fail-on-warning   Exit with code 7 if any warning is printed, e.g. in CI.
+                    Warnings are still not fatal: commands run to the end.
+
+--verify            Use with "apply" to check afterwards that every extension
+                    and custom app file is in place and user.css is
+                    generated by spicetify. Problems are reported as warnings
+                    and make spicetify exit with code 5.
+
 -c, --config        Print config file path and quit
 
--h, --help          Print this help text and quit
+-h, --help          Print this help text and quit. After a command, print
+                    help of that command only.
 
 -v, --version       Print version number and quit
 
+` + utils.Bold("EXIT CODES") + `
+0                   Success
+1                   Other error, or a confirmation prompt was declined
+2                   Invalid config, flag or command
+3                   No backup is available
+4                   Spotify cannot be found or is not in a usable state
+5                   Finished, but some extensions or custom apps failed
+6                   Spotify has overwritten spicetify changes
+7                   Finished, but warnings were printed and
+                    "--fail-on-warning" is used
+8                   Another spicetify process is modifying Spotify or backup
+9                   Backup is of another Spotify version
+10                  Backup files are missing or damaged
+
 For config information, run "spicetify -h config".
-For more information and bug report: https://github.com/khanhas/spicetify-cli/`)
+For more information and bug report: https://github.com/khanhas/spicetify-cli/`
 }
 
 func helpConfig() {
@@ -360,18 +1315,95 @@ prefs_path
     Path to Spotify's "prefs" file
 
 current_theme
-    Name of folder of your theme
+    Name of folder of your theme.
+    Themes, extensions and custom apps are looked for in user Themes,
+    Extensions and CustomApps folders first, then in bundled ones: next to
+    spicetify executable, in "../share/spicetify" from it, then in
+    "spicetify" folder of every $XDG_DATA_DIRS entry (Linux), of
+    "/opt/homebrew/share" and "/usr/local/share" (macOS) or of
+    %ProgramData% (Windows).
+    Multiple themes can be layered by separating them with "|", e.g.
+    "Base|Tweaks". Later themes' CSS is appended after earlier ones, their
+    assets overwrite earlier ones and their colors take precedence.
+    A theme can have "theme.js", which runs in Spotify after extensions
+    while the theme is current. Each layer's theme.js runs in its own
+    function scope, in layer order.
 
 color_scheme
     Color config section name in color.ini file.
     If color_scheme is blank, first section in color.ini file would be used.
+    A color.ini value can be "@<key>" to use color of another key, e.g.
+    "button-active = @button". References can be chained.
+    Keys starting with "_", e.g. "_brand", are private: other keys can refer
+    to them and user.scss can use them, but they do not become "--spice-"
+    CSS variables.
+    Colors can be hex "rrggbb" or "rgb", without "#", which starts a comment,
+    "r,g,b", "rgb(r, g, b)" or a CSS color name like "tomato". Invalid values
+    are reported and default color is used for them.
 
 inject_css <0 | 1>
     Whether custom css from user.css in theme folder is applied
+    A theme can have user.scss instead, compiled with Dart Sass ("sass"
+    command). Color scheme is available with '@use "spicetify" as *;' as
+    "$spice-<key>" and "$spice-rgb-<key>" variables.
+    user.css in Spotify is regenerated on every apply, so edits made to it
+    there are lost, except between "/* spicetify:user-begin */" and
+    "/* spicetify:user-end */" lines. These regions are kept, in order, and
+    put after theme CSS. Without them, nothing is kept.
 
 replace_colors <0 | 1>
     Whether custom colors is applied
 
+overwrite_assets <0 | 1>
+    Whether files in "assets" folder of theme are copied to Spotify Apps folder.
+    Sub-folder "assets/_scheme_<name>" is only copied when color scheme <name>
+    is used, overwriting matching files from base assets.
+
+inject_splash <0 | 1>
+    Whether "splash.html" of theme is shown while Spotify loads. It is an HTML
+    fragment without Javascript, up to 64 KB, styled by user.css through
+    "#spicetify-splash". Images go in theme "assets" folder.
+
+accent_follow_system <0 | 1>
+    Whether "button" and "button-active" colors of color scheme are replaced
+    with OS accent color, read from Windows registry, macOS defaults, KDE or
+    GNOME settings. Color scheme values are kept when it cannot be read.
+    Independently, any color.ini value can be "${accent}" or
+    "${accent:<fallback color>}" to use OS accent color.
+
+xpui_path
+    Folder of Spotify frontend ("index.html" and "xpui.js"), relative to
+    Spotify Apps folder, that extensions, custom apps and user.css are
+    injected into. If blank, "xpui" is used when it exists, otherwise it is
+    looked for up to two levels deep, e.g. for beta builds.
+
+reapply_on_revert <0 | 1>
+    Whether "check" applies spicetify again when it detects Spotify has
+    overwritten spicetify changes.
+
+css_history
+    Number of previous user.css versions to keep, each saved when "apply",
+    "update" or "watch" replaces user.css. 0 disables history. See
+    "css-history" command.
+
+node_modules_copy_fallback <0 | 1>
+    Whether "node_modules" folder of Extensions folder is copied into Spotify
+    when it cannot be linked, e.g. on file systems without link support or
+    on Windows without permission. Copying can take long for big folders,
+    and changes to it only take effect on next apply.
+
+normalize_line_endings <0 | 1>
+    Whether files spicetify generates in Spotify, e.g. user.css, custom app
+    JS and CSS and folder extensions, are written with LF line endings, even
+    when their sources mix in CRLF ones. Extension files and assets copied
+    as-is are not changed.
+
+backup_launcher <0 | 1>
+    Linux only. Whether "backup" also backs up Spotify desktop entries and
+    wrapper scripts of detected install type, e.g. "spotify.desktop" and
+    "/usr/bin/spotify", so "restore" brings back how Spotify is launched.
+    Files that need root permission are only restored when run with it.
+
 spotify_launch_flags
     Command-line flags used when launching/restarting Spotify.
     Separate each flag with "|".
@@ -402,8 +1434,79 @@ disable_upgrade_check <0 | 1>
 ` + utils.Bold("[AdditionalOptions]") + `
 custom_apps <string>
     List of custom apps. Separate each app with "|".
+    App is opened at "/<app>" in Spotify unless its "manifest.json" declares
+    "route", e.g. "tools/stats". Invalid routes and ones conflicting with a
+    route of an app listed earlier fall back to app name, with a warning.
+    "nav" in manifest lists sidebar entries, each with optional "name",
+    "path" under app route, "icon" and "active-icon". Without it, one entry
+    links to app route.
+    Subfiles matching names or glob patterns in "disabled_subfiles" of
+    manifest are left out of app, with a warning, to toggle pieces of it
+    while developing without editing "subfiles".
 
 extensions <string>
     List of Javascript files to be executed along with Spotify main script.
-    Separate each extension with "|".`)
+    Separate each extension with "|".
+    A CSS file with the same base name next to extension file (e.g. "foo.css"
+    for "foo.js") is loaded along with extension.
+    Extension can be limited to some platforms with a header comment, e.g.
+    "// spicetify_platform: !appx". Terms are OS ("windows", "linux",
+    "darwin" or "macos") or install type ("default", "appx", "flatpak" or
+    "snap"), separated by spaces or commas and negated with "!". Extension
+    is loaded when any plain term matches (if there is one) and no negated
+    term matches. Otherwise it is skipped.
+    An extension can also be a folder, which is transferred as one file named
+    after the folder. Its optional "extension.json" declares "entry" file
+    (default "index.js") and "subfiles" appended to it, like custom app
+    "manifest.json". Headers and CSS file are looked up next to entry file.
+
+` + utils.Bold("[Patch]") + `
+<file>_find_<n>
+    RegExp to find in xpui <file>, e.g. "xpui.js_find_0". Replaced with
+    "<file>_repl_<n>" (first match) or "<file>_repl_all_<n>" (all matches).
+
+<file>_find_<n>_alt_<m>
+    Alternative RegExps, tried in order of <m> from 1 when "<file>_find_<n>"
+    matches nothing, e.g. after Spotify update renamed minified symbols.
+    A warning is shown when no RegExp matches.
+
+<file>_when_<n>
+    Condition for patch "<file>_find_<n>" to apply, over config keys
+    written "<Section>.<key>", e.g. "AdditionalOptions.my_feature". A key
+    alone is true when its value is set and not "0", "false", "no" or
+    "off". Compare with "==" or "!=", negate with leading "!" and combine
+    with "&&" and "||" ("&&" first). Patches with false condition are
+    skipped, which is not a failure, and shown as "skipped" by
+    "spicetify patches list".
+
+` + utils.Bold("[Install <name>]") + `
+Additional Spotify install, e.g. a beta next to stable, that "--install
+<name>" and "--all-installs" run commands against. <name> consists of
+letters, digits, "_" and "-". Everything else in config applies to every
+install. Backup, extracted files and apply records of each install are kept
+apart in "Installs/<name>" of spicetify folder.
+
+spotify_path
+    Path to Spotify directory of this install. Required, it is not detected.
+
+prefs_path
+    Path to Spotify "prefs" file of this install. Required, it is not
+    detected.
+
+xpui_path
+    Frontend folder of this install, like [Setting] "xpui_path". Detected
+    when blank.
+
+` + utils.Bold("SYSTEM CONFIG") + `
+A system-wide config, e.g. for shared machines, is layered under user config
+when it exists. Location is "/etc/spicetify/config-xpui.ini" on Linux,
+"/Library/Application Support/spicetify/config-xpui.ini" on macOS and
+"%PROGRAMDATA%\spicetify\config-xpui.ini" on Windows, or
+"SPICETIFY_SYSTEM_CONFIG" environment variable.
+    - User values win, except blank ones, which take system value.
+    - "extensions", "custom_apps" and "spotify_launch_flags" lists are
+      joined, system entries first. A user entry ending with "-", e.g.
+      "foo.js-", removes that system entry.
+    - [Backup] is never read from system config.
+"spicetify config dump" marks values taken from system config.`)
 }
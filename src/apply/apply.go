@@ -10,75 +10,273 @@ import (
 	"github.com/khanhas/spicetify-cli/src/utils"
 )
 
+const schemeOverlayPrefix = "_scheme_"
+
+// UserCSSMarker is the first line of every user.css spicetify generates
+const UserCSSMarker = "/* Generated by spicetify */"
+
+// User regions of user.css are edited by hand in Spotify frontend folder.
+// They are kept, markers included, when user.css is regenerated and put
+// after theme CSS. Everything outside them is owned by spicetify.
+const (
+	UserRegionBegin = "/* spicetify:user-begin */"
+	UserRegionEnd   = "/* spicetify:user-end */"
+)
+
+// SplashCSSName is stylesheet of theme splash screen in frontend folder
+const SplashCSSName = "spicetify-splash.css"
+
+// ThemeJSName is script of current themes in frontend folder, combined
+// from "theme.js" of every theme layer
+const ThemeJSName = "spicetify-theme.js"
+
+// splashCSS covers Spotify with splash screen until it renders into "#main"
+const splashCSS = `#spicetify-splash {
+    position: fixed;
+    inset: 0;
+    z-index: 9999;
+    display: flex;
+    align-items: center;
+    justify-content: center;
+    background-color: var(--spice-main, #121212);
+    color: var(--spice-text, #ffffff);
+}
+
+#main:not(:empty) ~ #spicetify-splash {
+    display: none;
+}
+`
+
 // Flag enables/disables additional feature
 type Flag struct {
 	Extension []string
 	CustomApp []string
+	// CustomAppChunk lists ids of additional custom app chunks
+	CustomAppChunk []string
+	// CustomAppRoute maps custom app to its URL path, without leading
+	// slash. Apps it lacks are at their name.
+	CustomAppRoute map[string]string
+	// Splash is HTML of theme splash screen, blank for none
+	Splash string
+	// ThemeJS links script of current themes, after extensions
+	ThemeJS bool
+}
+
+// filesToModify maps name of Spotify frontend file to function injecting
+// extensions and custom apps into it
+var filesToModify = map[string]func(path string, flags Flag){
+	"index.html": htmlMod,
+	"xpui.js":    insertCustomApp,
 }
 
-// AdditionalOptions .
-func AdditionalOptions(appsFolderPath string, flags Flag) {
-	filesToModified := map[string]func(path string, flags Flag){
-		filepath.Join(appsFolderPath, "xpui", "index.html"): htmlMod,
-		filepath.Join(appsFolderPath, "xpui", "xpui.js"):    insertCustomApp,
+// AdditionalOptions injects extensions and custom apps into Spotify
+// frontend folder `xpuiPath`.
+func AdditionalOptions(xpuiPath string, flags Flag) {
+	for name := range filesToModify {
+		AdditionalOption(xpuiPath, name, flags)
 	}
+}
 
-	for file, call := range filesToModified {
-		if _, err := os.Stat(file); os.IsNotExist(err) {
-			continue
-		}
+// ModifiesFile reports whether AdditionalOptions modifies frontend file
+// `name`
+func ModifiesFile(name string) bool {
+	_, ok := filesToModify[name]
+	return ok
+}
+
+// AdditionalOption injects extensions and custom apps into frontend file
+// `name` only, if it is one AdditionalOptions modifies. File must be stock.
+// Returns whether file is modified.
+func AdditionalOption(xpuiPath, name string, flags Flag) bool {
+	call, ok := filesToModify[name]
+	if !ok {
+		return false
+	}
 
-		call(file, flags)
+	file := filepath.Join(xpuiPath, name)
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return false
 	}
+
+	call(file, flags)
+	return true
 }
 
-// UserCSS creates user.css file in "zlink", "login" and "settings" apps.
-// To not use custom css, set `themeFolder` to blank string
+// UserCSS creates user.css file in Spotify frontend folder `xpuiPath`.
+// CSS of every theme in `themeCSS` is appended in order, then user regions
+// of existing user.css.
+// To not use custom css, set `themeCSS` to `nil`
 // To use default color scheme, set `scheme` to `nil`
-func UserCSS(appsFolderPath, themeFolder string, scheme map[string]string) {
-	css := []byte(getColorCSS(scheme) + getUserCSS(themeFolder))
+func UserCSS(xpuiPath string, themeCSS []string, scheme map[string]string) {
+	css := UserCSSMarker + "\n" + getColorCSS(scheme)
+	for _, content := range themeCSS {
+		css += content + "\n"
+	}
+
+	dest := filepath.Join(xpuiPath, "user.css")
+	if existing, err := ioutil.ReadFile(dest); err == nil {
+		regions, unterminated := getUserRegions(string(existing))
+		if unterminated {
+			utils.PrintWarning(`user.css has "` + UserRegionBegin + `" without "` + UserRegionEnd + `" after it. Everything after it is kept as user region.`)
+		}
+		for _, region := range regions {
+			css += region + "\n"
+		}
+	}
 
-	dest := filepath.Join(appsFolderPath, "xpui", "user.css")
-	if err := ioutil.WriteFile(dest, css, 0700); err != nil {
+	if err := utils.WriteTextFile(dest, []byte(css), 0700); err != nil {
 		utils.Fatal(err)
 	}
 }
 
-// UserAsset .
-func UserAsset(appsFolderPath, themeFolder string) {
+// getUserRegions returns user regions of `css`, markers included. When
+// last begin marker has no end marker, its region runs to end of `css` and
+// gets one, and `unterminated` is true.
+func getUserRegions(css string) (regions []string, unterminated bool) {
+	for {
+		begin := strings.Index(css, UserRegionBegin)
+		if begin == -1 {
+			return regions, false
+		}
+		css = css[begin:]
+
+		end := strings.Index(css, UserRegionEnd)
+		if end == -1 {
+			regions = append(regions, strings.TrimRight(css, "\r\n")+"\n"+UserRegionEnd)
+			return regions, true
+		}
+		end += len(UserRegionEnd)
+		regions = append(regions, css[:end])
+		css = css[end:]
+	}
+}
+
+// UserAsset copies theme assets to Apps folder, overwriting existing files.
+// Folders named "_scheme_<name>" in assets folder are overlays that are
+// only copied, on top of base assets, when color scheme <name> is in use.
+func UserAsset(appsFolderPath, themeFolder, schemeName string) {
 	var assetsPath = getAssetsPath(themeFolder)
+	if len(assetsPath) == 0 {
+		return
+	}
 
-	if err := utils.Copy(assetsPath, appsFolderPath, true, nil); err != nil {
+	entries, err := ioutil.ReadDir(assetsPath)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	overlayPath := ""
+	for _, entry := range entries {
+		name := entry.Name()
+		entryPath := filepath.Join(assetsPath, name)
+
+		if entry.IsDir() && strings.HasPrefix(name, schemeOverlayPrefix) {
+			// Section names are case insensitive in color.ini
+			if len(schemeName) > 0 && strings.EqualFold(name, schemeOverlayPrefix+schemeName) {
+				overlayPath = entryPath
+			}
+			continue
+		}
+
+		if entry.IsDir() {
+			err = utils.Copy(entryPath, filepath.Join(appsFolderPath, name), true, nil)
+		} else {
+			err = utils.CopyFile(entryPath, appsFolderPath)
+		}
+
+		if err != nil {
+			utils.Fatal(err)
+		}
+	}
+
+	if len(overlayPath) == 0 {
+		return
+	}
+
+	if err := utils.Copy(overlayPath, appsFolderPath, true, nil); err != nil {
 		utils.Fatal(err)
 	}
 }
 
 func htmlMod(htmlPath string, flags Flag) {
-	if len(flags.Extension) == 0 {
+	if len(flags.Extension) == 0 && len(flags.Splash) == 0 && !flags.ThemeJS {
 		return
 	}
 
-	extensionsHTML := ""
+	injectedHTML := ""
+	if len(flags.Splash) > 0 {
+		// After "#main", so splash stylesheet can hide it once Spotify
+		// renders
+		injectedHTML += `<div id="spicetify-splash">` + flags.Splash + `</div>` + "\n"
+	}
 
 	for _, v := range flags.Extension {
+		// Stylesheet shipped alongside extension, transferred as "<name>.css"
+		cssName := v + ".css"
+		if _, err := os.Stat(filepath.Join(filepath.Dir(htmlPath), cssName)); err == nil {
+			injectedHTML += `<link rel="stylesheet" class="extensionCSS" href="` + cssName + `">` + "\n"
+		}
+
+		// Extension id scopes its Spicetify.LocalStorage.scoped() bucket
 		if strings.HasSuffix(v, ".mjs") {
-			extensionsHTML += `<script type="module" src="` + v + `"></script>` + "\n"
+			injectedHTML += `<script type="module" data-extension-id="` + v + `" src="` + v + `"></script>` + "\n"
 		} else {
-			extensionsHTML += `<script src="` + v + `"></script>` + "\n"
+			injectedHTML += `<script data-extension-id="` + v + `" src="` + v + `"></script>` + "\n"
 		}
 	}
 
+	if flags.ThemeJS {
+		injectedHTML += `<script data-extension-id="` + ThemeJSName + `" src="` + ThemeJSName + `"></script>` + "\n"
+	}
+
 	utils.ModifyFile(htmlPath, func(content string) string {
+		if len(flags.Splash) > 0 {
+			utils.Replace(
+				&content,
+				`</head>`,
+				`<link rel="stylesheet" class="splashCSS" href="`+SplashCSSName+`">${0}`,
+			)
+		}
 		utils.Replace(
 			&content,
 			`</body>`,
-			extensionsHTML+"${0}",
+			injectedHTML+"${0}",
 		)
 		return content
 	})
 }
 
-func getUserCSS(themeFolder string) string {
+// SplashStyle writes splash screen stylesheet to frontend folder
+// `xpuiPath`, or removes it when splash screen is not `enabled`.
+func SplashStyle(xpuiPath string, enabled bool) error {
+	dest := filepath.Join(xpuiPath, SplashCSSName)
+	if !enabled {
+		if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	return utils.WriteTextFile(dest, []byte(splashCSS), 0700)
+}
+
+// ThemeScript writes `script` of current themes to frontend folder
+// `xpuiPath`, or removes it when `script` is blank.
+func ThemeScript(xpuiPath, script string) error {
+	dest := filepath.Join(xpuiPath, ThemeJSName)
+	if len(script) == 0 {
+		if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	return utils.WriteTextFile(dest, []byte(script), 0700)
+}
+
+// ReadUserCSS returns content of user.css in `themeFolder`, or blank if
+// there is none.
+func ReadUserCSS(themeFolder string) string {
 	if len(themeFolder) == 0 {
 		return ""
 	}
@@ -98,9 +296,9 @@ func getUserCSS(themeFolder string) string {
 	return string(content)
 }
 
-func getColorCSS(scheme map[string]string) string {
-	var variableList string
-	var variableRGBList string
+// SchemeColors returns colors of `scheme`, with default colors for ones it
+// lacks
+func SchemeColors(scheme map[string]string) map[string]utils.Color {
 	mergedScheme := make(map[string]string)
 
 	for k, v := range scheme {
@@ -113,8 +311,27 @@ func getColorCSS(scheme map[string]string) string {
 		}
 	}
 
+	colors := make(map[string]utils.Color)
 	for k, v := range mergedScheme {
-		parsed := utils.ParseColor(v)
+		colors[k] = utils.ParseColor(v)
+	}
+
+	return colors
+}
+
+// PrivateColorPrefix starts color scheme keys that only serve other keys,
+// e.g. as target of "@<key>" references or in Sass, and are not emitted as
+// CSS variables
+const PrivateColorPrefix = "_"
+
+func getColorCSS(scheme map[string]string) string {
+	var variableList string
+	var variableRGBList string
+
+	for k, parsed := range SchemeColors(scheme) {
+		if strings.HasPrefix(k, PrivateColorPrefix) {
+			continue
+		}
 		variableList += fmt.Sprintf("    --spice-%s: #%s;\n", k, parsed.Hex())
 		variableRGBList += fmt.Sprintf("    --spice-rgb-%s: %s;\n", k, parsed.RGB())
 	}
@@ -140,11 +357,17 @@ func insertCustomApp(jsPath string, flags Flag) {
 		appEleMap := ""
 		cssEnableMap := ""
 		appNameArray := ""
+		appRouteMap := ""
 
 		for index, app := range flags.CustomApp {
 			appName := `spicetify-routes-` + app
+			route := flags.CustomAppRoute[app]
+			if len(route) == 0 {
+				route = app
+			}
 			appMap += fmt.Sprintf(`"%s":"%s",`, appName, appName)
 			appNameArray += fmt.Sprintf(`"%s",`, app)
+			appRouteMap += fmt.Sprintf(`"%s":"%s",`, app, route)
 
 			appReactMap += fmt.Sprintf(
 				`,spicetifyApp%d=Spicetify.React.lazy((()=>%s.%s("%s").then(%s.bind(%s,"%s"))))`,
@@ -153,11 +376,15 @@ func insertCustomApp(jsPath string, flags Flag) {
 
 			appEleMap += fmt.Sprintf(
 				`Spicetify.React.createElement(%s,{path:"/%s"},Spicetify.React.createElement(spicetifyApp%d,null)),`,
-				eleSymbs[0], app, index)
+				eleSymbs[0], route, index)
 
 			cssEnableMap += fmt.Sprintf(`,"%s":1`, appName)
 		}
 
+		for _, chunkID := range flags.CustomAppChunk {
+			appMap += fmt.Sprintf(`"%s":"%s",`, chunkID, chunkID)
+		}
+
 		utils.Replace(
 			&content,
 			`\{(\d+:"xpui)`,
@@ -191,7 +418,7 @@ func insertCustomApp(jsPath string, flags Flag) {
 		content = strings.Replace(
 			content,
 			sidebarItemMatch,
-			sidebarItemMatch+",Spicetify._cloneSidebarItem(["+appNameArray+"])",
+			sidebarItemMatch+",Spicetify._cloneSidebarItem(["+appNameArray+"],{"+appRouteMap+"})",
 			1)
 
 		return content
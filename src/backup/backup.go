@@ -1,22 +1,82 @@
 package backup
 
 import (
+	"archive/zip"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/khanhas/spicetify-cli/src/utils"
 )
 
-// Start backing up Spotify Apps folder to backupPath
-func Start(appPath, backupPath string) error {
-	return utils.Copy(appPath, backupPath, false, []string{".spa"})
+// RequiredApps lists app packages that backup cannot work without.
+var RequiredApps = []string{"xpui", "login", "settings", "glue-resources"}
+
+// Plan lists app packages in Spotify Apps folder at `appPath` to back up,
+// and ones skipped because their file name matches any glob pattern in
+// `exclude`. Packages of RequiredApps are never skipped.
+func Plan(appPath string, exclude []string) (files []string, excluded []string, err error) {
+	fileList, err := ioutil.ReadDir(appPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files = []string{}
+	excluded = []string{}
+	for _, file := range fileList {
+		fileName := file.Name()
+		if file.IsDir() || !strings.HasSuffix(fileName, ".spa") {
+			continue
+		}
+
+		if !IsRequired(fileName) && IsExcluded(fileName, exclude) {
+			excluded = append(excluded, fileName)
+		} else {
+			files = append(files, fileName)
+		}
+	}
+
+	return files, excluded, nil
+}
+
+// Start backing up `files` in Spotify Apps folder to backupPath
+// and call `callback` at every successfully copied file
+func Start(appPath, backupPath string, files []string, callback func(finishedFile string)) error {
+	_, err := StartIncremental(appPath, backupPath, "", files, callback)
+	return err
+}
+
+// IsExcluded reports whether `fileName` matches any glob pattern in
+// `exclude`.
+func IsExcluded(fileName string, exclude []string) bool {
+	for _, pattern := range exclude {
+		if matched, _ := filepath.Match(pattern, fileName); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsRequired reports whether `fileName` is a package of a required app.
+func IsRequired(fileName string) bool {
+	for _, app := range RequiredApps {
+		if fileName == app+".spa" {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Extract all SPA files from backupPath to extractPath
 // and call `callback` at every successfully extracted app
 func Extract(backupPath, extractPath string, callback func(finishedApp string)) {
-	apps := []string{"xpui", "login", "settings", "glue-resources"}
-
-	for _, v := range apps {
+	for _, v := range RequiredApps {
 		appPath := filepath.Join(backupPath, v + ".spa")
 		appName := v
 
@@ -30,3 +90,96 @@ func Extract(backupPath, extractPath string, callback func(finishedApp string))
 		callback(appName)
 	}
 }
+
+// Checksums maps every file in Apps folder at `path` to its CRC-32
+// checksum. Files inside app packages are read from the archive, so a
+// packed "xpui.spa" and an extracted "xpui" folder both give keys like
+// "xpui/index.html". Packages or folders whose name is in `skip` are left
+// out.
+func Checksums(path string, skip []string) (map[string]uint32, error) {
+	fileList, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sums := map[string]uint32{}
+	for _, file := range fileList {
+		name := file.Name()
+		if containsString(skip, name) {
+			continue
+		}
+
+		filePath := filepath.Join(path, name)
+		switch {
+		case file.IsDir():
+			err = folderChecksums(filePath, name, sums)
+		case strings.HasSuffix(name, ".spa"):
+			err = packageChecksums(filePath, strings.TrimSuffix(name, ".spa"), sums)
+		default:
+			sums[name], err = fileChecksum(filePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sums, nil
+}
+
+func packageChecksums(spaPath, prefix string, sums map[string]uint32) error {
+	archive, err := zip.OpenReader(spaPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		sums[path.Join(prefix, file.Name)] = file.CRC32
+	}
+
+	return nil
+}
+
+func folderChecksums(folder, prefix string, sums map[string]uint32) error {
+	return filepath.Walk(folder, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(folder, filePath)
+		if err != nil {
+			return err
+		}
+
+		sums[path.Join(prefix, filepath.ToSlash(rel))], err = fileChecksum(filePath)
+		return err
+	})
+}
+
+func fileChecksum(filePath string) (uint32, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	hash := crc32.NewIEEE()
+	if _, err = io.Copy(hash, file); err != nil {
+		return 0, err
+	}
+
+	return hash.Sum32(), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
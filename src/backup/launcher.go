@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// LauncherFolder is folder in backup that holds launcher files, e.g.
+// ".desktop" entries and wrapper scripts, that live outside Apps folder
+const LauncherFolder = "launcher"
+
+// StartLauncher copies launcher files at absolute `paths` into backup at
+// `backupPath` and records them in backup manifest, along with where they
+// are restored to. Missing files are skipped. Returns paths backed up.
+func StartLauncher(backupPath string, paths []string) ([]string, error) {
+	manifest, err := ReadManifest(backupPath)
+	if err != nil {
+		manifest = map[string]FileIdentity{}
+	}
+
+	folder := filepath.Join(backupPath, LauncherFolder)
+	if err = os.MkdirAll(folder, 0700); err != nil {
+		return nil, err
+	}
+
+	done := []string{}
+	for i, origin := range paths {
+		identity, err := identify(origin)
+		if err != nil {
+			continue
+		}
+
+		// Index keeps files of the same name, e.g. two "spotify.desktop",
+		// apart
+		name := strconv.Itoa(i) + "-" + filepath.Base(origin)
+		content, err := ioutil.ReadFile(origin)
+		if err != nil {
+			return done, err
+		}
+		if err = ioutil.WriteFile(filepath.Join(folder, name), content, 0600); err != nil {
+			return done, err
+		}
+
+		identity.Origin = origin
+		if info, err := os.Stat(origin); err == nil {
+			identity.Mode = uint32(info.Mode().Perm())
+		}
+		manifest[path.Join(LauncherFolder, name)] = identity
+		done = append(done, origin)
+	}
+
+	return done, WriteManifest(backupPath, manifest)
+}
+
+// RestoreLauncher writes launcher files recorded in backup at `backupPath`
+// back to where they were backed up from, if they changed since. Returns
+// paths restored and errors of ones that cannot be written, e.g. for lack
+// of permission.
+func RestoreLauncher(backupPath string) ([]string, []error) {
+	manifest, err := ReadManifest(backupPath)
+	if err != nil {
+		return nil, nil
+	}
+
+	names := []string{}
+	for name, identity := range manifest {
+		if len(identity.Origin) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	restored := []string{}
+	errs := []error{}
+	for _, name := range names {
+		identity := manifest[name]
+		if isSameFile(identity.Origin, identity, FileIdentity{}) && hasMode(identity.Origin, identity.Mode) {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(backupPath, filepath.FromSlash(name)))
+		if err == nil {
+			err = writeLauncherFile(identity.Origin, content, os.FileMode(identity.Mode))
+		}
+		if err != nil {
+			errs = append(errs, errors.New(identity.Origin+": "+err.Error()))
+			continue
+		}
+		restored = append(restored, identity.Origin)
+	}
+
+	return restored, errs
+}
+
+// hasMode reports whether file at `path` has permission bits `mode`. Mode
+// not recorded is not checked.
+func hasMode(path string, mode uint32) bool {
+	if mode == 0 {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && uint32(info.Mode().Perm()) == mode
+}
+
+func writeLauncherFile(origin string, content []byte, mode os.FileMode) error {
+	if mode == 0 {
+		mode = 0644
+	}
+	if err := os.MkdirAll(filepath.Dir(origin), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(origin, content, mode); err != nil {
+		return err
+	}
+	// WriteFile keeps mode of existing file, e.g. a wrapper script that lost
+	// its executable bit
+	return os.Chmod(origin, mode)
+}
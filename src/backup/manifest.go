@@ -0,0 +1,205 @@
+package backup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// ManifestName is file in backup folder that records backed up files
+const ManifestName = "backup.json"
+
+// VersionName is file in backup folder that records Spotify version it is
+// backed up from, so backup can be used without config, e.g. on another
+// machine
+const VersionName = "version"
+
+// NoteName is file in backup folder that holds note user gave when backing
+// up, e.g. why backup was made
+const NoteName = "note"
+
+// FileIdentity is what backup manifest records about a backed up file
+type FileIdentity struct {
+	Size     int64  `json:"size"`
+	Checksum string `json:"sha256"`
+	// Linked tells file is a hardlink to the same file of previous backup
+	Linked bool `json:"linked,omitempty"`
+	// Origin is where a launcher file is restored to, blank for app packages
+	Origin string `json:"origin,omitempty"`
+	// Mode is permission bits of launcher file
+	Mode uint32 `json:"mode,omitempty"`
+}
+
+// StartIncremental is like Start, but hardlinks files that are unchanged
+// since previous backup at `previousPath` instead of copying them. Files are
+// copied where hardlinks are not supported, e.g. across devices. Identity
+// of every file is recorded in backup manifest. Returns number of linked
+// files.
+func StartIncremental(appPath, backupPath, previousPath string, files []string, callback func(finishedFile string)) (int, error) {
+	os.MkdirAll(backupPath, 0700)
+
+	var previous map[string]FileIdentity
+	if len(previousPath) > 0 {
+		previous, _ = ReadManifest(previousPath)
+	}
+	manifest := map[string]FileIdentity{}
+	linked := 0
+
+	for _, fileName := range files {
+		source := filepath.Join(appPath, fileName)
+		identity, err := identify(source)
+		if err != nil {
+			return linked, err
+		}
+
+		dest := filepath.Join(backupPath, fileName)
+		if len(previousPath) > 0 && isSameFile(filepath.Join(previousPath, fileName), identity, previous[fileName]) {
+			os.Remove(dest)
+			if os.Link(filepath.Join(previousPath, fileName), dest) == nil {
+				identity.Linked = true
+				linked++
+			}
+		}
+
+		if !identity.Linked {
+			if err := utils.CopyFile(source, backupPath); err != nil {
+				return linked, err
+			}
+		}
+
+		manifest[fileName] = identity
+		callback(fileName)
+	}
+
+	return linked, WriteManifest(backupPath, manifest)
+}
+
+// isSameFile reports whether file at `path` has `identity`. Checksum
+// recorded in previous manifest, `recorded`, saves reading the file again.
+func isSameFile(path string, identity, recorded FileIdentity) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != identity.Size {
+		return false
+	}
+
+	if recorded.Size == identity.Size && len(recorded.Checksum) > 0 {
+		return recorded.Checksum == identity.Checksum
+	}
+
+	checksum, err := utils.FileChecksum(path)
+	return err == nil && checksum == identity.Checksum
+}
+
+func identify(path string) (FileIdentity, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileIdentity{}, err
+	}
+
+	checksum, err := utils.FileChecksum(path)
+	if err != nil {
+		return FileIdentity{}, err
+	}
+
+	return FileIdentity{Size: info.Size(), Checksum: checksum}, nil
+}
+
+// ReadManifest reads manifest of backup at `backupPath`
+func ReadManifest(backupPath string) (map[string]FileIdentity, error) {
+	content, err := ioutil.ReadFile(filepath.Join(backupPath, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := map[string]FileIdentity{}
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// WriteManifest writes manifest of backup at `backupPath`
+func WriteManifest(backupPath string, manifest map[string]FileIdentity) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(backupPath, ManifestName), content, 0600)
+}
+
+// WriteVersion records Spotify `version` in backup at `backupPath`
+func WriteVersion(backupPath, version string) error {
+	return ioutil.WriteFile(filepath.Join(backupPath, VersionName), []byte(version+"\n"), 0600)
+}
+
+// ReadVersion returns Spotify version recorded in backup at `backupPath`,
+// or blank if it is not recorded.
+func ReadVersion(backupPath string) string {
+	content, err := ioutil.ReadFile(filepath.Join(backupPath, VersionName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// WriteNote records `note` in backup at `backupPath`
+func WriteNote(backupPath, note string) error {
+	return ioutil.WriteFile(filepath.Join(backupPath, NoteName), []byte(note+"\n"), 0600)
+}
+
+// ReadNote returns note recorded in backup at `backupPath`, or blank if
+// there is none.
+func ReadNote(backupPath string) string {
+	content, err := ioutil.ReadFile(filepath.Join(backupPath, NoteName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// Verify checks files of backup at `backupPath` against its manifest and
+// returns names of ones that are missing or changed. Backups made without
+// manifest are not checked.
+func Verify(backupPath string) []string {
+	manifest, err := ReadManifest(backupPath)
+	if err != nil {
+		return nil
+	}
+
+	problems := []string{}
+	for fileName, identity := range manifest {
+		if !isSameFile(filepath.Join(backupPath, fileName), identity, FileIdentity{}) {
+			problems = append(problems, fileName)
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// QuickVerify is like Verify, but only compares file sizes, so it is cheap
+// enough to run often. It misses files changed without changing size.
+func QuickVerify(backupPath string) []string {
+	manifest, err := ReadManifest(backupPath)
+	if err != nil {
+		return nil
+	}
+
+	problems := []string{}
+	for fileName, identity := range manifest {
+		info, err := os.Stat(filepath.Join(backupPath, fileName))
+		if err != nil || info.Size() != identity.Size {
+			problems = append(problems, fileName)
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Key hashes `parts`, e.g. source URL or content and options it is
+// processed with, into a cache entry key.
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Path returns file path of entry `key` in cache folder `dir`. Entries are
+// spread into sub-folders named by first two characters of key.
+func Path(dir, key string) string {
+	return filepath.Join(dir, key[:2], key)
+}
+
+// Get reads entry `key` from cache folder `dir`. It reports false when
+// entry does not exist or cannot be read.
+func Get(dir, key string) ([]byte, bool) {
+	content, err := ioutil.ReadFile(Path(dir, key))
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// Put writes `content` as entry `key` in cache folder `dir`. Content is
+// written to a temporary file first so that an interrupted write never
+// leaves a partial entry.
+func Put(dir, key string, content []byte) error {
+	dest := Path(dir, key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(dest), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = temp.Write(content); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+
+	if err = temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+
+	return os.Rename(temp.Name(), dest)
+}
+
+// Info counts entries in cache folder `dir` and their total size in bytes.
+func Info(dir string) (count int, size int64, err error) {
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !info.IsDir() {
+			count++
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return count, size, err
+}
+
+// Clean removes every entry in cache folder `dir` and returns how many
+// bytes were reclaimed. Cache folder itself is kept.
+func Clean(dir string) (int64, error) {
+	_, size, err := Info(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	children, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	for _, child := range children {
+		if err = os.RemoveAll(filepath.Join(dir, child.Name())); err != nil {
+			return 0, err
+		}
+	}
+
+	return size, nil
+}
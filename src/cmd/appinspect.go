@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/khanhas/spicetify-cli/src/manifest"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// resolvedApp is how spicetify interprets custom app: its folder, manifest
+// and files that are concatenated into its main chunk
+type resolvedApp struct {
+	Path string
+	// ManifestContent is manifest.json as transferred to Spotify, "{}"
+	// when app has none
+	ManifestContent []byte
+	Manifest        appManifest
+	// Problems are schema problems of manifest.json
+	Problems []string
+	// Files are index.js then enabled subfiles, in concatenation order
+	Files []string
+	// DisabledFiles are subfiles left out by "disabled_subfiles"
+	DisabledFiles []string
+	// Style is style.css of app, blank if it has none
+	Style string
+}
+
+// resolveApp finds custom app `app` and resolves its manifest and files
+// without printing or writing anything.
+func resolveApp(app string) (resolvedApp, error) {
+	customAppPath, err := getCustomAppPath(app)
+	if err != nil {
+		return resolvedApp{}, err
+	}
+
+	resolved := resolvedApp{Path: customAppPath, Problems: []string{}}
+	resolved.ManifestContent, resolved.Manifest = readAppManifest(customAppPath)
+
+	// Validating placeholder of missing manifest would report missing name
+	if content, err := os.ReadFile(filepath.Join(customAppPath, "manifest.json")); err == nil {
+		for _, err := range manifest.Validate(content) {
+			resolved.Problems = append(resolved.Problems, err.Error())
+		}
+	}
+
+	entry := filepath.Join(customAppPath, "index.js")
+	if _, err := os.Stat(entry); err != nil {
+		resolved.Problems = append(resolved.Problems, "index.js not found")
+	} else {
+		resolved.Files = append(resolved.Files, entry)
+	}
+
+	subfiles, disabled := getAppSubfiles(customAppPath, resolved.Manifest)
+	for _, subfile := range subfiles {
+		// Missing subfiles are skipped when concatenating
+		if _, err := os.Stat(subfile); err == nil {
+			resolved.Files = append(resolved.Files, subfile)
+		}
+	}
+	resolved.DisabledFiles = disabled
+
+	if style := filepath.Join(customAppPath, "style.css"); isFile(style) {
+		resolved.Style = style
+	}
+
+	return resolved, nil
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// appInspection is what "apps inspect" prints
+type appInspection struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Manifest is manifest.json as transferred to Spotify
+	Manifest json.RawMessage `json:"manifest"`
+	Route    string          `json:"route"`
+	// Files are relative to app folder, in concatenation order
+	Files         []string         `json:"files"`
+	DisabledFiles []string         `json:"disabled_subfiles"`
+	Chunks        []inspectedChunk `json:"chunks"`
+	Style         string           `json:"style"`
+	Problems      []string         `json:"problems"`
+}
+
+type inspectedChunk struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+	File string `json:"file"`
+}
+
+// InspectApp prints, as JSON, how custom app `app` would be applied: its
+// manifest, route, files concatenated into its main chunk, additional
+// chunks and manifest problems. Nothing is written. Exits with
+// ExitConfigError when app has problems.
+func InspectApp(app string) {
+	resolved, err := resolveApp(app)
+	if err != nil {
+		utils.PrintError(`Custom app "` + app + `" not found.`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	// Route and chunk ids depend on every configured app
+	appList := getCustomAppList()
+	if !containsString(appList, app) {
+		appList = append(appList, app)
+	}
+
+	inspection := appInspection{
+		Name:          app,
+		Path:          resolved.Path,
+		Route:         "/" + getAppRoutes(appList, false)[app],
+		Files:         relativePaths(resolved.Path, resolved.Files),
+		DisabledFiles: relativePaths(resolved.Path, resolved.DisabledFiles),
+		Chunks:        []inspectedChunk{},
+		Problems:      resolved.Problems,
+	}
+	if len(resolved.Style) > 0 {
+		inspection.Style = filepath.Base(resolved.Style)
+	}
+
+	if json.Valid(resolved.ManifestContent) {
+		inspection.Manifest = resolved.ManifestContent
+	} else {
+		inspection.Manifest = json.RawMessage("null")
+	}
+
+	for _, chunk := range getAppsChunks(appList, false)[app] {
+		file := relativePaths(resolved.Path, []string{chunk.Path})[0]
+		inspection.Chunks = append(inspection.Chunks, inspectedChunk{chunk.Name, chunk.ID, file})
+	}
+	if skipped := len(resolved.Manifest.Chunks) - len(inspection.Chunks); skipped > 0 {
+		inspection.Problems = append(inspection.Problems, fmt.Sprintf(`%d of %d chunks are skipped, "spicetify apply" tells why`, skipped, len(resolved.Manifest.Chunks)))
+	}
+
+	out, err := json.MarshalIndent(inspection, "", "  ")
+	if err != nil {
+		utils.Fatal(err)
+	}
+	log.Println(string(out))
+
+	if len(inspection.Problems) > 0 {
+		utils.Exit(utils.ExitConfigError)
+	}
+}
+
+// relativePaths returns `paths` relative to `folder`, with "/" separators
+func relativePaths(folder string, paths []string) []string {
+	result := []string{}
+	for _, path := range paths {
+		if rel, err := filepath.Rel(folder, path); err == nil {
+			path = filepath.ToSlash(rel)
+		}
+		result = append(result, path)
+	}
+	return result
+}
@@ -4,11 +4,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/khanhas/spicetify-cli/src/apply"
+	"github.com/khanhas/spicetify-cli/src/manifest"
 	backupstatus "github.com/khanhas/spicetify-cli/src/status/backup"
 	spotifystatus "github.com/khanhas/spicetify-cli/src/status/spotify"
 	"github.com/khanhas/spicetify-cli/src/utils"
@@ -16,102 +22,289 @@ import (
 
 // Apply .
 func Apply() {
-	checkStates()
+	policy := requireBackup
+	if flags.NoBackupCheck {
+		policy = skipBackupCheck
+	}
+	checkStates(policy)
+	if flags.Locked {
+		verifyLockfile()
+	}
+	if flags.AssetsOnly {
+		applyAssets()
+		return
+	}
+	if flags.Repair {
+		repairApply()
+		return
+	}
+	if flags.Interactive {
+		selectInteractive()
+	}
 	InitSetting()
 
-	// Copy raw assets to Spotify Apps folder if Spotify is never applied
-	// before.
-	// extractedStock is for preventing copy raw assets 2 times when
-	// replaceColors is false.
-	extractedStock := false
-	if !spotifystatus.Get(appDestPath).IsApplied() {
-		utils.PrintBold(`Copying raw assets:`)
-		if err := os.RemoveAll(appDestPath); err != nil {
-			utils.Fatal(err)
-		}
-		if err := utils.Copy(rawFolder, appDestPath, true, nil); err != nil {
-			utils.Fatal(err)
-		}
-		utils.PrintGreen("OK")
-		extractedStock = true
+	extentionList := getExtensionList()
+	customAppsList := getCustomAppList()
+
+	checkCompatibility(extentionList, customAppsList)
+	checkStrict(extentionList, customAppsList)
+
+	sources := collectSources(extentionList, customAppsList)
+	previousSources := readSourceManifest()
+	isApplied := detectApplied()
+
+	var sizeBefore int64
+	if flags.MeasureSize {
+		sizeBefore = measureXpuiSize()
 	}
 
-	if replaceColors {
-		utils.PrintBold(`Overwriting themed assets:`)
-		if err := utils.Copy(themedFolder, appDestPath, true, nil); err != nil {
-			utils.Fatal(err)
+	if canApplyIncrementally(previousSources, sources, isApplied) {
+		applyIncrementally(previousSources, sources, extentionList, customAppsList)
+		sources["applied"] = hashAppliedState()
+		writeSourceManifest(sources)
+		recordInstall()
+		if flags.Verify {
+			verifyApply(extentionList, customAppsList)
 		}
-		utils.PrintGreen("OK")
-	} else if !extractedStock {
-		utils.PrintBold(`Overwriting raw assets:`)
-		if err := utils.Copy(rawFolder, appDestPath, true, nil); err != nil {
-			utils.Fatal(err)
+		if flags.MeasureSize {
+			printSizeReport(sizeBefore)
 		}
-		utils.PrintGreen("OK")
+		utils.PrintSuccess("Spotify is spiced up!")
+		return
 	}
 
-	utils.PrintBold(`Transferring user.css:`)
-	updateCSS()
-	utils.PrintGreen("OK")
-
-	if overwriteAssets {
-		utils.PrintBold(`Overwriting custom assets:`)
-		updateAssets()
-		utils.PrintGreen("OK")
-	}
+	// Recorded sources are only valid once this full apply finishes.
+	clearSourceManifest()
 
-	if (preprocSection.Key("expose_apis").MustBool(false)) {
-		utils.CopyFile(
-			filepath.Join(utils.GetJsHelperDir(), "spicetifyWrapper.js"),
-			filepath.Join(appDestPath, "xpui"))
+	noRawCopy := flags.NoRawCopy
+	if !isApplied && noRawCopy {
+		utils.PrintWarning(`Skipping wipe and copy of raw assets ("--no-raw-copy"). Applying on top of existing files, stale stock files may be left behind.`)
+	} else if !isApplied {
+		if entries, _ := os.ReadDir(rawFolder); len(entries) == 0 && flags.NoBackupCheck {
+			// Raw assets come from backup, which is knowingly missing
+			utils.PrintWarning(`There are no raw assets to copy without a backup, so they are skipped as with "--no-raw-copy". Applying on top of existing files, stale stock files may be left behind.`)
+			noRawCopy = true
+		} else if len(entries) == 0 {
+			utils.PrintError(`There are no raw assets to copy, which come from backup. Run "spicetify backup" first, or use "--no-raw-copy" to apply on top of existing files.`)
+			utils.Exit(utils.ExitNoBackup)
+		}
 	}
 
-	extentionList := featureSection.Key("extensions").Strings("|")
-	customAppsList := featureSection.Key("custom_apps").Strings("|")
+	// extractedStock is for preventing copy raw assets 2 times when
+	// replaceColors is false.
+	extractedStock := false
+	splash := ""
+	themeJS := false
+	patchProblems := []string{}
 
-	utils.PrintBold(`Applying additional modifications:`)
-	apply.AdditionalOptions(appDestPath, apply.Flag{
-		Extension:            extentionList,
-		CustomApp:            customAppsList,
+	stageProblems := runStages([]applyStage{
+		{name: "raw-assets", run: func() {
+			// Copy raw assets to Spotify Apps folder if Spotify is never
+			// applied before.
+			if isApplied || noRawCopy {
+				return
+			}
+			utils.PrintStage(`Copying raw assets`)
+			if err := clearAppsFolder(appDestPath); err != nil {
+				utils.Fatal(err)
+			}
+			if err := utils.Copy(rawFolder, appDestPath, true, nil); err != nil {
+				utils.Fatal(err)
+			}
+			utils.PrintStageDone()
+			extractedStock = true
+		}},
+		{name: "themed-assets", after: []string{"raw-assets"}, run: func() {
+			if replaceColors {
+				utils.PrintStage(`Overwriting themed assets`)
+				if err := utils.Copy(themedFolder, appDestPath, true, nil); err != nil {
+					utils.Fatal(err)
+				}
+				utils.PrintStageDone()
+			} else if !extractedStock {
+				utils.PrintStage(`Overwriting raw assets`)
+				if err := utils.Copy(rawFolder, appDestPath, true, nil); err != nil {
+					utils.Fatal(err)
+				}
+				utils.PrintStageDone()
+			}
+		}},
+		// Stock user.css comes with themed assets and is replaced
+		{name: "css", after: []string{"themed-assets"}, run: func() {
+			utils.PrintStage(`Transferring user.css`)
+			updateCSS()
+			utils.PrintStageDone()
+		}},
+		// Custom assets overwrite themed ones and may replace user.css
+		{name: "custom-assets", after: []string{"themed-assets", "css"}, run: func() {
+			if overwriteAssets {
+				utils.PrintStage(`Overwriting custom assets`)
+				updateAssets()
+				utils.PrintStageDone()
+			}
+		}},
+		{name: "wrapper", after: []string{"custom-assets"}, run: func() {
+			if preprocSection.Key("expose_apis").MustBool(false) {
+				utils.PrintStage(`Transferring spicetifyWrapper.js`)
+				if pushWrapper() {
+					utils.PrintStageDone()
+				}
+			}
+		}},
+		{name: "extensions", after: []string{"custom-assets"}, run: func() {
+			if len(extentionList) > 0 {
+				utils.PrintStage(`Transferring extensions`)
+				extentionList = pushExtensions(extentionList...)
+				utils.PrintStageDone()
+				nodeModuleSymlink()
+			}
+			pruneExtensionCSS(extentionList)
+		}},
+		{name: "splash", after: []string{"custom-assets"}, run: func() {
+			splash = updateSplash()
+		}},
+		{name: "theme-js", after: []string{"custom-assets"}, run: func() {
+			themeJS = updateThemeJS()
+		}},
+		// Links only extensions that are transferred, and splash and theme
+		// script if valid, into stock index.html and xpui.js
+		{name: "modifications", after: []string{"themed-assets", "extensions", "splash", "theme-js"}, run: func() {
+			utils.PrintStage(`Applying additional modifications`)
+			apply.AdditionalOptions(getXpuiPath(), apply.Flag{
+				Extension:      extentionList,
+				CustomApp:      customAppsList,
+				CustomAppChunk: getAppChunkIDs(customAppsList),
+				CustomAppRoute: getAppRoutes(customAppsList, false),
+				Splash:         splash,
+				ThemeJS:        themeJS,
+			})
+			utils.PrintStageDone()
+		}},
+		{name: "apps", after: []string{"custom-assets"}, run: func() {
+			if len(customAppsList) > 0 {
+				utils.PrintStage(`Transferring custom apps`)
+				pushApps(customAppsList...)
+				utils.PrintStageDone()
+			}
+		}},
+		// Patches apply to files as every other stage left them
+		{name: "patches", after: []string{"css", "wrapper", "modifications", "apps"}, run: func() {
+			savePrePatch()
+			if len(patchSection.Keys()) > 0 {
+				utils.PrintStage(`Patching`)
+				patchProblems = Patch()
+				utils.PrintStageDone()
+			}
+		}},
 	})
-	utils.PrintGreen("OK")
 
-	if len(extentionList) > 0 {
-		utils.PrintBold(`Transferring extensions:`)
-		pushExtensions(extentionList...)
-		utils.PrintGreen("OK")
-		nodeModuleSymlink()
+	// Without recorded sources, next apply is a full one that retries
+	// failed stages
+	if len(stageProblems) == 0 {
+		sources["applied"] = hashAppliedState()
+		writeSourceManifest(sources)
 	}
-
-	if len(customAppsList) > 0 {
-		utils.PrintBold(`Transferring custom apps:`)
-		pushApps(customAppsList...)
-		utils.PrintGreen("OK")
+	recordInstall()
+	if flags.Verify {
+		verifyApply(extentionList, customAppsList)
 	}
-
-	if len(patchSection.Keys()) > 0 {
-		utils.PrintBold(`Patching:`)
-		Patch()
-		utils.PrintGreen("OK")
+	if flags.MeasureSize {
+		printSizeReport(sizeBefore)
 	}
 
-	utils.PrintSuccess("Spotify is spiced up!")
+	if flags.KeepGoing && len(stageProblems)+len(patchProblems) > 0 {
+		summarizeProblems(stageProblems, patchProblems)
+	} else {
+		utils.PrintSuccess("Spotify is spiced up!")
+	}
 
 	if isAppX {
 		utils.PrintInfo(`You are using Spotify Windows Store version, which is only partly supported.
 Stop using Spicetify with Windows Store version unless you absolutely CANNOT install normal Spotify from installer.
+Modifications are stored in ` + appDestPath + `.
 Modded Spotify cannot be launched using original Shortcut/Start menu tile. To correctly launch Spotify with modification, please make a desktop shortcut that execute "spicetify auto". After that, you can change its icon, pin to start menu or put in startup folder.`)
 	}
 }
 
+// summarizeProblems prints what "--keep-going" apply could not do
+func summarizeProblems(stageProblems, patchProblems []string) {
+	utils.PrintWarning("Apply finished with problems:")
+	for _, problem := range stageProblems {
+		log.Println("    " + problem)
+	}
+	if len(patchProblems) > 0 {
+		log.Println("    Patches not applied:")
+		for _, problem := range patchProblems {
+			log.Println("        " + problem)
+		}
+	}
+	utils.MarkPartialFailure()
+}
+
+// detectApplied returns whether Spotify Apps folder is already applied,
+// which decides if raw assets are copied first. "--assume-applied" and
+// "--assume-fresh" override detection for when it guesses wrong.
+func detectApplied() bool {
+	if flags.AssumeApplied && flags.AssumeFresh {
+		utils.PrintError(`"--assume-applied" cannot be used with "--assume-fresh".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	detected := spotifystatus.Get(appDestPath).IsApplied()
+	switch {
+	case flags.AssumeApplied:
+		if !detected {
+			utils.PrintWarning(`Spotify looks not applied, but is treated as applied ("--assume-applied"). Raw assets are not copied.`)
+		}
+		return true
+	case flags.AssumeFresh:
+		if detected {
+			utils.PrintWarning(`Spotify looks applied, but is treated as not applied ("--assume-fresh"). Apps folder is cleared and raw assets are copied again.`)
+		}
+		return false
+	}
+
+	return detected
+}
+
+// applyAssets only overwrites custom assets of current theme, skipping CSS,
+// extensions, custom apps and patches. It requires a full apply to have been
+// done, so the rest of Spotify is not left stale.
+func applyAssets() {
+	InitSetting()
+
+	manifest := readSourceManifest()
+	if manifest == nil || !spotifystatus.Get(appDestPath).IsApplied() {
+		utils.PrintError(`Spotify is not applied yet. Run "spicetify apply" without "--assets-only" first.`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	if !overwriteAssets {
+		utils.PrintWarning(`Nothing is updated: Config "overwrite_assets" is disabled or current theme has no "assets" folder.`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	utils.PrintStage(`Overwriting custom assets`)
+	updateAssets()
+	utils.PrintStageDone()
+
+	// Keep recorded sources in sync so next apply can still be incremental
+	manifest["assets"] = hashAssetSources()
+	manifest["applied"] = hashAppliedState()
+	writeSourceManifest(manifest)
+	recordInstall()
+
+	utils.PrintSuccess("Custom assets are updated")
+}
+
 // UpdateTheme updates user.css and overwrites custom assets
 func UpdateTheme() {
-	checkStates()
+	checkStates(requireBackup)
 	InitSetting()
 
 	if len(themeFolder) == 0 {
 		utils.PrintWarning(`Nothing is updated: Config "current_theme" is blank.`)
-		os.Exit(1)
+		utils.Exit(utils.ExitConfigError)
 	}
 
 	updateCSS()
@@ -125,47 +318,122 @@ func UpdateTheme() {
 
 func updateCSS() {
 	var scheme map[string]string = nil
-	if colorSection != nil {
-		scheme = colorSection.KeysHash()
+	if replaceColors {
+		scheme = colorScheme
+		problems := normalizeColorScheme(scheme)
+		for _, problem := range problems {
+			if flags.StrictColors {
+				utils.PrintError(problem)
+			} else {
+				utils.PrintWarning(problem + " Default color is used.")
+			}
+		}
+		if len(problems) > 0 && flags.StrictColors {
+			utils.PrintInfo("user.css is not updated because of strict mode.")
+			utils.Exit(utils.ExitFailure)
+		}
 	}
-	theme := themeFolder
-	if !injectCSS {
-		theme = ""
+	var themeCSS []string = nil
+	if injectCSS {
+		for _, folder := range themeFolders {
+			themeCSS = append(themeCSS, getThemeCSS(folder, scheme))
+		}
+	} else {
+		warnCSSDisabled()
 	}
-	apply.UserCSS(appDestPath, theme, scheme)
+	snapshotCSS(getXpuiPath())
+	apply.UserCSS(getXpuiPath(), themeCSS, scheme)
 }
 
 func updateAssets() {
-	apply.UserAsset(appDestPath, themeFolder)
+	for _, theme := range themeFolders {
+		apply.UserAsset(appDestPath, theme, themeSchemeNames[theme])
+	}
+}
+
+// cssWarned keeps warnCSSDisabled from repeating itself, e.g. in watch mode
+var cssWarned = false
+
+// warnCSSDisabled warns when theme has CSS but config "inject_css" keeps it
+// from being applied, which otherwise looks like theme is broken.
+func warnCSSDisabled() {
+	if cssWarned || settingSection.Key("inject_css").MustBool(false) || !anyThemeHasCSS() {
+		return
+	}
+	cssWarned = true
+
+	message := `Config "inject_css" is disabled, so CSS of theme "` + strings.Join(getThemeNames(), "|") + `" is not applied`
+	if replaceColors {
+		message += ", only its colors are"
+	}
+	utils.PrintWarning(message + `. Run "spicetify config inject_css 1" to apply it.`)
+}
+
+// pushWrapper copies spicetifyWrapper.js, which exposes Spicetify APIs to
+// extensions and custom apps, to xpui. A missing one means spicetify
+// installation is incomplete, reported as partial failure since Spotify
+// still loads without it. Returns whether it is copied.
+func pushWrapper() bool {
+	wrapperPath := filepath.Join(utils.GetJsHelperDir(), "spicetifyWrapper.js")
+	if _, err := os.Stat(wrapperPath); err != nil {
+		utils.PrintError(`Cannot find ` + wrapperPath + `. Spicetify installation is incomplete, reinstall spicetify to bring it back. Extensions and custom apps do not work without it.`)
+		utils.MarkPartialFailure()
+		return false
+	}
+
+	if err := utils.CopyFile(wrapperPath, getXpuiPath()); err != nil {
+		utils.PrintError("Cannot transfer spicetifyWrapper.js: " + err.Error())
+		utils.MarkPartialFailure()
+		return false
+	}
+
+	return true
 }
 
 // UpdateAllExtension pushs all extensions to Spotify
 func UpdateAllExtension() {
-	checkStates()
-	list := featureSection.Key("extensions").Strings("|")
+	checkStates(requireBackup)
+	list := getExtensionList()
 	if len(list) > 0 {
 		pushExtensions(list...)
 		utils.PrintSuccess(utils.PrependTime("All extensions are updated."))
 	} else {
 		utils.PrintError("No extension to update.")
+		utils.Exit(utils.ExitConfigError)
 	}
 }
 
+// backupPolicy decides whether checkStates enforces that a backup exists
+type backupPolicy int
+
+const (
+	// requireBackup stops when there is no backup
+	requireBackup backupPolicy = iota
+	// skipBackupCheck only warns when there is no backup
+	skipBackupCheck
+)
+
 // checkStates examines both Backup and Spotify states to promt informative
 // instruction for users
-func checkStates() {
-	backupVersion := backupSection.Key("version").MustString("")
+func checkStates(policy backupPolicy) {
+	checkInstall()
+	checkSpotifyVersion()
+
+	backupVersion := getBackupVersion()
 	backStat := backupstatus.Get(prefsPath, backupFolder, backupVersion)
 	spotStat := spotifystatus.Get(appPath)
 
-	if backStat.IsEmpty() {
+	if backStat.IsEmpty() && policy == skipBackupCheck {
+		utils.PrintWarning(utils.Bold(`Applying WITHOUT a backup ("--no-backup-check"). "spicetify restore" cannot undo these changes; reinstall Spotify to get back to stock.`))
+
+	} else if backStat.IsEmpty() {
 		if spotStat.IsBackupable() {
 			utils.PrintError(`You haven't backed up. Run "spicetify backup apply".`)
 
 		} else {
 			utils.PrintError(`You haven't backed up and Spotify cannot be backed up at this state. Please re-install Spotify then run "spicetify backup apply".`)
 		}
-		os.Exit(1)
+		utils.Exit(utils.ExitNoBackup)
 
 	} else if backStat.IsOutdated() {
 		utils.PrintWarning("Spotify version and backup version are mismatched.")
@@ -182,11 +450,67 @@ func checkStates() {
 		}
 
 		if !ReadAnswer("Continue anyway? [y/N] ", false, true) {
-			os.Exit(1)
+			utils.Exit(utils.ExitFailure)
 		}
 	}
 }
 
+// checkSpotifyVersion stops when Spotify is not at version given with
+// "--compare-version", unless "--force" is used. A version matches ones it
+// is a prefix of, e.g. "1.1.70" matches "1.1.70.610.g4585142b".
+func checkSpotifyVersion() {
+	expected := strings.TrimSpace(flags.CompareVersion)
+	if len(expected) == 0 {
+		return
+	}
+
+	detected := utils.GetInstalledSpotifyVersion(prefsPath)
+	if detected == expected || strings.HasPrefix(detected, expected+".") {
+		return
+	}
+
+	if len(detected) == 0 {
+		detected = "unknown"
+	}
+	message := `Spotify version "` + detected + `" is not "` + expected + `" given with "--compare-version".`
+	if flags.Force {
+		utils.PrintWarning(message + ` Continuing because of "--force".`)
+		return
+	}
+	utils.PrintError(message)
+	utils.PrintInfo(`Check theme, extensions and custom apps against this version and update "--compare-version", or use "--force" to continue anyway.`)
+	utils.Exit(utils.ExitSpotifyError)
+}
+
+// checkInstall stops with a hint to reinstall Spotify when its core files
+// are missing, instead of failing halfway through with a confusing error.
+func checkInstall() {
+	exeFolder := spotifyPath
+	if len(flags.From) > 0 {
+		// Apps folder given by user may be a copy without Spotify itself
+		exeFolder = ""
+	}
+
+	frontendFolder := filepath.Join(appPath, defaultXpuiFolder)
+	if configured := getInstallSection().Key("xpui_path").String(); len(configured) > 0 {
+		frontendFolder = filepath.Join(appPath, filepath.FromSlash(configured))
+	} else if detected, ok := detectXpuiFolder(appPath); ok {
+		frontendFolder = filepath.Join(appPath, detected)
+	}
+
+	missing := spotifystatus.FindMissingFiles(exeFolder, appPath, frontendFolder)
+	if len(missing) == 0 {
+		return
+	}
+
+	utils.PrintError("Spotify installation looks incomplete, e.g. because an update was interrupted or antivirus quarantined some files. Missing or unreadable:")
+	for _, file := range missing {
+		log.Println("    " + file)
+	}
+	utils.PrintInfo(`Please reinstall Spotify, then run "spicetify backup apply".`)
+	utils.Exit(utils.ExitSpotifyError)
+}
+
 func getExtensionPath(name string) (string, error) {
 	extFilePath := filepath.Join(userExtensionsFolder, name)
 
@@ -194,18 +518,50 @@ func getExtensionPath(name string) (string, error) {
 		return extFilePath, nil
 	}
 
-	extFilePath = filepath.Join(utils.GetExecutableDir(), "Extensions", name)
-
-	if _, err := os.Stat(extFilePath); err == nil {
-		return extFilePath, nil
+	for _, extFilePath = range utils.GetBundledPaths(filepath.Join("Extensions", name)) {
+		if _, err := os.Stat(extFilePath); err == nil {
+			return extFilePath, nil
+		}
 	}
 
 	return "", errors.New("Extension not found")
 }
 
-func pushExtensions(list ...string) {
+// checkExtensionFile sniffs the first bytes of extension file at `path`
+// and returns an error describing the detected type if it is clearly not
+// a Javascript file.
+func checkExtensionFile(path string) error {
+	ext := filepath.Ext(path)
+	if ext != ".js" && ext != ".mjs" {
+		return errors.New(`unsupported file type "` + ext + `", only ".js" and ".mjs" are allowed`)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := file.Read(head)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if !strings.HasPrefix(contentType, "text/plain") {
+		return errors.New(`content looks like "` + contentType + `", not Javascript`)
+	}
+
+	return nil
+}
+
+// pushExtensions copies extension files to Spotify and returns names of
+// the ones that are successfully transferred.
+func pushExtensions(list ...string) []string {
 	var err error
-	var dest = filepath.Join(appDestPath, "xpui")
+	var dest = getXpuiPath()
+	var pushed []string
 
 	for _, v := range list {
 		var extName, extPath string
@@ -217,32 +573,317 @@ func pushExtensions(list ...string) {
 			extName = v
 			extPath, err = getExtensionPath(v)
 			if err != nil {
-				utils.PrintError(`Extension "` + extName + `" not found.`)
+				failItem(flags.StrictExtensions, utils.PrintError, `Extension "`+extName+`" not found.`)
 				continue
 			}
 		}
 
-		if err = utils.CopyFile(extPath, dest); err != nil {
-			utils.PrintError(err.Error())
+		entryPath := extPath
+		if info, err := os.Stat(extPath); err == nil && info.IsDir() {
+			manifest, err := readExtensionManifest(extPath)
+			if err != nil {
+				failItem(flags.StrictExtensions, utils.PrintWarning, `Extension "`+extName+`" is skipped: `+err.Error())
+				continue
+			}
+			entryPath = filepath.Join(extPath, manifest.Entry)
+		}
+
+		if err = checkExtensionFile(entryPath); err != nil {
+			failItem(flags.StrictExtensions, utils.PrintWarning, `Extension "`+extName+`" is skipped: `+err.Error())
+			continue
+		}
+
+		if ok, reason := isExtensionForPlatform(extPath); !ok {
+			utils.PrintInfo(`Extension "` + extName + `" is skipped: ` + reason)
+			continue
+		}
+
+		fileName := getExtensionFileName(extName, extPath)
+		if entryPath == extPath {
+			err = utils.CopyFile(extPath, dest)
+		} else {
+			var content []byte
+			if content, err = buildFolderExtension(extPath); err == nil {
+				err = utils.WriteTextFile(filepath.Join(dest, fileName), content, 0700)
+			}
+		}
+		if err != nil {
+			failItem(flags.StrictExtensions, utils.PrintError, err.Error())
 			continue
 		}
 
-		if strings.HasSuffix(extName, ".mjs") {
-			utils.ModifyFile(filepath.Join(dest, extName), func(content string) string {
+		pushExtensionCSS(extPath, fileName, dest)
+
+		if strings.HasSuffix(fileName, ".mjs") {
+			utils.ModifyFile(filepath.Join(dest, fileName), func(content string) string {
 				lines := strings.Split(content, "\n")
-				for i := 0; i < len(lines); i++ {
-					mapping := utils.FindSymbol("", lines[i], []string{
-						`//\s*spicetify_map\{(.+?)\}\{(.+?)\}`,
-					})
+				for i := 0; i+1 < len(lines); i++ {
+					mapping := utils.FindSymbols("", lines[i], []string{spicetifyMapRegex}, 1)
 					if len(mapping) > 0 {
-						lines[i+1] = strings.Replace(lines[i+1], mapping[0], mapping[1], 1)
+						lines[i+1] = strings.Replace(lines[i+1], mapping[0].Named["from"], mapping[0].Named["to"], 1)
 					}
 				}
 
 				return strings.Join(lines, "\n")
 			})
 		}
+
+		pushed = append(pushed, fileName)
+		utils.ReportPushed("extensions", v)
+	}
+
+	return pushed
+}
+
+// failItem reports extension or custom app that cannot be applied with
+// `print`. In strict mode (`strict`) apply is aborted, otherwise the item is
+// skipped and exit code tells some items failed.
+func failItem(strict bool, print func(string), message string) {
+	if strict {
+		utils.PrintError(message)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	print(message)
+	utils.MarkPartialFailure()
+}
+
+// checkStrict makes sure, with "--strict-extensions" or "--strict-apps",
+// that every extension or custom app can be found before anything is
+// modified, so apply cannot ship an incomplete install.
+func checkStrict(extensionList, appList []string) {
+	problems := []string{}
+
+	if flags.StrictExtensions {
+		for _, ext := range extensionList {
+			extPath := ext
+			if !filepath.IsAbs(ext) {
+				var err error
+				if extPath, err = getExtensionPath(ext); err != nil {
+					problems = append(problems, `Extension "`+ext+`" not found.`)
+					continue
+				}
+			}
+
+			if info, err := os.Stat(extPath); err == nil && info.IsDir() {
+				if _, err = readExtensionManifest(extPath); err != nil {
+					problems = append(problems, `Extension "`+ext+`": `+err.Error())
+					continue
+				}
+			}
+
+			if err := checkExtensionFile(getExtensionEntry(extPath)); err != nil {
+				problems = append(problems, `Extension "`+ext+`": `+err.Error())
+			}
+		}
+	}
+
+	if flags.StrictColors && replaceColors {
+		scheme := map[string]string{}
+		for key, value := range colorScheme {
+			scheme[key] = value
+		}
+		problems = append(problems, normalizeColorScheme(scheme)...)
 	}
+
+	if flags.StrictApps {
+		for _, app := range appList {
+			resolved, err := resolveApp(app)
+			if err != nil {
+				problems = append(problems, `Custom app "`+app+`" not found.`)
+				continue
+			}
+
+			if _, err = buildAppJS(app, resolved); err != nil {
+				problems = append(problems, `Custom app "`+app+`" does not have index.js`)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return
+	}
+
+	for _, problem := range problems {
+		utils.PrintError(problem)
+	}
+	utils.PrintInfo("Nothing is applied because of strict mode.")
+	utils.Exit(utils.ExitFailure)
+}
+
+// extensionManifest is "extension.json" of a folder extension. Like custom
+// app manifest, it lists subfiles appended to entry file.
+type extensionManifest struct {
+	// Entry is file that subfiles are appended to, relative to extension
+	// folder. Its file extension decides whether extension is a module.
+	Entry string   `json:"entry"`
+	Files []string `json:"subfiles"`
+}
+
+// readExtensionManifest reads "extension.json" of folder extension at
+// `extPath`. Manifest is optional and entry defaults to "index.js", as in
+// custom apps.
+func readExtensionManifest(extPath string) (extensionManifest, error) {
+	var manifest extensionManifest
+
+	content, err := os.ReadFile(filepath.Join(extPath, "extension.json"))
+	if err == nil {
+		if err = json.Unmarshal(content, &manifest); err != nil {
+			return manifest, errors.New("extension.json: " + err.Error())
+		}
+	} else if !os.IsNotExist(err) {
+		return manifest, err
+	}
+
+	if len(manifest.Entry) == 0 {
+		manifest.Entry = "index.js"
+	}
+
+	return manifest, nil
+}
+
+// getExtensionEntry returns entry file of folder extension at `extPath`, or
+// `extPath` itself for single file extension.
+func getExtensionEntry(extPath string) string {
+	if info, err := os.Stat(extPath); err != nil || !info.IsDir() {
+		return extPath
+	}
+
+	manifest, _ := readExtensionManifest(extPath)
+	return filepath.Join(extPath, manifest.Entry)
+}
+
+// getExtensionFileName returns file name extension is transferred as.
+// Folder extension is named after its folder, with file extension of its
+// entry, e.g. "foo.js" for folder "foo" with entry "index.js".
+func getExtensionFileName(extName, extPath string) string {
+	entry := getExtensionEntry(extPath)
+	if entry == extPath {
+		return extName
+	}
+
+	return extName + filepath.Ext(entry)
+}
+
+// getExtensionSources returns files extension at `extPath` is built from:
+// the extension file, or entry and subfiles of folder extension.
+func getExtensionSources(extPath string) ([]string, error) {
+	info, err := os.Stat(extPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{extPath}, nil
+	}
+
+	manifest, err := readExtensionManifest(extPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(
+		[]string{filepath.Join(extPath, manifest.Entry)},
+		resolveSubfiles(extPath, manifest.Files)...), nil
+}
+
+// buildFolderExtension concatenates entry and subfiles of folder extension
+// at `extPath`. Like custom app subfiles, missing ones are skipped.
+func buildFolderExtension(extPath string) ([]byte, error) {
+	sources, err := getExtensionSources(extPath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(sources[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subfilePath := range sources[1:] {
+		subfileContent, err := os.ReadFile(subfilePath)
+		if err != nil {
+			continue
+		}
+		content = append(content, '\n')
+		content = append(content, subfileContent...)
+	}
+
+	return content, nil
+}
+
+// getExtensionCSSPath returns path of CSS file shipped alongside extension
+// at `extPath`: same folder, same base name, e.g. "foo.css" for "foo.js"
+// or "foo.mjs". For folder extension, it is next to entry file.
+func getExtensionCSSPath(extPath string) string {
+	entry := getExtensionEntry(extPath)
+	return strings.TrimSuffix(entry, filepath.Ext(entry)) + ".css"
+}
+
+// getExtensionCSSName returns file name extension CSS is transferred as.
+// Full extension file name is kept so that it cannot clash with Spotify's
+// own stylesheets.
+func getExtensionCSSName(extName string) string {
+	return extName + ".css"
+}
+
+// pushExtensionCSS transfers CSS file shipped alongside extension, or
+// removes previously transferred one when extension no longer has it.
+func pushExtensionCSS(extPath, extName, dest string) {
+	cssDest := filepath.Join(dest, getExtensionCSSName(extName))
+	cssPath := getExtensionCSSPath(extPath)
+
+	if _, err := os.Stat(cssPath); err != nil {
+		os.Remove(cssDest)
+		return
+	}
+
+	content, err := os.ReadFile(cssPath)
+	if err != nil {
+		utils.PrintError(err.Error())
+		utils.MarkPartialFailure()
+		return
+	}
+
+	if err = utils.WriteTextFile(cssDest, content, 0700); err != nil {
+		utils.PrintError(err.Error())
+		utils.MarkPartialFailure()
+	}
+}
+
+// pruneExtensionCSS removes CSS of extensions not in `extensionList` from
+// Spotify, so removed extensions do not leave their styles behind.
+func pruneExtensionCSS(extensionList []string) {
+	dest := getXpuiPath()
+	for _, pattern := range []string{"*.js.css", "*.mjs.css"} {
+		matches, _ := filepath.Glob(filepath.Join(dest, pattern))
+		for _, match := range matches {
+			extName := strings.TrimSuffix(filepath.Base(match), ".css")
+			if !containsString(extensionList, extName) {
+				os.Remove(match)
+			}
+		}
+	}
+}
+
+// getExtensionsWithCSS returns extensions in `extensionList` that ship CSS.
+func getExtensionsWithCSS(extensionList []string) []string {
+	withCSS := []string{}
+	for _, ext := range extensionList {
+		extPath := ext
+		if !filepath.IsAbs(ext) {
+			var err error
+			if extPath, err = getExtensionPath(ext); err != nil {
+				continue
+			}
+		}
+
+		if _, err := os.Stat(getExtensionCSSPath(extPath)); err == nil {
+			withCSS = append(withCSS, ext)
+		}
+	}
+
+	return withCSS
 }
 
 func getCustomAppPath(name string) (string, error) {
@@ -252,10 +893,10 @@ func getCustomAppPath(name string) (string, error) {
 		return customAppFolderPath, nil
 	}
 
-	customAppFolderPath = filepath.Join(utils.GetExecutableDir(), "CustomApps", name)
-
-	if _, err := os.Stat(customAppFolderPath); err == nil {
-		return customAppFolderPath, nil
+	for _, customAppFolderPath = range utils.GetBundledPaths(filepath.Join("CustomApps", name)) {
+		if _, err := os.Stat(customAppFolderPath); err == nil {
+			return customAppFolderPath, nil
+		}
 	}
 
 	return "", errors.New("Custom app not found")
@@ -263,70 +904,346 @@ func getCustomAppPath(name string) (string, error) {
 
 type appManifest struct {
 	Files []string `json:"subfiles"`
+	// DisabledFiles are names or glob patterns of subfiles left out, e.g.
+	// while developing
+	DisabledFiles []string `json:"disabled_subfiles"`
+	// RequiresFlags lists Spotify command-line flags app depends on
+	RequiresFlags []string `json:"requires_flags"`
+	// Chunks maps names of additional lazy-loaded chunks to their files
+	Chunks map[string]string `json:"chunks"`
+	// Route is URL path of app in Spotify, app name when blank
+	Route string `json:"route"`
+	// Nav lists sidebar entries of app, one entry for app route when empty
+	Nav []appNavEntry `json:"nav"`
 }
 
 func pushApps(list ...string) {
+	list = uniqueApps(list)
+
+	// Chunk ids are checked against every configured app, not only pushed ones
+	appsChunks := getAppsChunks(getCustomAppList(), true)
+	getAppRoutes(getCustomAppList(), true)
+
 	for _, app := range list {
 		appName := `spicetify-routes-` + app
 
-		customAppPath, err := getCustomAppPath(app)
+		resolved, err := resolveApp(app)
 		if err != nil {
-			utils.PrintError(`Custom app "` + app + `" not found.`)
+			failItem(flags.StrictApps, utils.PrintError, `Custom app "`+app+`" not found.`)
 			continue
 		}
+		customAppPath, manifestJson := resolved.Path, resolved.Manifest
+
+		for _, problem := range resolved.Problems {
+			utils.PrintWarning(`Custom app "` + app + `" manifest.json: ` + problem)
+		}
+		checkAppRequiredFlags(app, manifestJson)
+		checkAppNav(app, manifestJson.Nav)
+		checkAppDisabledFiles(app, customAppPath, manifestJson)
+		utils.WriteTextFile(
+			filepath.Join(getXpuiPath(), appName + ".json"), 
+			resolved.ManifestContent,
+			0700)
 
-		jsFile := filepath.Join(customAppPath, "index.js")
-		jsFileContent, err := os.ReadFile(jsFile)
+		jsTemplate, err := buildAppJS(app, resolved)
 		if err != nil {
-			utils.PrintError(`Custom app "` + app + `" does not have index.js`)
+			failItem(flags.StrictApps, utils.PrintError, `Custom app "`+app+`" does not have index.js`)
 			continue
 		}
-		
-		manifestFile := filepath.Join(customAppPath, "manifest.json")
-		manifestFileContent, err := os.ReadFile(manifestFile)
+
+		utils.WriteTextFile(
+			filepath.Join(getXpuiPath(), appName + ".js"), 
+			[]byte(jsTemplate),
+			0700)
+
+		for _, chunk := range appsChunks[app] {
+			chunkJS, err := buildChunkJS(chunk)
+			if err != nil {
+				failItem(flags.StrictApps, utils.PrintError, err.Error())
+				continue
+			}
+
+			utils.WriteTextFile(
+				filepath.Join(getXpuiPath(), chunk.ID+".js"),
+				[]byte(chunkJS),
+				0700)
+		}
+
+		cssFile := filepath.Join(customAppPath, "style.css")
+		cssFileContent, err := os.ReadFile(cssFile)
 		if err != nil {
-			manifestFileContent = []byte{'{', '}'}
+			cssFileContent = []byte{}
 		}
-		os.WriteFile(
-			filepath.Join(appDestPath, "xpui", appName + ".json"), 
-			manifestFileContent,
+		utils.WriteTextFile(
+			filepath.Join(getXpuiPath(), appName + ".css"), 
+			[]byte(cssFileContent),
 			0700)
+		utils.ReportPushed("custom_apps", app)
+	}
+}
 
-		var manifestJson appManifest
-		if err = json.Unmarshal(manifestFileContent, &manifestJson); err == nil {
-			for _, subfile := range(manifestJson.Files) {
-				subfilePath := filepath.Join(customAppPath, subfile)
-				subfileContent, err := os.ReadFile(subfilePath)
-				if err != nil {
-					continue
-				}
-				jsFileContent = append(jsFileContent, '\n')
-				jsFileContent = append(jsFileContent, subfileContent...)
+// duplicateAppsWarned holds custom apps already warned about being listed
+// more than once, so warning is printed once per run.
+var duplicateAppsWarned = map[string]bool{}
+
+// uniqueApps removes repeated entries of custom app `list`, keeping first
+// occurrence, so each app gets one route and one set of files.
+func uniqueApps(list []string) []string {
+	unique := []string{}
+	for _, app := range list {
+		if !containsString(unique, app) {
+			unique = append(unique, app)
+			continue
+		}
+		if !duplicateAppsWarned[app] {
+			utils.PrintWarning(`Custom app "` + app + `" is listed more than once, only first entry is used.`)
+			duplicateAppsWarned[app] = true
+		}
+	}
+	return unique
+}
+
+// ValidateManifest validates custom app manifest at `path`, which is either
+// manifest.json file or custom app folder, and prints every problem found.
+func ValidateManifest(path string) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "manifest.json")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		utils.PrintError(err.Error())
+		utils.Exit(utils.ExitFailure)
+	}
+
+	errs := manifest.Validate(content)
+	if len(errs) == 0 {
+		utils.PrintSuccess(path + " is valid.")
+		return
+	}
+
+	utils.PrintError(path + " has problems:")
+	for _, err := range errs {
+		utils.PrintError("    " + err.Error())
+	}
+	utils.Exit(utils.ExitConfigError)
+}
+
+// checkAppRequiredFlags warns about Spotify flags that custom app needs but
+// are not in "spotify_launch_flags". They are still added whenever
+// spicetify launches Spotify, but not when Spotify is launched otherwise.
+func checkAppRequiredFlags(app string, manifestJson appManifest) {
+	launchFlags := utils.ListValues(settingSection.Key("spotify_launch_flags"))
+
+	for _, flag := range manifestJson.RequiresFlags {
+		if containsString(launchFlags, flag) {
+			continue
+		}
+
+		utils.PrintWarning(`Custom app "` + app + `" requires Spotify flag "` + flag + `".`)
+		utils.PrintInfo(`It is enabled when Spotify is launched by spicetify. To always enable it, add it to "spotify_launch_flags" in config, or the app may not work.`)
+	}
+}
+
+// getAppsRequiredFlags returns Spotify flags required by all configured
+// custom apps.
+func getAppsRequiredFlags() []string {
+	var flags []string
+
+	for _, app := range getCustomAppList() {
+		customAppPath, err := getCustomAppPath(app)
+		if err != nil {
+			continue
+		}
+
+		_, manifestJson := readAppManifest(customAppPath)
+		for _, flag := range manifestJson.RequiresFlags {
+			if !containsString(flags, flag) {
+				flags = append(flags, flag)
 			}
 		}
+	}
+
+	return flags
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readAppManifest returns raw content and parsed manifest.json of custom
+// app. Missing or malformed manifest is treated as an empty one.
+func readAppManifest(customAppPath string) ([]byte, appManifest) {
+	var manifestJson appManifest
+
+	manifestFile := filepath.Join(customAppPath, "manifest.json")
+	manifestFileContent, err := os.ReadFile(manifestFile)
+	if err != nil {
+		manifestFileContent = []byte{'{', '}'}
+	}
+
+	json.Unmarshal(manifestFileContent, &manifestJson)
+
+	return manifestFileContent, manifestJson
+}
+
+// resolveSubfiles returns paths of custom app or folder extension
+// subfiles in a deterministic order: manifest declared order, with glob
+// patterns expanded to their matches sorted alphabetically. Files already
+// listed are not included again.
+func resolveSubfiles(folder string, patterns []string) []string {
+	var subfiles []string
+	included := map[string]bool{}
+
+	for _, subfile := range patterns {
+		matches := []string{filepath.Join(folder, subfile)}
+		if strings.ContainsAny(subfile, "*?[") {
+			matches, _ = filepath.Glob(matches[0])
+			sort.Strings(matches)
+		}
+
+		for _, match := range matches {
+			if included[match] {
+				continue
+			}
+			included[match] = true
+			subfiles = append(subfiles, match)
+		}
+	}
+
+	return subfiles
+}
+
+// getAppSubfiles returns resolved subfiles of custom app at
+// `customAppPath`, split into enabled ones and ones matching
+// "disabled_subfiles" of its manifest.
+func getAppSubfiles(customAppPath string, manifestJson appManifest) (enabled, disabled []string) {
+	for _, subfile := range resolveSubfiles(customAppPath, manifestJson.Files) {
+		if len(matchDisabledFile(customAppPath, subfile, manifestJson.DisabledFiles)) > 0 {
+			disabled = append(disabled, subfile)
+		} else {
+			enabled = append(enabled, subfile)
+		}
+	}
+	return enabled, disabled
+}
 
-		jsTemplate := fmt.Sprintf(
-			`(("undefined"!=typeof self?self:global).webpackChunkopen=("undefined"!=typeof self?self:global).webpackChunkopen||[])
+// matchDisabledFile returns first of `patterns` matching path of `subfile`
+// relative to `folder`, or blank if none does.
+func matchDisabledFile(folder, subfile string, patterns []string) string {
+	rel, err := filepath.Rel(folder, subfile)
+	if err != nil {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(filepath.Clean(pattern))
+		if matched, _ := path.Match(pattern, rel); matched || pattern == rel {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// checkAppDisabledFiles warns about subfiles of custom app skipped because
+// of "disabled_subfiles", and entries of it matching no subfile.
+func checkAppDisabledFiles(app, customAppPath string, manifestJson appManifest) {
+	if len(manifestJson.DisabledFiles) == 0 {
+		return
+	}
+
+	_, disabled := getAppSubfiles(customAppPath, manifestJson)
+	used := map[string]bool{}
+	names := []string{}
+	for _, subfile := range disabled {
+		used[matchDisabledFile(customAppPath, subfile, manifestJson.DisabledFiles)] = true
+		rel, _ := filepath.Rel(customAppPath, subfile)
+		names = append(names, filepath.ToSlash(rel))
+	}
+
+	if len(names) > 0 {
+		utils.PrintWarning(`Custom app "` + app + `" skips disabled subfiles: ` + strings.Join(names, ", "))
+	}
+	for _, pattern := range manifestJson.DisabledFiles {
+		if !used[filepath.ToSlash(filepath.Clean(pattern))] {
+			utils.PrintWarning(`Custom app "` + app + `" disabled subfile "` + pattern + `" matches no subfile.`)
+		}
+	}
+}
+
+// buildAppJS assembles index.js and subfiles of custom app into a webpack
+// chunk, which gets `loadChunk` if app declares additional chunks. Line
+// endings are normalized when it is written, with utils.WriteTextFile.
+func buildAppJS(app string, resolved resolvedApp) (string, error) {
+	appName := `spicetify-routes-` + app
+	jsFileContent, err := os.ReadFile(filepath.Join(resolved.Path, "index.js"))
+	if err != nil {
+		return "", err
+	}
+
+	// Files start with index.js
+	for _, subfilePath := range resolved.Files[1:] {
+		subfileContent, err := os.ReadFile(subfilePath)
+		if err != nil {
+			continue
+		}
+		jsFileContent = append(jsFileContent, '\n')
+		jsFileContent = append(jsFileContent, subfileContent...)
+	}
+
+	chunkLoader := ""
+	if len(resolved.Manifest.Chunks) > 0 {
+		chunkLoader = getChunkLoaderJS(app) + "\n"
+	}
+
+	jsTemplate := fmt.Sprintf(
+		`(("undefined"!=typeof self?self:global).webpackChunkopen=("undefined"!=typeof self?self:global).webpackChunkopen||[])
 .push([["%s"],{"%s":(e,t,n)=>{
 "use strict";n.r(t),n.d(t,{default:()=>render});
-%s
+%s%s
 }}]);`,
-			appName, appName, jsFileContent)
+		appName, appName, chunkLoader, jsFileContent)
 
-		os.WriteFile(
-			filepath.Join(appDestPath, "xpui", appName + ".js"), 
-			[]byte(jsTemplate),
-			0700)
+	return jsTemplate, nil
+}
 
-		cssFile := filepath.Join(customAppPath, "style.css")
-		cssFileContent, err := os.ReadFile(cssFile)
+// PrintInjectedJS prints JS that apply injects for custom app `app`, main
+// chunk then additional chunks, without writing anything.
+func PrintInjectedJS(app string) {
+	resolved, err := resolveApp(app)
+	if err != nil {
+		utils.PrintError(`Custom app "` + app + `" not found.`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	jsTemplate, err := buildAppJS(app, resolved)
+	if err != nil {
+		utils.PrintError(`Custom app "` + app + `" does not have index.js`)
+		utils.Exit(utils.ExitFailure)
+	}
+	fmt.Println("// spicetify-routes-" + app + ".js")
+	fmt.Println(jsTemplate)
+
+	// Chunk ids depend on every configured app
+	appList := getCustomAppList()
+	if !containsString(appList, app) {
+		appList = append(appList, app)
+	}
+	for _, chunk := range getAppsChunks(appList, true)[app] {
+		chunkJS, err := buildChunkJS(chunk)
 		if err != nil {
-			cssFileContent = []byte{}
+			utils.PrintError(err.Error())
+			continue
 		}
-		os.WriteFile(
-			filepath.Join(appDestPath, "xpui", appName + ".css"), 
-			[]byte(cssFileContent),
-			0700)
+		fmt.Println("\n// " + chunk.ID + ".js")
+		fmt.Println(chunkJS)
 	}
 }
 
@@ -334,19 +1251,33 @@ func toTernary(key string) utils.TernaryBool {
 	return utils.TernaryBool(featureSection.Key(key).MustInt(0))
 }
 
+// nodeModuleSymlink links node_modules folder of Extensions folder into
+// xpui, or copies it when linking fails and config
+// "node_modules_copy_fallback" is on.
 func nodeModuleSymlink() {
 	nodeModulePath, err := getExtensionPath("node_modules")
 	if err != nil {
 		return
 	}
 
-	utils.PrintBold(`Found node_modules folder. Creating node_modules symlink:`)
+	utils.PrintStage(`Found node_modules folder. Creating node_modules symlink`)
 
-	nodeModuleDest := filepath.Join(appDestPath, "xpui", "node_modules")
+	nodeModuleDest := filepath.Join(getXpuiPath(), "node_modules")
 	if err = utils.CreateJunction(nodeModulePath, nodeModuleDest); err != nil {
-		utils.PrintError("Cannot create node_modules symlink")
-		return
+		if !settingSection.Key("node_modules_copy_fallback").MustBool(false) {
+			utils.PrintError("Cannot create node_modules symlink")
+			utils.PrintInfo(`Enable config "node_modules_copy_fallback" to copy node_modules folder instead.`)
+			return
+		}
+
+		utils.PrintWarning("Cannot create node_modules symlink, copying node_modules folder instead. Changes to it only take effect on next apply.")
+		os.RemoveAll(nodeModuleDest)
+		if err = utils.Copy(nodeModulePath, nodeModuleDest, true, nil); err != nil {
+			utils.PrintError("Cannot copy node_modules folder: " + err.Error())
+			utils.MarkPartialFailure()
+			return
+		}
 	}
 
-	utils.PrintGreen("OK")
+	utils.PrintStageDone()
 }
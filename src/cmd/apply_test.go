@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// writeFiles creates `files`, keyed by slash-separated path, under `dir`
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// useAppsFolder makes custom apps be looked up in `dir` for the test
+func useAppsFolder(t *testing.T, dir string) {
+	saved := userAppsFolder
+	userAppsFolder = dir
+	t.Cleanup(func() { userAppsFolder = saved })
+}
+
+// buildAppFile resolves custom app `app`, builds its JS and writes it like
+// apply does, returning written content
+func buildAppFile(t *testing.T, app string) []byte {
+	t.Helper()
+	resolved, err := resolveApp(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := buildAppJS(app, resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), app+".js")
+	if err = utils.WriteTextFile(out, []byte(js), 0600); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestBuildAppJSIsReproducible(t *testing.T) {
+	apps := t.TempDir()
+	useAppsFolder(t, apps)
+	writeFiles(t, filepath.Join(apps, "repro"), map[string]string{
+		"manifest.json": `{"name": "repro", "subfiles": ["z.js", "lib/*.js", "a.js", "lib/b.js"]}`,
+		"index.js":      "index();\r\n",
+		"z.js":          "z();\r\n",
+		"a.js":          "a();\n",
+		"lib/c.js":      "libC();\r\n",
+		"lib/a.js":      "libA();\r\n",
+		"lib/b.js":      "libB();\r\n",
+	})
+
+	resolved, err := resolveApp("repro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Manifest order, glob matches sorted and listed files not repeated
+	want := []string{"index.js", "z.js", "lib/a.js", "lib/b.js", "lib/c.js", "a.js"}
+	if got := relativePaths(resolved.Path, resolved.Files); !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	first := buildAppFile(t, "repro")
+	second := buildAppFile(t, "repro")
+	if !bytes.Equal(first, second) {
+		t.Errorf("builds differ:\n%s\n---\n%s", first, second)
+	}
+
+	if bytes.Contains(first, []byte("\r")) {
+		t.Errorf("CRLF line endings are not normalized:\n%q", first)
+	}
+	order := []string{"index();", "z();", "libA();", "libB();", "libC();", "a();"}
+	last := -1
+	for _, code := range order {
+		index := bytes.Index(first, []byte(code))
+		if index <= last {
+			t.Fatalf("%s is out of order in:\n%s", code, first)
+		}
+		last = index
+	}
+}
+
+func TestBuildAppJSKeepsLineEndingsWhenNotNormalizing(t *testing.T) {
+	apps := t.TempDir()
+	useAppsFolder(t, apps)
+	writeFiles(t, filepath.Join(apps, "crlf"), map[string]string{
+		"index.js": "index();\r\n",
+	})
+
+	utils.SetNormalizeLineEndings(false)
+	t.Cleanup(func() { utils.SetNormalizeLineEndings(true) })
+
+	if content := buildAppFile(t, "crlf"); !bytes.Contains(content, []byte("index();\r\n")) {
+		t.Errorf("CRLF line endings are changed:\n%q", content)
+	}
+}
+
+func TestUniqueApps(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		// calls is how many times uniqueApps runs in the same process
+		calls    int
+		want     []string
+		warnings map[string]int
+	}{
+		{"no duplicates", "a|b|c", 1, []string{"a", "b", "c"}, map[string]int{}},
+		{"duplicates keep first occurrence", "a|b|a|c|b", 1, []string{"a", "b", "c"}, map[string]int{"a": 1, "b": 1}},
+		{"repeated more than twice", "c|a|c|c", 1, []string{"c", "a"}, map[string]int{"c": 1}},
+		{"warned once per run", "b|a|b", 3, []string{"b", "a"}, map[string]int{"b": 1}},
+	}
+
+	savedOutput := log.Writer()
+	t.Cleanup(func() { log.SetOutput(savedOutput) })
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			duplicateAppsWarned = map[string]bool{}
+			var output bytes.Buffer
+			log.SetOutput(&output)
+
+			var got []string
+			for i := 0; i < c.calls; i++ {
+				got = uniqueApps(strings.Split(c.input, "|"))
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("uniqueApps(%q) = %v, want %v", c.input, got, c.want)
+			}
+			for _, app := range strings.Split(c.input, "|") {
+				warning := `"` + app + `" is listed more than once`
+				if count := strings.Count(output.String(), warning); count != c.warnings[app] {
+					t.Errorf("warned about %q %d times, want %d", app, count, c.warnings[app])
+				}
+			}
+		})
+	}
+}
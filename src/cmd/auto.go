@@ -1,34 +1,205 @@
 package cmd
 
 import (
-	"os"
+	"log"
+	"path/filepath"
+	"strings"
 
+	"github.com/khanhas/spicetify-cli/src/backup"
 	backupstatus "github.com/khanhas/spicetify-cli/src/status/backup"
 	spotifystatus "github.com/khanhas/spicetify-cli/src/status/spotify"
+	"github.com/khanhas/spicetify-cli/src/utils"
 )
 
 // Auto checks Spotify state, re-backup and apply if needed, then launch
 // Spotify client normally.
 func Auto() {
-	backupVersion := backupSection.Key("version").MustString("")
+	// Auto is meant to run unattended (e.g. from a shortcut), so every
+	// prompt falls back to its quiet mode answer. Commands chained after
+	// it still prompt as usual.
+	defer func(wasQuiet bool) { quiet = wasQuiet }(quiet)
+	quiet = true
+
+	backupVersion := getBackupVersion()
 	spotStat := spotifystatus.Get(appPath)
 	backStat := backupstatus.Get(prefsPath, backupFolder, backupVersion)
 
-	if spotStat.IsBackupable() && (backStat.IsEmpty() || backStat.IsOutdated()) {
-		Backup()
-		backupVersion := backupSection.Key("version").MustString("")
+	if spotStat.IsBackupable() {
+		if backStat.IsEmpty() {
+			Backup()
+		} else if backStat.IsOutdated() || isBackupStale() {
+			spotifyVersion := utils.GetInstalledSpotifyVersion(prefsPath)
+			utils.PrintInfo(`Spotify has been updated (backup: ` + backupVersion + `, Spotify: ` + spotifyVersion + `). Backing up new version.`)
+			Backup()
+		}
+
+		backupVersion = getBackupVersion()
 		backStat = backupstatus.Get(prefsPath, backupFolder, backupVersion)
 	}
 
 	if !backStat.IsBackuped() {
-		os.Exit(1)
+		if backStat.IsOutdated() {
+			utils.PrintError(`Backup is outdated but Spotify cannot be backed up at this state. Please re-install Spotify then run "spicetify auto" again.`)
+		} else {
+			utils.PrintError(`You haven't backed up and Spotify cannot be backed up at this state. Please re-install Spotify then run "spicetify auto" again.`)
+		}
+		utils.Exit(utils.ExitNoBackup)
 	}
 
 	if isAppX {
 		spotStat = spotifystatus.Get(appDestPath)
+	} else {
+		spotStat = spotifystatus.Get(appPath)
 	}
 
-	if !spotStat.IsApplied() && backStat.IsBackuped() {
+	if !spotStat.IsApplied() || isReverted() {
 		Apply()
 	}
 }
+
+// Check compares installed xpui against what was recorded at last apply to
+// detect Spotify overwriting spicetify changes, e.g. by updating itself.
+// When detected, reapplies if "reapply_on_revert" is enabled, otherwise
+// exits with ExitReverted.
+func Check() {
+	if readSourceManifest() == nil {
+		utils.PrintInfo(`Spicetify has not been applied, nothing to check.`)
+		return
+	}
+
+	if !isReverted() {
+		utils.PrintSuccess("Spicetify changes are intact.")
+		return
+	}
+
+	utils.PrintWarning("Spotify has overwritten spicetify changes, possibly by updating itself.")
+
+	if settingSection.Key("reapply_on_revert").MustBool(false) {
+		utils.PrintInfo("Reapplying.")
+		Auto()
+		return
+	}
+
+	utils.PrintInfo(`Run "spicetify auto" to backup if needed and apply again.`)
+	utils.Exit(utils.ExitReverted)
+}
+
+// CheckBackupVersion only reports whether backup matches current Spotify
+// version, cheaply enough for scripts to run on a timer before "auto".
+// Exits with ExitNoBackup, ExitBackupOutdated or ExitBackupCorrupt when it
+// does not.
+func CheckBackupVersion() {
+	status := getBackupReport()
+
+	log.Println("backup            " + status.Backup)
+	log.Println("backup version    " + status.BackupVersion)
+	log.Println("spotify version   " + status.SpotifyVersion)
+	log.Println("spotify           " + status.Spotify)
+	if len(status.BackupNote) > 0 {
+		log.Println("backup note       " + status.BackupNote)
+	}
+	if len(status.DamagedFiles) > 0 {
+		log.Println("damaged files     " + strings.Join(status.DamagedFiles, ", "))
+	}
+
+	if status.code != utils.ExitSuccess {
+		utils.Exit(status.code)
+	}
+}
+
+// backupReport is state of backup and Spotify CheckBackupVersion reports
+type backupReport struct {
+	// Backup is "current", "empty", "outdated" or "corrupt"
+	Backup         string `json:"backup"`
+	BackupVersion  string `json:"backup_version"`
+	SpotifyVersion string `json:"spotify_version"`
+	// Spotify is "stock", "mixed", "applied" or "invalid"
+	Spotify      string   `json:"spotify"`
+	BackupNote   string   `json:"backup_note,omitempty"`
+	DamagedFiles []string `json:"damaged_files,omitempty"`
+	// code is exit code for Backup state
+	code int
+}
+
+func getBackupReport() backupReport {
+	backupVersion := getBackupVersion()
+	backStat := backupstatus.Get(prefsPath, backupFolder, backupVersion)
+
+	spotStat := spotifystatus.Get(appDestPath)
+	spotifyState := "invalid"
+	switch {
+	case spotStat.IsStock():
+		spotifyState = "stock"
+	case spotStat.IsMixed():
+		spotifyState = "mixed"
+	case spotStat.IsApplied():
+		spotifyState = "applied"
+	}
+
+	state, code := "current", utils.ExitSuccess
+	damaged := []string{}
+	switch {
+	case backStat.IsEmpty():
+		state, code = "empty", utils.ExitNoBackup
+	case backStat.IsOutdated():
+		state, code = "outdated", utils.ExitBackupOutdated
+	default:
+		if damaged = backup.QuickVerify(backupFolder); len(damaged) > 0 {
+			state, code = "corrupt", utils.ExitBackupCorrupt
+		}
+	}
+
+	return backupReport{
+		Backup:         state,
+		BackupVersion:  backupVersion,
+		SpotifyVersion: utils.GetInstalledSpotifyVersion(prefsPath),
+		Spotify:        spotifyState,
+		BackupNote:     backup.ReadNote(backupFolder),
+		DamagedFiles:   damaged,
+		code:           code,
+	}
+}
+
+// isReverted reports whether spicetify was applied but its changes are no
+// longer in Spotify Apps folder.
+func isReverted() bool {
+	manifest := readSourceManifest()
+	if manifest == nil {
+		return false
+	}
+
+	if !spotifystatus.Get(appDestPath).IsApplied() {
+		return true
+	}
+
+	// Recorded by older versions without applied state checksum
+	if len(manifest["applied"]) == 0 {
+		return false
+	}
+
+	return manifest["applied"] != hashAppliedState()
+}
+
+// isBackupStale reports whether stock packages currently in Spotify Apps
+// folder differ from backed up ones. Spotify only updates "prefs" version
+// after first launch, so a self-update can slip past version comparison.
+func isBackupStale() bool {
+	spaList, err := filepath.Glob(filepath.Join(appPath, "*.spa"))
+	if err != nil || len(spaList) == 0 {
+		return false
+	}
+
+	for _, spa := range spaList {
+		current, err := utils.FileChecksum(spa)
+		if err != nil {
+			continue
+		}
+
+		backedUp, err := utils.FileChecksum(filepath.Join(backupFolder, filepath.Base(spa)))
+		if err != nil || current != backedUp {
+			return true
+		}
+	}
+
+	return false
+}
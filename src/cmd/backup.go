@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	spotifystatus "github.com/khanhas/spicetify-cli/src/status/spotify"
 
@@ -16,7 +20,7 @@ import (
 // Backup stores original apps packages, extracts them and preprocesses
 // extracted apps' assets
 func Backup() {
-	backupVersion := backupSection.Key("version").MustString("")
+	backupVersion := getBackupVersion()
 	backStat := backupstatus.Get(prefsPath, backupFolder, backupVersion)
 	if !backStat.IsEmpty() {
 		utils.PrintInfo("There is available backup.")
@@ -24,36 +28,106 @@ func Backup() {
 
 		spotStat := spotifystatus.Get(appPath)
 		if spotStat.IsBackupable() {
+			if flags.Incremental {
+				archiveBackup()
+			}
 			clearBackup()
 
 		} else {
 			utils.PrintWarning(`After clearing backup, Spotify cannot be backed up again.`)
 			utils.PrintInfo(`Please restore first then backup, run "spicetify restore backup" or re-install Spotify then run "spicetify backup".`)
-			os.Exit(1)
+			utils.Exit(utils.ExitSpotifyError)
+		}
+	}
+
+	exclude := []string{}
+	for _, pattern := range flags.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			utils.PrintError(`Invalid exclude pattern "` + pattern + `".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		exclude = append(exclude, pattern)
+	}
+
+	for _, app := range backup.RequiredApps {
+		if backup.IsExcluded(app+".spa", exclude) {
+			utils.PrintWarning(`"` + app + `.spa" is needed for a clean restore and cannot be excluded. It is backed up anyway.`)
 		}
 	}
 
 	utils.PrintBold("Backing up app files:")
 
-	if err := backup.Start(appPath, backupFolder); err != nil {
-		log.Fatal(err)
+	files, excluded, err := backup.Plan(appPath, exclude)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	if len(files) == 0 {
+		utils.PrintError("Cannot backup app files. Reinstall Spotify and try again.")
+		utils.Exit(utils.ExitSpotifyError)
+	}
+
+	previous := ""
+	if history := getBackupHistory(); flags.Incremental && len(history) > 0 {
+		previous = history[len(history)-1]
+	}
+
+	tracker := utils.NewTracker(len(files))
+	linked, err := backup.StartIncremental(appPath, backupFolder, previous, files, tracker.Update)
+	if err != nil {
+		utils.Fatal(err)
+	}
+	tracker.Finish()
+
+	backupLauncher()
+
+	if flags.Incremental {
+		utils.PrintInfo(fmt.Sprintf("%d of %d files are unchanged and linked to previous backup.", linked, len(files)))
 	}
 
+	if len(excluded) > 0 {
+		utils.PrintInfo("Excluded: " + strings.Join(excluded, ", "))
+	}
+	backupSection.Key("excluded").SetValue(strings.Join(excluded, "|"))
+
 	appList, err := ioutil.ReadDir(backupFolder)
 	if err != nil {
-		log.Fatal(err)
+		utils.Fatal(err)
 	}
 
-	totalApp := len(appList)
-	if totalApp > 0 {
-		utils.PrintGreen("OK")
-	} else {
-		utils.PrintError("Cannot backup app files. Reinstall Spotify and try again.")
-		os.Exit(1)
+	totalApp := 0
+	var totalSize int64
+	for _, file := range appList {
+		if strings.HasSuffix(file.Name(), ".spa") {
+			totalApp++
+			totalSize += file.Size()
+		}
+	}
+
+	spotifyVersion := utils.GetInstalledSpotifyVersion(prefsPath)
+	utils.PrintInfo(fmt.Sprintf("Backed up %d files (%s) to %s", totalApp, utils.FormatSize(totalSize), backupFolder))
+	utils.PrintInfo("Spotify version: " + spotifyVersion)
+
+	extractBackup()
+
+	backupSection.Key("version").SetValue(spotifyVersion)
+	cfg.Write()
+	if err := backup.WriteVersion(backupFolder, spotifyVersion); err != nil {
+		utils.PrintWarning("Cannot record Spotify version in backup: " + err.Error())
 	}
+	if len(flags.Note) > 0 {
+		if err := backup.WriteNote(backupFolder, flags.Note); err != nil {
+			utils.PrintWarning("Cannot record note in backup: " + err.Error())
+		}
+	}
+	utils.PrintSuccess("Everything is ready, you can start applying now!")
+}
 
+// extractBackup extracts app packages of backup to raw assets folder,
+// preprocesses them and derives themed assets from them
+func extractBackup() {
 	utils.PrintBold("Extracting:")
-	tracker := utils.NewTracker(totalApp)
+	tracker := utils.NewTracker(len(backup.RequiredApps))
 
 	backup.Extract(backupFolder, rawFolder, tracker.Update)
 	tracker.Finish()
@@ -76,7 +150,7 @@ func Backup() {
 
 	tracker.Finish()
 
-	err = utils.Copy(rawFolder, themedFolder, true, []string{".html", ".js", ".css"})
+	err := utils.Copy(rawFolder, themedFolder, true, []string{".html", ".js", ".css"})
 	if err != nil {
 		utils.Fatal(err)
 	}
@@ -85,10 +159,54 @@ func Backup() {
 
 	preprocess.StartCSS(themedFolder, tracker.Update)
 	tracker.Finish()
+}
 
-	backupSection.Key("version").SetValue(utils.GetSpotifyVersion(prefsPath))
-	cfg.Write()
-	utils.PrintSuccess("Everything is ready, you can start applying now!")
+// backupHistoryLimit is how many previous backups incremental backup keeps
+const backupHistoryLimit = 3
+
+func getBackupHistoryFolder() string {
+	return filepath.Join(stateFolder, "BackupHistory")
+}
+
+// getBackupHistory returns paths of previous backups, oldest first
+func getBackupHistory() []string {
+	entries, err := ioutil.ReadDir(getBackupHistoryFolder())
+	if err != nil {
+		return nil
+	}
+
+	history := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			history = append(history, filepath.Join(getBackupHistoryFolder(), entry.Name()))
+		}
+	}
+
+	// Names start with a timestamp
+	sort.Strings(history)
+	return history
+}
+
+// archiveBackup moves current backup to backup history, for incremental
+// backup to link unchanged files to. Only backupHistoryLimit latest
+// backups are kept.
+func archiveBackup() {
+	name := time.Now().Format("20060102-150405")
+	if version := getBackupVersion(); len(version) > 0 {
+		name += "-" + version
+	}
+
+	utils.CheckExistAndCreate(getBackupHistoryFolder())
+	if err := os.Rename(backupFolder, filepath.Join(getBackupHistoryFolder(), name)); err != nil {
+		utils.PrintWarning("Cannot keep previous backup in history: " + err.Error())
+		return
+	}
+
+	history := getBackupHistory()
+	for len(history) > backupHistoryLimit {
+		os.RemoveAll(history[0])
+		history = history[1:]
+	}
 }
 
 // Clear clears current backup. Before clearing, it checks whether Spotify is in
@@ -99,7 +217,7 @@ func Clear() {
 	if !spotStat.IsBackupable() {
 		utils.PrintWarning("Before clearing backup, please restore or re-install Spotify to stock state.")
 		if !ReadAnswer("Continue clearing anyway? [y/N]: ", false, true) {
-			os.Exit(1)
+			utils.Exit(utils.ExitFailure)
 		}
 	}
 
@@ -123,13 +241,41 @@ func clearBackup() {
 	os.Mkdir(themedFolder, 0700)
 
 	backupSection.Key("version").SetValue("")
+	backupSection.Key("excluded").SetValue("")
 	cfg.Write()
+	clearSourceManifest()
 	utils.PrintSuccess("Backup is cleared.")
 }
 
+// getBackupVersion returns Spotify version of backup in use, recorded in
+// config, or in backup folder itself for backup given with "--backup".
+func getBackupVersion() string {
+	if len(flags.Backup) > 0 {
+		return backup.ReadVersion(backupFolder)
+	}
+	return backupSection.Key("version").MustString("")
+}
+
+// checkBackupOverride makes sure backup given with "--backup" is usable
+func checkBackupOverride() {
+	if len(flags.Backup) == 0 {
+		return
+	}
+
+	if backupstatus.Get(prefsPath, backupFolder, getBackupVersion()).IsEmpty() {
+		utils.PrintError(`"` + backupFolder + `" is not a valid backup: it has no Spotify app packages (".spa" files).`)
+		utils.Exit(utils.ExitNoBackup)
+	}
+
+	if len(getBackupVersion()) == 0 {
+		utils.PrintWarning(`Backup "` + backupFolder + `" does not record which Spotify version it is from, so it is treated as mismatched.`)
+	}
+	utils.PrintInfo("Using backup " + backupFolder)
+}
+
 // Restore uses backup to revert every changes made by Spicetify.
 func Restore() {
-	backupVersion := backupSection.Key("version").MustString("")
+	backupVersion := getBackupVersion()
 	backStat := backupstatus.Get(prefsPath, backupFolder, backupVersion)
 	spotStat := spotifystatus.Get(appPath)
 
@@ -139,7 +285,7 @@ func Restore() {
 		if !spotStat.IsBackupable() {
 			utils.PrintWarning(`But Spotify cannot be backed up at this state. Please re-install Spotify then run "spicetify backup"`)
 		}
-		os.Exit(1)
+		utils.Exit(utils.ExitNoBackup)
 
 	} else if backStat.IsOutdated() {
 		utils.PrintWarning("Spotify version and backup version are mismatched.")
@@ -149,17 +295,56 @@ func Restore() {
 		}
 
 		if !ReadAnswer("Continue restoring anyway? [y/N] ", false, true) {
-			os.Exit(1)
+			utils.Exit(utils.ExitFailure)
 		}
 	}
 
-	if err := os.RemoveAll(appDestPath); err != nil {
+	if damaged := backup.Verify(backupFolder); len(damaged) > 0 {
+		utils.PrintError("Backup is damaged, these files do not match what was backed up: " + strings.Join(damaged, ", "))
+		utils.PrintInfo(`Please re-install Spotify then run "spicetify backup".`)
+		utils.Exit(utils.ExitNoBackup)
+	}
+
+	restoreApps()
+	restoreLauncher()
+	utils.PrintSuccess("Spotify is restored.")
+}
+
+// restoreApps replaces Apps folder content with backed up app packages
+func restoreApps() {
+	clearSourceManifest()
+
+	if err := clearAppsFolder(appDestPath); err != nil {
 		utils.Fatal(err)
 	}
 
 	if err := utils.Copy(backupFolder, appDestPath, false, []string{".spa"}); err != nil {
 		utils.Fatal(err)
 	}
+}
 
-	utils.PrintSuccess("Spotify is restored.")
+// clearAppsFolder removes everything in Apps folder at `path`, except app
+// packages excluded from backup, since backup cannot bring them back.
+func clearAppsFolder(path string) error {
+	excluded := utils.ListValues(backupSection.Key("excluded"))
+	if len(excluded) == 0 {
+		return os.RemoveAll(path)
+	}
+
+	fileList, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range fileList {
+		if containsString(excluded, file.Name()) {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(path, file.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/khanhas/spicetify-cli/src/backup"
+	backupstatus "github.com/khanhas/spicetify-cli/src/status/backup"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+type backupInfo struct {
+	// Name is "current" for backup in use, otherwise folder name of backup
+	// in history
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Version string    `json:"version"`
+	Date    time.Time `json:"date"`
+	Note    string    `json:"note"`
+}
+
+// ListBackups prints current backup and previous ones kept in history by
+// "backup --incremental", newest first, with Spotify version, date and
+// note given with "--note". Output is JSON when "--json" is used.
+func ListBackups() {
+	infos := []backupInfo{}
+	if !backupstatus.Get(prefsPath, backupFolder, getBackupVersion()).IsEmpty() {
+		current := getBackupInfo("current", backupFolder)
+		if len(current.Version) == 0 {
+			// Backups made before version was recorded in them
+			current.Version = getBackupVersion()
+		}
+		infos = append(infos, current)
+	}
+	history := getBackupHistory()
+	for i := len(history) - 1; i >= 0; i-- {
+		infos = append(infos, getBackupInfo(filepath.Base(history[i]), history[i]))
+	}
+
+	if flags.JSON {
+		out, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			utils.Fatal(err)
+		}
+		log.Println(string(out))
+		return
+	}
+
+	if len(infos) == 0 {
+		utils.PrintInfo(`There is no backup. Run "spicetify backup" to make one.`)
+		return
+	}
+
+	for _, info := range infos {
+		version := info.Version
+		if len(version) == 0 {
+			version = "unknown"
+		}
+		log.Println(utils.Bold(info.Name) + "  " + info.Date.Format("2006-01-02 15:04") + "  Spotify " + version)
+		if len(info.Note) > 0 {
+			log.Println("    " + info.Note)
+		}
+		log.Println("    " + info.Path)
+	}
+}
+
+// getBackupInfo describes backup at `path`. Its date is when its version
+// was recorded, which is when backing up finished.
+func getBackupInfo(name, path string) backupInfo {
+	info := backupInfo{
+		Name:    name,
+		Path:    path,
+		Version: backup.ReadVersion(path),
+		Note:    backup.ReadNote(path),
+	}
+	if stat, err := os.Stat(filepath.Join(path, backup.VersionName)); err == nil {
+		info.Date = stat.ModTime()
+	} else if stat, err := os.Stat(path); err == nil {
+		info.Date = stat.ModTime()
+	}
+	return info
+}
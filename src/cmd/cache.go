@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"path/filepath"
+	"strconv"
+
+	"github.com/khanhas/spicetify-cli/src/cache"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// getCacheFolder returns folder that every cached file, e.g. downloads or
+// build outputs, is stored in through package cache.
+func getCacheFolder() string {
+	return filepath.Join(spicetifyFolder, "Cache")
+}
+
+// fetchCached downloads whole content at `url` and caches it. When download
+// fails for other reasons than server response, e.g. while offline or on
+// timeout, last cached content is used instead, with a warning.
+func fetchCached(url string) ([]byte, error) {
+	key := cache.Key("download", url)
+	content, err := utils.FetchURL(url)
+	if err == nil {
+		if err = cache.Put(getCacheFolder(), key, content); err != nil {
+			utils.PrintWarning("Cannot cache download: " + err.Error())
+		}
+		return content, nil
+	}
+
+	var statusErr *utils.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return nil, err
+	}
+	cached, ok := cache.Get(getCacheFolder(), key)
+	if !ok {
+		return nil, err
+	}
+	utils.PrintWarning("Cannot download " + url + ": " + err.Error() + ". Using cached copy instead.")
+	return cached, nil
+}
+
+// CacheInfo prints cache folder location, number of entries and their size
+func CacheInfo() {
+	count, size, err := cache.Info(getCacheFolder())
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	utils.PrintBold("Cache")
+	log.Println("folder    " + getCacheFolder())
+	log.Println("entries   " + strconv.Itoa(count))
+	log.Println("size      " + utils.FormatSize(size))
+}
+
+// CleanCache removes every cached entry and reports reclaimed space
+func CleanCache() {
+	size, err := cache.Clean(getCacheFolder())
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	utils.PrintSuccess("Cache is cleaned, reclaimed " + utils.FormatSize(size) + ".")
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// appChunk is an additional route chunk of custom app, lazy-loaded by its
+// main chunk with `loadChunk(name)`.
+type appChunk struct {
+	Name string
+	ID   string
+	Path string
+}
+
+var chunkNameRegex = regexp.MustCompile(`^[\w-]+$`)
+
+func getAppChunkID(app, chunkName string) string {
+	return "spicetify-routes-" + app + "-" + chunkName
+}
+
+// getAppsChunks returns valid additional chunks of every app in
+// `appList`, sorted by name. Chunks with invalid names, missing files or ids
+// that collide with another app's chunk are left out, with an error printed
+// when `warn` is true.
+func getAppsChunks(appList []string, warn bool) map[string][]appChunk {
+	report := func(app, chunkName, reason string) {
+		if warn {
+			utils.PrintError(`Custom app "` + app + `" chunk "` + chunkName + `" is skipped: ` + reason)
+			utils.MarkPartialFailure()
+		}
+	}
+
+	// Main chunk ids are reserved first so that a chunk never shadows an app
+	owners := map[string]string{}
+	for _, app := range appList {
+		owners["spicetify-routes-"+app] = app
+	}
+
+	result := map[string][]appChunk{}
+	for _, app := range appList {
+		customAppPath, err := getCustomAppPath(app)
+		if err != nil {
+			continue
+		}
+
+		_, manifestJson := readAppManifest(customAppPath)
+
+		names := []string{}
+		for name := range manifestJson.Chunks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if !chunkNameRegex.MatchString(name) {
+				report(app, name, "name can only contain letters, digits, "+`"_" and "-"`)
+				continue
+			}
+
+			chunkPath := filepath.Join(customAppPath, manifestJson.Chunks[name])
+			if _, err := os.Stat(chunkPath); err != nil {
+				report(app, name, `file "`+manifestJson.Chunks[name]+`" not found`)
+				continue
+			}
+
+			id := getAppChunkID(app, name)
+			if owner, taken := owners[id]; taken {
+				report(app, name, `id "`+id+`" is already used by custom app "`+owner+`"`)
+				continue
+			}
+			owners[id] = app
+
+			result[app] = append(result[app], appChunk{name, id, chunkPath})
+		}
+	}
+
+	return result
+}
+
+// getAppChunkIDs returns ids of all valid additional chunks of custom apps
+// in `appList`.
+func getAppChunkIDs(appList []string) []string {
+	ids := []string{}
+	chunks := getAppsChunks(appList, false)
+	for _, app := range appList {
+		for _, chunk := range chunks[app] {
+			ids = append(ids, chunk.ID)
+		}
+	}
+
+	return ids
+}
+
+// buildChunkJS wraps content of additional chunk file into a webpack chunk
+// containing one module of the same id. Chunk code sets its exports on
+// `exports` or `module.exports`.
+func buildChunkJS(chunk appChunk) (string, error) {
+	content, err := os.ReadFile(chunk.Path)
+	if err != nil {
+		return "", err
+	}
+
+	jsTemplate := fmt.Sprintf(
+		`(("undefined"!=typeof self?self:global).webpackChunkopen=("undefined"!=typeof self?self:global).webpackChunkopen||[])
+.push([["%s"],{"%s":(module,exports,__webpack_require__)=>{
+%s
+}}]);`,
+		chunk.ID, chunk.ID, content)
+
+	return jsTemplate, nil
+}
+
+// getChunkLoaderJS returns declaration of `loadChunk` function available in
+// custom app main chunk, which resolves to exports of chunk `name`.
+func getChunkLoaderJS(app string) string {
+	return fmt.Sprintf(
+		`const loadChunk=(name)=>n.e("%s"+name).then(n.bind(n,"%s"+name));`,
+		getAppChunkID(app, ""), getAppChunkID(app, ""))
+}
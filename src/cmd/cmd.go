@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/go-ini/ini"
+	spotifystatus "github.com/khanhas/spicetify-cli/src/status/spotify"
 	"github.com/khanhas/spicetify-cli/src/utils"
 )
 
@@ -32,13 +34,165 @@ var (
 	featureSection          *ini.Section
 	patchSection            *ini.Section
 	themeFolder             string
+	themeFolders            []string
 	colorCfg                *ini.File
 	colorSection            *ini.Section
+	colorScheme             map[string]string
+	themeSchemeNames        map[string]string
 	injectCSS               bool
 	replaceColors           bool
 	overwriteAssets         bool
+	injectSplash            bool
+	appArgs                 []string
 )
 
+// Flag holds command-line flags that alter how commands behave.
+type Flag struct {
+	// Full makes apply reprocess everything instead of only changed sources.
+	Full bool
+	// From overrides Spotify Apps folder location.
+	From string
+	// Exclude lists glob patterns of app packages backup skips.
+	Exclude []string
+	// Verify makes apply check that injected files ended up in place.
+	Verify bool
+	// JSON makes config dump print JSON instead of INI.
+	JSON bool
+	// Unsafe makes config dump keep sensitive values.
+	Unsafe bool
+	// MJS makes extension scaffold generate a module.
+	MJS bool
+	// TypeScript makes extension scaffold generate TypeScript source.
+	TypeScript bool
+	// Register makes extension scaffold add extension to config.
+	Register bool
+	// NoRawCopy makes first apply keep existing files in Apps folder
+	// instead of replacing them with raw assets.
+	NoRawCopy bool
+	// AssumeApplied and AssumeFresh make apply treat Spotify as already
+	// applied, or as stock, instead of detecting it.
+	AssumeApplied bool
+	AssumeFresh   bool
+	// WatchSpotify keeps apply running to reapply whenever Spotify
+	// overwrites changes.
+	WatchSpotify bool
+	// AppArgs holds values of "--app-args", each a command-line string of
+	// extra Spotify arguments.
+	AppArgs []string
+	// AccentFollowSystem replaces accent colors of color scheme with OS
+	// accent color, same as config "accent_follow_system".
+	AccentFollowSystem bool
+	// Interactive makes apply ask which extensions and custom apps to
+	// enable first.
+	Interactive bool
+	// ExtensionsFrom and AppsFrom are files, or "-" for stdin, listing
+	// extensions and custom apps to use instead of config for this run.
+	ExtensionsFrom string
+	AppsFrom       string
+	// Theme replaces config "current_theme" for this run.
+	Theme string
+	// NoBackupCheck lets apply continue when there is no backup.
+	NoBackupCheck bool
+	// StrictExtensions and StrictApps make apply fail when an extension or
+	// custom app cannot be applied, instead of skipping it.
+	StrictExtensions bool
+	StrictApps       bool
+	// StrictColors makes apply and update fail when a color scheme value
+	// is not a color, instead of using default color for it.
+	StrictColors bool
+	// KeepGoing makes apply continue past a failed stage, skipping only
+	// stages depending on it, and summarize problems at the end.
+	KeepGoing bool
+	// Force makes themes install replace installed theme of the same name,
+	// and apply continue when Spotify is not at CompareVersion.
+	Force bool
+	// Incremental makes backup keep previous backup in history and
+	// hardlink files unchanged since then instead of copying them.
+	Incremental bool
+	// Purge makes uninstall delete spicetify folder too.
+	Purge bool
+	// AssetsOnly makes apply only overwrite custom assets of theme.
+	AssetsOnly bool
+	// PrintInjectedJS is custom app that apply only prints injected JS of.
+	PrintInjectedJS string
+	// Locked makes apply fail when extensions or custom apps differ from
+	// lockfile.
+	Locked bool
+	// Repair makes apply only bring back applied files that are missing or
+	// changed.
+	Repair bool
+	// Backup overrides backup folder, for restore, check and diff-backup.
+	Backup string
+	// ColorScheme overrides config "color_scheme" for this run.
+	ColorScheme string
+	// Note is recorded in backup made by this run.
+	Note string
+	// CompareVersion is Spotify version apply refuses to run against any
+	// other of.
+	CompareVersion string
+	// MeasureSize makes apply report size of frontend folder before and
+	// after, and what applied files contribute to it.
+	MeasureSize bool
+	// BackupVersionCheckOnly makes check only report whether backup matches
+	// Spotify version.
+	BackupVersionCheckOnly bool
+	// Install is name of "[Install <name>]" Spotify install commands run
+	// against instead of main one.
+	Install string
+	// AllInstalls makes commands run against main and every additional
+	// Spotify install in turn.
+	AllInstalls bool
+}
+
+var flags Flag
+
+// InitFlags stores command-line flags for commands to use.
+func InitFlags(f Flag) {
+	flags = f
+
+	for _, value := range f.AppArgs {
+		args, err := utils.SplitArgs(value)
+		if err != nil {
+			utils.PrintError(`Invalid "--app-args": ` + err.Error())
+			utils.Exit(utils.ExitConfigError)
+		}
+		appArgs = append(appArgs, args...)
+	}
+
+	initOverrides(f)
+
+	if len(f.Backup) > 0 {
+		dir, err := filepath.Abs(f.Backup)
+		if err == nil {
+			var info os.FileInfo
+			if info, err = os.Stat(dir); err == nil && !info.IsDir() {
+				err = errors.New("not a folder")
+			}
+		}
+		if err != nil {
+			utils.PrintError(`Invalid "--backup": ` + err.Error())
+			utils.Exit(utils.ExitConfigError)
+		}
+		backupFolder = dir
+	}
+
+	if len(f.From) > 0 && (len(f.Install) > 0 || f.AllInstalls) {
+		utils.PrintError(`"--from" cannot be used with "--install" or "--all-installs".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	if len(f.Install) > 0 {
+		if f.AllInstalls {
+			utils.PrintError(`"--install" cannot be used with "--all-installs".`)
+			utils.Exit(utils.ExitConfigError)
+		}
+		if err := UseInstall(f.Install); err != nil {
+			utils.PrintError(err.Error())
+			utils.Exit(utils.ExitConfigError)
+		}
+	}
+}
+
 // InitConfig gets and parses config file.
 func InitConfig(isQuiet bool) {
 	quiet = isQuiet
@@ -55,17 +209,31 @@ func InitConfig(isQuiet bool) {
 // tries to auto-detect them and stops spicetify when any one
 // of them is invalid.
 func InitPaths() {
-	spotifyPath = settingSection.Key("spotify_path").String()
+	defer checkBackupOverride()
+
+	if len(flags.From) > 0 {
+		initPathsFrom(flags.From)
+		return
+	}
+
+	section := getInstallSection()
+	spotifyPath = section.Key("spotify_path").String()
 
 	if len(spotifyPath) == 0 {
+		// Detection finds main Spotify, which additional installs are not
+		if len(installName) > 0 {
+			utils.PrintError(`Please set "spotify_path" in ` + getInstallConfigLabel() + ` to directory of Spotify.`)
+			utils.Exit(utils.ExitConfigError)
+		}
+
 		spotifyPath = utils.FindAppPath()
 
 		if len(spotifyPath) == 0 {
 			utils.PrintError(`Cannot detect Spotify location. Please manually set "spotify_path" in config-xpui.ini`)
-			os.Exit(1)
+			utils.Exit(utils.ExitSpotifyError)
 		}
 
-		settingSection.Key("spotify_path").SetValue(spotifyPath)
+		section.Key("spotify_path").SetValue(spotifyPath)
 		cfg.Write()
 	}
 
@@ -75,34 +243,28 @@ func InitPaths() {
 
 	if _, err := os.Stat(spotifyPath); err != nil {
 		if isAppX {
-			settingSection.Key("spotify_path").SetValue("")
+			section.Key("spotify_path").SetValue("")
 			isAppX = false
 			InitPaths()
 			return
 		}
-		utils.PrintError(spotifyPath + ` does not exist or is not a valid path. Please manually set "spotify_path" in config-xpui.ini to correct directory of Spotify.`)
-		os.Exit(1)
+		utils.PrintError(spotifyPath + ` does not exist or is not a valid path. Please manually set "spotify_path" in ` + getInstallConfigLabel() + ` to correct directory of Spotify.`)
+		utils.Exit(utils.ExitSpotifyError)
 	}
 
-	prefsPath = settingSection.Key("prefs_path").String()
-
-	if len(prefsPath) != 0 {
-		if _, err := os.Stat(prefsPath); err != nil {
-			utils.PrintError(prefsPath + ` does not exist or is not a valid path. Please manually set "prefs_path" in config-xpui.ini to correct path of "prefs" file.`)
-			os.Exit(1)
-		}
-	} else if prefsPath = utils.FindPrefFilePath(); len(prefsPath) != 0 {
-		settingSection.Key("prefs_path").SetValue(prefsPath)
-		cfg.Write()
-	} else {
-		utils.PrintError(`Cannot detect Spotify "prefs" file location. Please manually set "prefs_path" in config-xpui.ini`)
-		os.Exit(1)
-	}
+	initPrefsPath()
 
 	appPath = filepath.Join(spotifyPath, "Apps")
 
 	if isAppX {
-		appDestPath = filepath.Join(spicetifyFolder, "AppX")
+		// Store package install folder is protected, so modded Apps folder
+		// lives in package's per-user data folder, which Spotify is then
+		// pointed to with "--app-directory" on launch.
+		appDestPath = utils.FindAppXDataPath()
+		if len(appDestPath) == 0 {
+			utils.PrintWarning("Cannot locate Spotify Windows Store package data folder. Falling back to spicetify config folder.")
+			appDestPath = filepath.Join(stateFolder, "AppX")
+		}
 	} else {
 		appDestPath = appPath
 	}
@@ -110,76 +272,236 @@ func InitPaths() {
 	utils.CheckExistAndCreate(appDestPath)
 }
 
+// initPathsFrom uses Spotify Apps folder at `dir`, given by user, instead
+// of detected or configured Spotify location.
+func initPathsFrom(dir string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	// Accept Spotify folder as well as its Apps folder
+	if _, err := os.Stat(filepath.Join(dir, "Apps")); err == nil {
+		dir = filepath.Join(dir, "Apps")
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		utils.PrintError(dir + ` does not exist or is not a directory.`)
+		utils.Exit(utils.ExitSpotifyError)
+	}
+
+	if spotifystatus.Get(dir).IsInvalid() {
+		utils.PrintError(dir + ` does not look like a Spotify Apps folder: no app package or extracted app is found.`)
+		utils.Exit(utils.ExitSpotifyError)
+	}
+
+	appPath = dir
+	appDestPath = dir
+	spotifyPath = filepath.Dir(dir)
+	isAppX = false
+
+	initPrefsPath()
+	utils.PrintInfo("Using Spotify Apps folder: " + appPath)
+}
+
+func initPrefsPath() {
+	section := getInstallSection()
+	prefsPath = section.Key("prefs_path").String()
+
+	if len(prefsPath) != 0 {
+		if _, err := os.Stat(prefsPath); err != nil {
+			utils.PrintError(prefsPath + ` does not exist or is not a valid path. Please manually set "prefs_path" in ` + getInstallConfigLabel() + ` to correct path of "prefs" file.`)
+			utils.Exit(utils.ExitSpotifyError)
+		}
+	} else if len(installName) > 0 {
+		utils.PrintError(`Please set "prefs_path" in ` + getInstallConfigLabel() + ` to path of "prefs" file.`)
+		utils.Exit(utils.ExitConfigError)
+	} else if prefsPath = utils.FindPrefFilePath(); len(prefsPath) != 0 {
+		section.Key("prefs_path").SetValue(prefsPath)
+		cfg.Write()
+	} else {
+		utils.PrintError(`Cannot detect Spotify "prefs" file location. Please manually set "prefs_path" in config-xpui.ini`)
+		utils.Exit(utils.ExitSpotifyError)
+	}
+}
+
 // InitSetting parses theme settings and gets color section.
+// "current_theme" can list multiple themes separated by "|". They are
+// layered in order: later themes' CSS is appended after earlier ones,
+// their assets overwrite earlier ones and their colors take precedence.
 func InitSetting() {
 	replaceColors = settingSection.Key("replace_colors").MustBool(false)
 	injectCSS = settingSection.Key("inject_css").MustBool(false)
 	overwriteAssets = settingSection.Key("overwrite_assets").MustBool(false)
+	injectSplash = settingSection.Key("inject_splash").MustBool(false)
+	utils.SetNormalizeLineEndings(settingSection.Key("normalize_line_endings").MustBool(true))
 
-	themeName := settingSection.Key("current_theme").String()
+	themeNames := getThemeNames()
 
-	if len(themeName) == 0 {
+	if len(themeNames) == 0 {
 		injectCSS = false
 		replaceColors = false
 		overwriteAssets = false
+		injectSplash = false
 		return
 	}
 
-	themeFolder = getThemeFolder(themeName)
+	themeFolders = []string{}
+	for _, name := range themeNames {
+		themeFolders = append(themeFolders, getThemeFolder(name))
+	}
+	themeFolder = themeFolders[0]
 
-	colorPath := filepath.Join(themeFolder, "color.ini")
-	cssPath := filepath.Join(themeFolder, "user.css")
-	assetsPath := filepath.Join(themeFolder, "assets")
+	injectCSS = injectCSS && anyThemeHasCSS()
+	overwriteAssets = overwriteAssets && anyThemeHas("assets")
+	injectSplash = injectSplash && anyThemeHas(splashFileName)
 
-	if replaceColors {
-		_, err := os.Stat(colorPath)
-		replaceColors = err == nil
+	if !replaceColors {
+		if len(colorSchemeOverride) > 0 {
+			utils.PrintWarning(`"--color-scheme" has no effect: config "replace_colors" is disabled or current theme has no colors.`)
+		}
+		return
 	}
 
-	if injectCSS {
-		_, err := os.Stat(cssPath)
-		injectCSS = err == nil
+	schemeName := getColorSchemeName()
+	schemeFound := false
+	colorScheme = nil
+	themeSchemeNames = map[string]string{}
+	for _, folder := range themeFolders {
+		colorPath := filepath.Join(folder, "color.ini")
+		if _, err := os.Stat(colorPath); err != nil {
+			continue
+		}
+
+		section, found := getColorSection(colorPath, schemeName)
+		if section == nil {
+			continue
+		}
+		schemeFound = schemeFound || found
+		themeSchemeNames[folder] = section.Name()
+
+		if colorScheme == nil {
+			colorScheme = map[string]string{}
+		}
+
+		for key, value := range section.KeysHash() {
+			colorScheme[key] = value
+		}
 	}
 
-	if overwriteAssets {
-		_, err := os.Stat(assetsPath)
-		overwriteAssets = err == nil
+	if len(colorSchemeOverride) > 0 && !schemeFound {
+		available := getColorSchemeNames()
+		if len(available) == 0 {
+			utils.PrintError(`"--color-scheme": current theme has no color schemes.`)
+		} else {
+			utils.PrintError(`"--color-scheme": color scheme "` + colorSchemeOverride + `" is not found in theme. Available: ` + strings.Join(available, ", ") + ".")
+		}
+		utils.Exit(utils.ExitConfigError)
+	} else if len(schemeName) > 0 && colorScheme != nil && !schemeFound {
+		utils.PrintWarning(`Color scheme "` + schemeName + `" is not found in theme. First color scheme is used instead.`)
 	}
 
-	if !replaceColors {
-		return
+	// Resolved after layering, so a layer can refer to colors of others
+	resolveColorReferences(colorScheme)
+
+	if flags.AccentFollowSystem || settingSection.Key("accent_follow_system").MustBool(false) {
+		if colorScheme == nil {
+			colorScheme = map[string]string{}
+		}
+		for _, key := range accentColorKeys {
+			// Scheme color stays as fallback when accent color is unreadable
+			fallback, ok := colorScheme[key]
+			if !ok {
+				fallback = utils.BaseColorList[key]
+			}
+			colorScheme[key] = "${accent:" + utils.ParseColor(fallback).Hex() + "}"
+		}
+	}
+
+	replaceColors = colorScheme != nil
+}
+
+// usesSystemAccent reports whether current color scheme has any color
+// resolved from OS accent color.
+func usesSystemAccent() bool {
+	for _, value := range colorScheme {
+		if strings.HasPrefix(value, "${accent") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// accentColorKeys are color scheme keys that follow OS accent color when
+// "accent_follow_system" is on
+var accentColorKeys = []string{"button", "button-active"}
+
+// anyThemeHas reports whether file or folder `name` exists in at least one
+// of current theme layers.
+func anyThemeHas(name string) bool {
+	for _, folder := range themeFolders {
+		if _, err := os.Stat(filepath.Join(folder, name)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anyThemeHasCSS reports whether any of current theme layers has user.css
+// or user.scss.
+func anyThemeHasCSS() bool {
+	return anyThemeHas("user.css") || anyThemeHas("user.scss")
+}
+
+// getColorSchemeNames lists color schemes defined in color.ini of current
+// theme layers
+func getColorSchemeNames() []string {
+	names := []string{}
+	for _, folder := range themeFolders {
+		colorFile, err := ini.InsensitiveLoad(filepath.Join(folder, "color.ini"))
+		if err != nil {
+			continue
+		}
+		for _, section := range colorFile.Sections() {
+			name := section.Name()
+			if !strings.EqualFold(name, ini.DefaultSection) && !containsString(names, name) {
+				names = append(names, name)
+			}
+		}
 	}
+	return names
+}
 
-	var err error
-	colorCfg, err = ini.InsensitiveLoad(colorPath)
+// getColorSection loads color.ini at `colorPath` and returns section
+// `schemeName`, or the first section when `schemeName` is blank or is not
+// defined in this file. The boolean reports whether `schemeName` is found.
+// Returns nil when file cannot be used.
+func getColorSection(colorPath, schemeName string) (*ini.Section, bool) {
+	colorFile, err := ini.InsensitiveLoad(colorPath)
 	if err != nil {
 		utils.PrintError("Cannot open file " + colorPath)
-		replaceColors = false
-		return
+		return nil, false
 	}
 
-	sections := colorCfg.Sections()
+	sections := colorFile.Sections()
 
 	if len(sections) < 2 {
 		utils.PrintError("No section found in " + colorPath)
-		replaceColors = false
-		return
+		return nil, false
 	}
 
-	schemeName := settingSection.Key("color_scheme").String()
 	if len(schemeName) == 0 {
-		colorSection = sections[1]
-		return
+		return sections[1], false
 	}
 
-	schemeSection, err := colorCfg.GetSection(schemeName)
+	schemeSection, err := colorFile.GetSection(schemeName)
 	if err != nil {
-		println("Err")
-		colorSection = sections[1]
-		return
+		return sections[1], false
 	}
 
-	colorSection = schemeSection
+	return schemeSection, true
 }
 
 // GetConfigPath returns location of config file
@@ -248,21 +570,31 @@ func getExtractFolder() (string, string) {
 }
 
 func getThemeFolder(themeName string) string {
+	folder, err := findThemeFolder(themeName)
+	if err != nil {
+		utils.PrintError(err.Error())
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	return folder
+}
+
+// findThemeFolder looks for theme `themeName` in user Themes folder, then
+// in bundled Themes folders, see utils.GetDataDirs.
+func findThemeFolder(themeName string) (string, error) {
 	folder := filepath.Join(userThemesFolder, themeName)
 	_, err := os.Stat(folder)
 	if err == nil {
-		return folder
+		return folder, nil
 	}
 
-	folder = filepath.Join(utils.GetExecutableDir(), "Themes", themeName)
-	_, err = os.Stat(folder)
-	if err == nil {
-		return folder
+	for _, folder = range utils.GetBundledPaths(filepath.Join("Themes", themeName)) {
+		if _, err = os.Stat(folder); err == nil {
+			return folder, nil
+		}
 	}
 
-	utils.PrintError(`Theme "` + themeName + `" not found`)
-	os.Exit(1)
-	return ""
+	return "", errors.New(`Theme "` + themeName + `" not found`)
 }
 
 // ReadAnswer prints out a yes/no form with string from `info`
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// logTailSize is how much of latest log output bug report bundle includes
+const logTailSize = 64 * 1024
+
+// GetLogPath returns location of log file recording output of commands
+// that change Spotify
+func GetLogPath() string {
+	return filepath.Join(spicetifyFolder, "spicetify.log")
+}
+
+type bundleFile struct {
+	name    string
+	content []byte
+}
+
+// bugReportStatus is "status.json" of bug report bundle
+type bugReportStatus struct {
+	SpicetifyVersion string `json:"spicetify_version"`
+	OS               string `json:"os"`
+	Arch             string `json:"arch"`
+	CreatedAt        string `json:"created_at"`
+	// Embedded state is left out when Spotify cannot be found
+	*backupReport
+	Applied  bool `json:"applied"`
+	Reverted bool `json:"reverted"`
+	// Errors are problems met while collecting, e.g. Spotify not found
+	Errors []string `json:"errors"`
+}
+
+// CollectLogs bundles what is needed to triage a bug report into zip file
+// `output`: effective config with resolved paths and versions, backup and
+// Spotify state, install manifest of last apply and latest log output.
+// Sensitive values are redacted, even with "--unsafe".
+func CollectLogs(output, spicetifyVersion string) {
+	status := bugReportStatus{
+		SpicetifyVersion: spicetifyVersion,
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		CreatedAt:        time.Now().UTC().Format(time.RFC3339),
+		Errors:           []string{},
+	}
+
+	// Not finding Spotify is worth reporting rather than stopping, so paths
+	// are checked before InitPaths, which exits on them.
+	if err := checkSpotifyPaths(); err != nil {
+		status.Errors = append(status.Errors, err.Error())
+	} else {
+		InitPaths()
+		report := getBackupReport()
+		status.backupReport = &report
+		status.Applied = readSourceManifest() != nil
+		status.Reverted = isReverted()
+	}
+
+	dump, _ := getConfigDump(spicetifyVersion, true)
+	var config bytes.Buffer
+	if _, err := dump.WriteTo(&config); err != nil {
+		utils.Fatal(err)
+	}
+
+	statusJSON, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	files := []bundleFile{
+		{"config.ini", config.Bytes()},
+		{"status.json", append(statusJSON, '\n')},
+	}
+	if manifest, err := os.ReadFile(getInstallManifestPath()); err == nil {
+		files = append(files, bundleFile{"apply-manifest.json", manifest})
+	}
+	if tail := utils.ReadLogTail(GetLogPath(), logTailSize); len(tail) > 0 {
+		files = append(files, bundleFile{"spicetify.log", []byte(redactLog(tail))})
+	}
+
+	var content bytes.Buffer
+	archive := zip.NewWriter(&content)
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			utils.Fatal(err)
+		}
+		if _, err = writer.Write(file.content); err != nil {
+			utils.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		utils.Fatal(err)
+	}
+
+	if err := os.WriteFile(output, content.Bytes(), 0644); err != nil {
+		utils.Fatal(err)
+	}
+
+	for _, file := range files {
+		utils.PrintInfo("Collected " + file.name)
+	}
+	utils.PrintSuccess("Bug report bundle is written to " + output + ". Check it before attaching it to an issue.")
+}
+
+// checkSpotifyPaths reports whether Spotify and its prefs file, configured
+// or detected, exist, which InitPaths needs.
+func checkSpotifyPaths() error {
+	if len(flags.From) > 0 {
+		return nil
+	}
+
+	spotify, prefs := getInstallConfigPaths()
+	if _, err := os.Stat(spotify); len(spotify) == 0 || err != nil {
+		return pathNotFoundError("Spotify", spotify)
+	}
+
+	if _, err := os.Stat(prefs); len(prefs) == 0 || err != nil {
+		return pathNotFoundError(`Spotify "prefs" file`, prefs)
+	}
+
+	return nil
+}
+
+func pathNotFoundError(what, path string) error {
+	if len(path) == 0 {
+		return errors.New("Cannot detect " + what + " location")
+	}
+	return errors.New(what + " is not found at " + path)
+}
+
+// redactLog hides credentials of URLs in log output, e.g. of proxies in
+// "--app-args"
+func redactLog(text string) string {
+	return urlCredentialRegex.ReplaceAllString(text, "${1}"+redacted+"@")
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"log"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/go-ini/ini"
@@ -24,7 +27,10 @@ func EditColor(args []string) {
 		value := args[1]
 		args = args[2:]
 
-		color := utils.ParseColor(value).Hex()
+		color := value
+		if !strings.HasPrefix(value, colorReferencePrefix) {
+			color = utils.ParseColor(value).Hex()
+		}
 
 		if key, err := colorSection.GetKey(field); err == nil {
 			key.SetValue(color)
@@ -52,10 +58,13 @@ func DisplayColors() {
 		return
 	}
 
+	scheme := colorSection.KeysHash()
+	resolveColorReferences(scheme)
+
 	for _, k := range utils.BaseColorOrder {
 		colorString := ""
 		if colorFileOk {
-			colorString = colorSection.Key(k).String()
+			colorString = scheme[k]
 		}
 
 		if len(colorString) == 0 {
@@ -74,7 +83,11 @@ func DisplayColors() {
 			continue
 		}
 
-		out := formatName(key) + formatColor(v.String())
+		if _, ok := scheme[key]; !ok {
+			continue
+		}
+
+		out := formatName(key) + formatColor(scheme[key])
 		log.Println(out)
 	}
 
@@ -84,14 +97,21 @@ func DisplayColors() {
 func initCmdColor() bool {
 	var err error
 
-	themeName := settingSection.Key("current_theme").String()
+	themeNames := getThemeNames()
 
-	if len(themeName) == 0 {
+	if len(themeNames) == 0 {
 		utils.PrintError(`Config "current_theme" is blank.`)
 		return false
 	}
 
-	themeFolder = getThemeFolder(themeName)
+	// With layered themes, colors of the last layer that has color.ini
+	// take precedence, so that is the one to display and edit.
+	for i := len(themeNames) - 1; i >= 0; i-- {
+		themeFolder = getThemeFolder(themeNames[i])
+		if _, err := os.Stat(filepath.Join(themeFolder, "color.ini")); err == nil {
+			break
+		}
+	}
 
 	colorPath := filepath.Join(themeFolder, "color.ini")
 
@@ -123,6 +143,105 @@ func initCmdColor() bool {
 	return true
 }
 
+// colorReferencePrefix starts a color scheme value that is another key of
+// the scheme, e.g. "button-active = @button"
+const colorReferencePrefix = "@"
+
+// resolveColorReferences replaces references in `scheme` with values of keys
+// they point to, following chains. A reference to a key scheme lacks uses
+// its default color. Keys whose reference is unknown or circular are
+// reported and removed, so default color is used for them.
+func resolveColorReferences(scheme map[string]string) {
+	keys := []string{}
+	for key := range scheme {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resolved := map[string]string{}
+	failed := map[string]error{}
+
+	var resolve func(key string, chain []string) (string, error)
+	resolve = func(key string, chain []string) (string, error) {
+		if value, ok := resolved[key]; ok {
+			return value, nil
+		}
+		if err, ok := failed[key]; ok {
+			return "", err
+		}
+
+		value, ok := scheme[key]
+		if !ok {
+			if value, ok = utils.BaseColorList[key]; !ok {
+				return "", errors.New(`it refers to unknown color "` + key + `"`)
+			}
+		}
+
+		var err error
+		if strings.HasPrefix(value, colorReferencePrefix) {
+			target := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(value, colorReferencePrefix)))
+			chain = append(chain, key)
+			if containsString(chain, target) {
+				err = errors.New(`circular reference "` + strings.Join(append(chain, target), " -> ") + `"`)
+			} else {
+				value, err = resolve(target, chain)
+			}
+		}
+
+		if err != nil {
+			failed[key] = err
+			return "", err
+		}
+		resolved[key] = value
+		return value, nil
+	}
+
+	for _, key := range keys {
+		value, err := resolve(key, nil)
+		if err != nil {
+			utils.PrintWarning(`Color "` + key + `" is not applied, default color is used: ` + err.Error() + ".")
+			delete(scheme, key)
+			continue
+		}
+		scheme[key] = value
+	}
+}
+
+// normalizeColorScheme converts colors of `scheme` to "rrggbb" and returns
+// problems with values that are not colors. Those are removed, so default
+// colors are used for them. Values read when applied, "${...}", are kept.
+func normalizeColorScheme(scheme map[string]string) []string {
+	keys := []string{}
+	for key := range scheme {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	problems := []string{}
+	for _, key := range keys {
+		value := scheme[key]
+		if strings.HasPrefix(value, "${") {
+			continue
+		}
+
+		hex, err := utils.NormalizeColor(value)
+		if err != nil && len(strings.TrimSpace(value)) == 0 {
+			// INI comments start with "#", a common slip for hex colors
+			problems = append(problems, `Color "`+key+`" is blank. In color.ini, "#" starts a comment, so write hex colors without it.`)
+			delete(scheme, key)
+			continue
+		}
+		if err != nil {
+			problems = append(problems, `Color "`+key+`" has invalid value "`+value+`": `+err.Error()+".")
+			delete(scheme, key)
+			continue
+		}
+		scheme[key] = hex
+	}
+
+	return problems
+}
+
 func colorChangeSuccess(field, value string) {
 	utils.PrintSuccess(`Color changed: ` + field + ` = ` + value)
 	utils.PrintInfo(`Run "spicetify update" to apply new color`)
@@ -130,7 +249,7 @@ func colorChangeSuccess(field, value string) {
 
 func formatColor(value string) string {
 	color := utils.ParseColor(value)
-	return "\x1B[48;2;" + color.TerminalRGB() + "m     \033[0m | " + color.Hex() + " | " + color.RGB()
+	return utils.Colorize("48;2;"+color.TerminalRGB(), "     ") + " | " + color.Hex() + " | " + color.RGB()
 }
 
 func formatName(name string) string {
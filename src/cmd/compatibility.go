@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/cache"
+	"github.com/khanhas/spicetify-cli/src/compatibility"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// getCompatibilityIndexKey returns cache key of fetched compatibility index
+func getCompatibilityIndexKey() string {
+	return cache.Key("compatibility", compatibility.IndexURL)
+}
+
+// checkCompatibility warns about configured extensions and custom apps
+// that are known to be broken on current Spotify version.
+func checkCompatibility(extensionList, appList []string) {
+	version := utils.GetSpotifyVersion(prefsPath)
+	content, _ := cache.Get(getCacheFolder(), getCompatibilityIndexKey())
+	index := compatibility.Load(content)
+
+	for _, ext := range extensionList {
+		names := []string{filepath.Base(ext)}
+		if extPath, err := getExtensionPath(ext); err == nil {
+			names = append(names, getExtensionDeclaredName(extPath))
+		} else if filepath.IsAbs(ext) {
+			names = append(names, getExtensionDeclaredName(ext))
+		}
+
+		if entry := index.FindExtension(version, names...); entry != nil {
+			warnIncompatible(`Extension "`+ext+`"`, version, entry)
+		}
+	}
+
+	for _, app := range appList {
+		names := []string{app}
+		if appPath, err := getCustomAppPath(app); err == nil {
+			names = append(names, getAppDeclaredName(appPath))
+		}
+
+		if entry := index.FindCustomApp(version, names...); entry != nil {
+			warnIncompatible(`Custom app "`+app+`"`, version, entry)
+		}
+	}
+}
+
+func warnIncompatible(subject, version string, entry *compatibility.Entry) {
+	utils.PrintWarning(subject + ` is known to be broken on Spotify ` + version + `.`)
+	if len(entry.Reason) > 0 {
+		utils.PrintInfo(entry.Reason)
+	}
+}
+
+// getExtensionDeclaredName returns name from "// NAME:" header comment of
+// extension file, or blank string if there is none.
+func getExtensionDeclaredName(extPath string) string {
+	return getExtensionHeader(extPath, "NAME")
+}
+
+// getExtensionHeader returns value of header comment "// <field>: <value>"
+// in the first lines of extension file, or entry of folder extension, or
+// blank string if there is none. Field name is case insensitive.
+func getExtensionHeader(extPath, field string) string {
+	file, err := os.Open(getExtensionEntry(extPath))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	prefix := strings.ToLower(field) + ":"
+	scanner := bufio.NewScanner(file)
+	for lineCount := 0; scanner.Scan() && lineCount < 20; lineCount++ {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		if strings.HasPrefix(strings.ToLower(line), prefix) {
+			return strings.TrimSpace(line[len(prefix):])
+		}
+	}
+
+	return ""
+}
+
+// getAppDeclaredName returns "name" field of custom app manifest, or blank
+// string if there is none.
+func getAppDeclaredName(appPath string) string {
+	content, err := os.ReadFile(filepath.Join(appPath, "manifest.json"))
+	if err != nil {
+		return ""
+	}
+
+	var manifest struct {
+		Name string `json:"name"`
+	}
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return ""
+	}
+
+	return manifest.Name
+}
+
+// refreshCompatibilityIndex fetches latest known incompatibility list.
+func refreshCompatibilityIndex() {
+	utils.PrintBold("Updating compatibility index:")
+	content, err := compatibility.Fetch()
+	if err != nil {
+		utils.PrintError("Cannot update compatibility index: " + err.Error())
+		utils.PrintInfo("Previously fetched index is used instead.")
+		return
+	}
+	if err = cache.Put(getCacheFolder(), getCompatibilityIndexKey(), content); err != nil {
+		utils.PrintError("Cannot cache compatibility index: " + err.Error())
+		return
+	}
+	utils.PrintGreen("OK")
+}
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"log"
 	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-ini/ini"
@@ -19,9 +26,11 @@ func EditConfig(args []string) {
 		case "extensions", "custom_apps":
 			arrayType(featureSection, field, value)
 		case "spotify_launch_flags":
-			continue
-		case "prefs_path", "spotify_path", "current_theme", "color_scheme":
+			arrayType(settingSection, field, value)
+		case "prefs_path", "spotify_path", "current_theme", "color_scheme", "xpui_path":
 			stringType(settingSection, field, value)
+		case "css_history":
+			countType(settingSection, field, value)
 
 		default:
 			toggleType(field, value)
@@ -33,6 +42,110 @@ func EditConfig(args []string) {
 	cfg.Write()
 }
 
+// UndoConfig restores config file to the version before last change made by
+// spicetify, after showing what it would change.
+func UndoConfig() {
+	configPath := GetConfigPath()
+	snapshots := utils.GetConfigSnapshots(configPath)
+	if len(snapshots) == 0 {
+		utils.PrintError("There is no previous config version to restore.")
+		utils.Exit(utils.ExitFailure)
+	}
+	snapshot := snapshots[len(snapshots)-1]
+
+	previous, err := ini.Load(snapshot)
+	if err != nil {
+		utils.PrintError("Previous config version is unreadable: " + err.Error())
+		utils.Exit(utils.ExitFailure)
+	}
+
+	current, err := ini.Load(configPath)
+	if err != nil {
+		// Broken config is replaced as a whole
+		current = ini.Empty()
+	}
+
+	changes := diffConfig(current, previous)
+	if len(changes) == 0 {
+		utils.PrintInfo("Previous config version has the same values.")
+	} else {
+		utils.PrintBold("Restoring previous config version changes:")
+		for _, change := range changes {
+			log.Println("    " + change)
+		}
+	}
+
+	if !ReadAnswer("Restore previous config version? [y/N] ", false, true) {
+		utils.Exit(utils.ExitFailure)
+	}
+
+	content, err := os.ReadFile(snapshot)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	if err = os.WriteFile(configPath, content, 0666); err != nil {
+		utils.Fatal(err)
+	}
+
+	// Undone version leaves history, so undoing again goes further back
+	os.Remove(snapshot)
+
+	utils.PrintSuccess("Config is restored.")
+	utils.PrintInfo(`Run "spicetify apply" to apply restored config`)
+}
+
+// diffConfig lists keys whose value differs between `from` and `to`, as
+// `[Section] key: "from" -> "to"`.
+func diffConfig(from, to *ini.File) []string {
+	sections := []string{}
+	seen := map[string]bool{}
+	for _, file := range []*ini.File{from, to} {
+		for _, name := range file.SectionStrings() {
+			if !seen[name] {
+				seen[name] = true
+				sections = append(sections, name)
+			}
+		}
+	}
+
+	changes := []string{}
+	for _, sectionName := range sections {
+		fromKeys := from.Section(sectionName).KeysHash()
+		toKeys := to.Section(sectionName).KeysHash()
+
+		keys := []string{}
+		for key := range fromKeys {
+			keys = append(keys, key)
+		}
+		for key := range toKeys {
+			if _, ok := fromKeys[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fromValue, inFrom := fromKeys[key]
+			toValue, inTo := toKeys[key]
+			if inFrom && inTo && fromValue == toValue {
+				continue
+			}
+
+			fromValue, toValue = `"`+fromValue+`"`, `"`+toValue+`"`
+			if !inFrom {
+				fromValue = "(none)"
+			}
+			if !inTo {
+				toValue = "(none)"
+			}
+			changes = append(changes, "["+sectionName+"] "+key+": "+fromValue+" -> "+toValue)
+		}
+	}
+
+	return changes
+}
+
 // DisplayAllConfig displays all configs in all sections
 func DisplayAllConfig() {
 	maxLen := 30
@@ -54,7 +167,7 @@ func DisplayAllConfig() {
 	for _, key := range featureSection.Keys() {
 		name := key.Name()
 		if name == "extensions" || name == "custom_apps" || name == "spotify_launch_flags" {
-			list := key.Strings("|")
+			list := utils.ListValues(key)
 			listLen := len(list)
 			if listLen == 0 {
 				log.Println(name)
@@ -76,7 +189,7 @@ func DisplayConfig(field string) {
 
 	name := key.Name()
 	if name == "extensions" || name == "custom_apps" {
-		list := key.Strings("|")
+		list := utils.ListValues(key)
 		for _, ext := range list {
 			log.Println(ext)
 		}
@@ -95,7 +208,7 @@ func searchField(field string) *ini.Key {
 			key, err = featureSection.GetKey(field)
 			if err != nil {
 				unchangeWarning(field, `Not a valid field.`)
-				os.Exit(1)
+				utils.Exit(utils.ExitConfigError)
 			}
 		}
 	}
@@ -117,11 +230,19 @@ func arrayType(section *ini.Section, field, value string) {
 		utils.Fatal(err)
 	}
 
-	allExts := key.Strings("|")
+	allExts := utils.ListValues(key)
+
+	value = strings.TrimSpace(value)
+	isSubstract := strings.HasSuffix(value, "-")
+	if isSubstract {
+		value = strings.TrimSpace(value[0 : len(value)-1])
+	}
+	if len(value) == 0 {
+		unchangeWarning(field, "Entry is blank.")
+		return
+	}
 
-	isSubstract := value[len(value)-1] == '-'
 	if isSubstract {
-		value = value[0 : len(value)-1]
 		found := false
 		newList := []string{}
 		for _, v := range allExts {
@@ -165,6 +286,21 @@ func stringType(section *ini.Section, field, value string) {
 	changeSuccess(field, value)
 }
 
+func countType(section *ini.Section, field, value string) {
+	key, err := section.GetKey(field)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	if count, err := strconv.Atoi(value); err != nil || count < 0 {
+		unchangeWarning(field, `"`+value+`" is not valid value. Only 0 or a positive number.`)
+		return
+	}
+
+	key.SetValue(value)
+	changeSuccess(field, value)
+}
+
 func toggleType(field, value string) {
 	key := searchField(field)
 
@@ -176,3 +312,222 @@ func toggleType(field, value string) {
 	key.SetValue(value)
 	changeSuccess(field, value)
 }
+
+// OpenConfigEditor opens config file in user's editor. After editor exits,
+// config is parsed again and any problem is reported with an option to
+// reopen the editor.
+func OpenConfigEditor() {
+	configPath := GetConfigPath()
+
+	for {
+		editor := getEditorCommand(configPath)
+		editor.Stdin = os.Stdin
+		editor.Stdout = os.Stdout
+		editor.Stderr = os.Stderr
+
+		if err := editor.Run(); err != nil {
+			utils.PrintError("Cannot open editor: " + err.Error())
+			utils.PrintInfo(`Set "EDITOR" environment variable to your preferred editor.`)
+			utils.Exit(1)
+		}
+
+		errs := utils.ValidateConfig(configPath)
+		if len(errs) == 0 {
+			utils.PrintSuccess("Config is valid.")
+			return
+		}
+
+		utils.PrintError("Config has problems:")
+		for _, err := range errs {
+			utils.PrintError("    " + err.Error())
+		}
+
+		if !ReadAnswer("Reopen editor? [Y/n] ", true, false) {
+			utils.Exit(utils.ExitConfigError)
+		}
+	}
+}
+
+// getEditorCommand builds command to open `path` in editor from "VISUAL" or
+// "EDITOR" environment variable, or a platform default.
+func getEditorCommand(path string) *exec.Cmd {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(env)); len(args) > 0 {
+			return exec.Command(args[0], append(args[1:], path)...)
+		}
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("notepad", path)
+	case "darwin":
+		return exec.Command("open", "-W", "-t", path)
+	default:
+		return exec.Command("vi", path)
+	}
+}
+
+// sensitiveKeyRegex matches config key names whose values are redacted in
+// config dump
+var sensitiveKeyRegex = regexp.MustCompile(`(?i)token|secret|password|passwd|api_?key|auth|cookie`)
+
+// urlCredentialRegex matches user info part of URLs, e.g. in proxy flags
+var urlCredentialRegex = regexp.MustCompile(`(\w+://)[^/@\s]+@`)
+
+const redacted = "<redacted>"
+
+// DumpConfig prints effective configuration: every config section,
+// including defaults, resolved color scheme, paths and versions. Output is
+// INI, or JSON when "--json" is used. Sensitive values are redacted unless
+// "--unsafe" is used.
+func DumpConfig(spicetifyVersion string) {
+	dump, sources := getConfigDump(spicetifyVersion, !flags.Unsafe)
+
+	if flags.JSON {
+		content := map[string]map[string]string{}
+		for _, section := range dump.Sections() {
+			if section.Name() == ini.DefaultSection {
+				continue
+			}
+			content[section.Name()] = section.KeysHash()
+		}
+		if len(sources) > 0 {
+			content["Sources"] = sources
+		}
+
+		out, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			utils.Fatal(err)
+		}
+		log.Println(string(out))
+		return
+	}
+
+	var out bytes.Buffer
+	if _, err := dump.WriteTo(&out); err != nil {
+		utils.Fatal(err)
+	}
+	log.Print(out.String())
+}
+
+// getConfigDump returns effective configuration DumpConfig prints, with
+// sensitive values redacted when `redact` is true, and sources of values
+// not only from user config, keyed by "<section>.<key>".
+func getConfigDump(spicetifyVersion string, redact bool) (*ini.File, map[string]string) {
+	// Missing theme is worth reporting rather than stopping the dump
+	themesFound := true
+	for _, name := range utils.ListValues(settingSection.Key("current_theme")) {
+		if _, err := findThemeFolder(name); err != nil {
+			utils.PrintWarning(err.Error() + ". Color scheme is not resolved.")
+			themesFound = false
+		}
+	}
+	if themesFound {
+		InitSetting()
+	}
+
+	dump := ini.Empty()
+	addSection := func(name string, keys [][2]string) {
+		section, _ := dump.NewSection(name)
+		for _, kv := range keys {
+			value := kv[1]
+			if redact {
+				value = redactValue(kv[0], value)
+			}
+			section.NewKey(kv[0], value)
+		}
+	}
+
+	// Values not only from user config are marked with their source
+	sources := map[string]string{}
+	sections := []*ini.Section{settingSection, preprocSection, featureSection, patchSection, cfg.GetSection("Backup")}
+	for _, name := range GetInstallNames()[1:] {
+		sections = append(sections, cfg.GetSection(utils.InstallSectionPrefix+name))
+	}
+	for _, section := range sections {
+		keys := [][2]string{}
+		for _, key := range section.Keys() {
+			keys = append(keys, [2]string{key.Name(), key.Value()})
+			if source := cfg.GetSource(section.Name(), key.Name()); source != "user" {
+				sources[section.Name()+"."+key.Name()] = source
+			}
+		}
+		addSection(section.Name(), keys)
+		for id, source := range sources {
+			if strings.HasPrefix(id, section.Name()+".") {
+				dump.Section(section.Name()).Key(strings.TrimPrefix(id, section.Name()+".")).Comment = "from " + source + " config"
+			}
+		}
+	}
+
+	if themesFound && replaceColors {
+		merged := map[string]string{}
+		for name, value := range utils.BaseColorList {
+			merged[name] = value
+		}
+		for name, value := range colorScheme {
+			merged[name] = value
+		}
+
+		names := []string{}
+		for name := range merged {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		keys := [][2]string{}
+		for _, name := range names {
+			keys = append(keys, [2]string{name, utils.ParseColor(merged[name]).Hex()})
+		}
+		addSection("Color", keys)
+	}
+
+	spotify, prefs := getInstallConfigPaths()
+
+	addSection("Paths", [][2]string{
+		{"config", GetConfigPath()},
+		{"system_config", getSystemConfigPath()},
+		{"spicetify_folder", spicetifyFolder},
+		{"spotify_path", spotify},
+		{"prefs_path", prefs},
+		{"backup_folder", backupFolder},
+		{"theme_folders", strings.Join(themeFolders, "|")},
+	})
+
+	spotifyVersion := ""
+	if _, err := os.Stat(prefs); len(prefs) > 0 && err == nil {
+		spotifyVersion = utils.GetInstalledSpotifyVersion(prefs)
+	}
+
+	addSection("Versions", [][2]string{
+		{"spicetify", spicetifyVersion},
+		{"spotify", spotifyVersion},
+		{"spotify_override", utils.GetSpotifyVersionOverride()},
+		{"backup", backupSection.Key("version").String()},
+	})
+
+	return dump, sources
+}
+
+// getSystemConfigPath returns system config location, or blank if there is
+// none
+func getSystemConfigPath() string {
+	path := utils.GetSystemConfigPath()
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// redactValue hides value of config key `name` if it looks sensitive
+func redactValue(name, value string) string {
+	if len(value) == 0 {
+		return value
+	}
+
+	if sensitiveKeyRegex.MatchString(name) {
+		return redacted
+	}
+
+	return urlCredentialRegex.ReplaceAllString(value, "${1}"+redacted+"@")
+}
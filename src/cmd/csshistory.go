@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// cssSnapshotLayout is time format of user.css history file names, which
+// keeps them sorted chronologically
+const cssSnapshotLayout = "20060102-150405.000000000"
+
+// maxDiffCells caps work diffLines does on changed region, beyond which it
+// shows whole region as replaced
+const maxDiffCells = 4000000
+
+func getCSSHistoryFolder() string {
+	return filepath.Join(stateFolder, "CSSHistory")
+}
+
+// getCSSSnapshots returns paths of user.css history files, oldest first
+func getCSSSnapshots() []string {
+	entries, err := os.ReadDir(getCSSHistoryFolder())
+	if err != nil {
+		return nil
+	}
+
+	snapshots := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".css" {
+			snapshots = append(snapshots, filepath.Join(getCSSHistoryFolder(), entry.Name()))
+		}
+	}
+
+	sort.Strings(snapshots)
+	return snapshots
+}
+
+// snapshotCSS copies user.css in `xpuiPath` to history before it is
+// overwritten, when config "css_history" is above 0. Only that many latest
+// versions are kept. Identical consecutive versions are stored once.
+func snapshotCSS(xpuiPath string) {
+	limit := settingSection.Key("css_history").MustInt(0)
+	if limit <= 0 {
+		return
+	}
+
+	current, err := os.ReadFile(filepath.Join(xpuiPath, "user.css"))
+	if err != nil {
+		return
+	}
+
+	snapshots := getCSSSnapshots()
+	if len(snapshots) > 0 {
+		if last, err := os.ReadFile(snapshots[len(snapshots)-1]); err == nil && bytes.Equal(last, current) {
+			return
+		}
+	}
+
+	if err = os.MkdirAll(getCSSHistoryFolder(), 0700); err != nil {
+		utils.PrintWarning("Cannot keep user.css history: " + err.Error())
+		return
+	}
+
+	snapshot := filepath.Join(getCSSHistoryFolder(), time.Now().Format(cssSnapshotLayout)+".css")
+	if err = os.WriteFile(snapshot, current, 0600); err != nil {
+		utils.PrintWarning("Cannot keep user.css history: " + err.Error())
+		return
+	}
+
+	snapshots = append(snapshots, snapshot)
+	for len(snapshots) > limit {
+		os.Remove(snapshots[0])
+		snapshots = snapshots[1:]
+	}
+}
+
+// cssSnapshotTime formats time user.css history file at `path` was taken
+func cssSnapshotTime(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".css")
+	taken, err := time.ParseInLocation(cssSnapshotLayout, name, time.Local)
+	if err != nil {
+		return name
+	}
+	return taken.Format("2006-01-02 15:04:05")
+}
+
+// CSSHistory lists kept versions of user.css, newest first, numbered for
+// "css-history diff".
+func CSSHistory() {
+	snapshots := getCSSSnapshots()
+	if len(snapshots) == 0 {
+		if settingSection.Key("css_history").MustInt(0) <= 0 {
+			utils.PrintInfo(`user.css history is disabled. Run "spicetify config css_history <count>" to keep <count> previous versions.`)
+		} else {
+			utils.PrintInfo("No previous user.css version is kept yet.")
+		}
+		return
+	}
+
+	utils.PrintBold("user.css history")
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		size := "?"
+		if info, err := os.Stat(snapshots[i]); err == nil {
+			size = utils.FormatSize(info.Size())
+		}
+		log.Println(strconv.Itoa(len(snapshots)-i) + "  " + cssSnapshotTime(snapshots[i]) + "  " + size)
+	}
+}
+
+// CSSHistoryDiff prints line changes from user.css version `from` to `to`.
+// Versions are numbers listed by CSSHistory, 1 being the latest one kept,
+// or "current" for user.css currently in Spotify.
+func CSSHistoryDiff(from, to string) {
+	fromPath, fromName := resolveCSSVersion(from)
+	toPath, toName := resolveCSSVersion(to)
+
+	fromContent, err := os.ReadFile(fromPath)
+	if err != nil {
+		utils.Fatal(err)
+	}
+	toContent, err := os.ReadFile(toPath)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	changes := diffLines(
+		strings.Split(normalizeLineEndings(string(fromContent)), "\n"),
+		strings.Split(normalizeLineEndings(string(toContent)), "\n"))
+
+	if len(changes) == 0 {
+		utils.PrintInfo("user.css " + fromName + " and " + toName + " are identical.")
+		return
+	}
+
+	log.Println(utils.Red("--- " + fromName))
+	log.Println(utils.Green("+++ " + toName))
+	for _, change := range changes {
+		switch change[0] {
+		case '-':
+			log.Println(utils.Red(change))
+		case '+':
+			log.Println(utils.Green(change))
+		default:
+			log.Println(utils.Blue(change))
+		}
+	}
+}
+
+// resolveCSSVersion returns path and display name of user.css version
+// `version`, or exits if there is no such version.
+func resolveCSSVersion(version string) (string, string) {
+	if version == "current" {
+		return filepath.Join(getXpuiPath(), "user.css"), "current"
+	}
+
+	snapshots := getCSSSnapshots()
+	index, err := strconv.Atoi(version)
+	if err != nil || index < 1 || index > len(snapshots) {
+		utils.PrintError(`Invalid user.css version "` + version + `". Use "current" or a number listed by "spicetify css-history".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	path := snapshots[len(snapshots)-index]
+	return path, version + " (" + cssSnapshotTime(path) + ")"
+}
+
+// diffLines returns changed lines from `a` to `b`, prefixed with "-" or "+",
+// grouped in hunks headed by "@@ -<line in a> +<line in b> @@".
+func diffLines(a, b []string) []string {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// ops: ' ' keeps, '-' removes from a, '+' adds from b
+	ops := []byte{}
+	if len(a)*len(b) > maxDiffCells {
+		ops = append(bytes.Repeat([]byte{'-'}, len(a)), bytes.Repeat([]byte{'+'}, len(b))...)
+	} else {
+		// Longest common subsequence lengths of a[i:] and b[j:]
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				ops = append(ops, ' ')
+				i++
+				j++
+			case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+				ops = append(ops, '-')
+				i++
+			default:
+				ops = append(ops, '+')
+				j++
+			}
+		}
+	}
+
+	changes := []string{}
+	i, j := 0, 0
+	inHunk := false
+	for _, op := range ops {
+		if op == ' ' {
+			inHunk = false
+			i++
+			j++
+			continue
+		}
+
+		if !inHunk {
+			changes = append(changes, "@@ -"+strconv.Itoa(prefix+i+1)+" +"+strconv.Itoa(prefix+j+1)+" @@")
+			inHunk = true
+		}
+
+		if op == '-' {
+			changes = append(changes, "-"+a[i])
+			i++
+		} else {
+			changes = append(changes, "+"+b[j])
+			j++
+		}
+	}
+
+	return changes
+}
+
+// normalizeLineEndings converts CRLF and lone CR line endings to LF, so
+// history entries written before or without normalization diff by content
+func normalizeLineEndings(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	return strings.ReplaceAll(content, "\r", "\n")
+}
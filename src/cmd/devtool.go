@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"log"
-
 	"github.com/go-ini/ini"
 	"github.com/khanhas/spicetify-cli/src/utils"
 )
@@ -16,12 +14,12 @@ func SetDevTool(enable bool) {
 		prefsPath)
 
 	if err != nil {
-		log.Fatal(err)
+		utils.Fatal(err)
 	}
 
 	rootSection, err := pref.GetSection("")
 	if err != nil {
-		log.Fatal(err)
+		utils.Fatal(err)
 	}
 
 	devTool := rootSection.Key("app.enable-developer-mode")
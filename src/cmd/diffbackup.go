@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/backup"
+	backupstatus "github.com/khanhas/spicetify-cli/src/status/backup"
+	spotifystatus "github.com/khanhas/spicetify-cli/src/status/spotify"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// backupDiff holds files of one app that differ from backup
+type backupDiff struct {
+	added, removed, changed []string
+}
+
+// DiffBackup compares files in Spotify Apps folder against backup by
+// checksum and prints a summary of added, removed and changed files per
+// app. Every file is listed with "--verbose".
+func DiffBackup() {
+	backupVersion := getBackupVersion()
+	backStat := backupstatus.Get(prefsPath, backupFolder, backupVersion)
+	if backStat.IsEmpty() {
+		utils.PrintError(`You haven't backed up.`)
+		utils.Exit(utils.ExitNoBackup)
+	} else if backStat.IsOutdated() {
+		utils.PrintWarning("Spotify version and backup version are mismatched.")
+	}
+
+	// Excluded packages are never in backup, so they are not compared
+	excluded := utils.ListValues(backupSection.Key("excluded"))
+
+	backupSums, err := backup.Checksums(backupFolder, []string{backup.ManifestName, backup.VersionName, backup.NoteName, backup.LauncherFolder})
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	currentSums, err := backup.Checksums(appDestPath, excluded)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	diffs := map[string]*backupDiff{}
+	getDiff := func(file string) *backupDiff {
+		app := strings.SplitN(file, "/", 2)[0]
+		if diffs[app] == nil {
+			diffs[app] = &backupDiff{}
+		}
+		return diffs[app]
+	}
+
+	for file, sum := range currentSums {
+		backupSum, ok := backupSums[file]
+		if !ok {
+			diff := getDiff(file)
+			diff.added = append(diff.added, file)
+		} else if sum != backupSum {
+			diff := getDiff(file)
+			diff.changed = append(diff.changed, file)
+		}
+	}
+
+	for file := range backupSums {
+		if _, ok := currentSums[file]; !ok {
+			diff := getDiff(file)
+			diff.removed = append(diff.removed, file)
+		}
+	}
+
+	if len(diffs) == 0 {
+		utils.PrintSuccess("Spotify Apps folder matches backup.")
+		return
+	}
+
+	apps := []string{}
+	for app := range diffs {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+
+	added, removed, changed := 0, 0, 0
+	for _, app := range apps {
+		diff := diffs[app]
+		added += len(diff.added)
+		removed += len(diff.removed)
+		changed += len(diff.changed)
+
+		log.Println(utils.Bold(app) + ": " + formatDiffCounts(len(diff.added), len(diff.removed), len(diff.changed)))
+
+		if !utils.IsVerbose(utils.VerbosityVerbose) {
+			continue
+		}
+
+		for _, list := range []struct {
+			files []string
+			mark  string
+		}{
+			{diff.added, utils.Green("+")},
+			{diff.removed, utils.Red("-")},
+			{diff.changed, utils.Yellow("~")},
+		} {
+			sort.Strings(list.files)
+			for _, file := range list.files {
+				log.Println("    " + list.mark + " " + file)
+			}
+		}
+	}
+
+	utils.PrintInfo("Compared to backup: " + formatDiffCounts(added, removed, changed) + ".")
+	if spotifystatus.Get(appDestPath).IsApplied() {
+		utils.PrintInfo(`Files modified by "spicetify apply" are reported as changed too.`)
+	}
+	if !utils.IsVerbose(utils.VerbosityVerbose) {
+		utils.PrintInfo(`Run with "--verbose" to list every file.`)
+	}
+}
+
+func formatDiffCounts(added, removed, changed int) string {
+	return strconv.Itoa(added) + " added, " +
+		strconv.Itoa(removed) + " removed, " +
+		strconv.Itoa(changed) + " changed"
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// InstallExtension downloads extension file from `source`, checks it like
+// "extensions test" and, once user confirms, installs it to user
+// Extensions folder as `name`, or its file name if blank. `source` is
+// "gh:owner/repo/path/ext.js[@ref]" shorthand or URL of a ".js" or ".mjs"
+// file.
+func InstallExtension(source, name string) {
+	fileURL, defaultName, fetch, err := resolveExtensionSource(source)
+	if err != nil {
+		utils.PrintError("Cannot get extension: " + err.Error())
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	if len(name) == 0 {
+		name = defaultName
+	} else if len(filepath.Ext(name)) == 0 {
+		name += path.Ext(defaultName)
+	}
+	if ext := filepath.Ext(name); len(name) == len(ext) || strings.ContainsAny(name, `/\:`) || (ext != ".js" && ext != ".mjs") {
+		utils.PrintError(`Invalid extension name "` + name + `", expected a ".js" or ".mjs" file name.`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	dest := filepath.Join(userExtensionsFolder, name)
+	if _, err := os.Stat(dest); err == nil && !flags.Force {
+		utils.PrintError(`Extension "` + name + `" is already installed. Use "--force" to replace it, or give another name.`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	content, err := fetch(fileURL)
+	if err != nil {
+		utils.PrintError("Cannot get extension: " + err.Error())
+		utils.Exit(utils.ExitFailure)
+	}
+
+	temp, err := os.MkdirTemp("", "spicetify-extension-")
+	if err != nil {
+		utils.Fatal(err)
+	}
+	defer os.RemoveAll(temp)
+
+	tempPath := filepath.Join(temp, name)
+	if err = os.WriteFile(tempPath, content, 0644); err != nil {
+		utils.Fatal(err)
+	}
+
+	result := &lintResult{}
+	lintExtension(tempPath, result)
+	for _, warning := range result.warnings {
+		utils.PrintWarning(warning)
+	}
+	if len(result.errors) > 0 {
+		for _, err := range result.errors {
+			utils.PrintError(err)
+		}
+		utils.PrintError(`Extension "` + name + `" is not installed.`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	utils.PrintWarning("Extensions run inside Spotify with access to your account. Only install ones from authors you trust.")
+	// Quiet mode only installs extensions when forced
+	if !ReadAnswer(`Install extension "`+name+`"? [y/N] `, false, flags.Force) {
+		if quiet {
+			utils.PrintError(`Extension "` + name + `" is only installed in quiet mode with "--force".`)
+		} else {
+			utils.PrintInfo("Extension is not installed.")
+		}
+		utils.Exit(utils.ExitFailure)
+	}
+
+	if err = os.WriteFile(dest, content, 0644); err != nil {
+		utils.Fatal(err)
+	}
+	utils.PrintSuccess(`Extension "` + name + `" is installed to ` + dest)
+
+	if flags.Register {
+		arrayType(featureSection, "extensions", name)
+		cfg.Write()
+	} else {
+		utils.PrintInfo(`Run "spicetify config extensions ` + name + `" and "spicetify apply" to use it.`)
+	}
+}
+
+// resolveExtensionSource returns URL of extension file `source` points to,
+// its file name and how to download it
+func resolveExtensionSource(source string) (string, string, func(string) ([]byte, error), error) {
+	if isGitHubShorthand(source) {
+		file, err := parseGitHubShorthand(source)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if len(file.path) == 0 {
+			return "", "", nil, errors.New(`"` + source + `" points to a repository, expected path of extension file, e.g. "gh:owner/repo/path/ext.js"`)
+		}
+		fetch := func(fileURL string) ([]byte, error) {
+			return fetchGitHub(fileURL, file)
+		}
+		return file.rawURL(), file.baseName(), fetch, nil
+	}
+
+	parsed, err := url.Parse(source)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return "", "", nil, errors.New(`"` + source + `" is neither "gh:owner/repo/path/ext.js" nor a URL`)
+	}
+	fetch := func(fileURL string) ([]byte, error) {
+		utils.PrintInfo("Downloading " + fileURL)
+		return fetchCached(fileURL)
+	}
+	return source, path.Base(parsed.Path), fetch, nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// githubShorthandPrefix starts a GitHub shorthand source, e.g.
+// "gh:owner/repo/path/to/folder@ref"
+const githubShorthandPrefix = "gh:"
+
+var (
+	githubOwnerRegex    = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+	githubRepoNameRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+	githubRefRegex      = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+)
+
+// githubSource is a file or folder in a GitHub repository, at `ref`, or
+// default branch if blank
+type githubSource struct {
+	owner string
+	repo  string
+	path  string
+	ref   string
+}
+
+// isGitHubShorthand reports whether `source` is a GitHub shorthand
+func isGitHubShorthand(source string) bool {
+	return strings.HasPrefix(source, githubShorthandPrefix)
+}
+
+// parseGitHubShorthand expands "gh:owner/repo[/path][@ref]" into its
+// parts, rejecting anything that is not a valid GitHub name.
+func parseGitHubShorthand(source string) (githubSource, error) {
+	result := githubSource{}
+	invalid := func(reason string) (githubSource, error) {
+		return result, errors.New(`invalid GitHub shorthand "` + source + `": ` + reason + `, expected "gh:owner/repo[/path][@ref]"`)
+	}
+
+	spec := strings.TrimPrefix(source, githubShorthandPrefix)
+	if at := strings.LastIndex(spec, "@"); at >= 0 {
+		result.ref = spec[at+1:]
+		spec = spec[:at]
+		if !githubRefRegex.MatchString(result.ref) || strings.Contains(result.ref, "..") ||
+			strings.HasPrefix(result.ref, "/") || strings.HasSuffix(result.ref, "/") {
+			return invalid(`invalid ref "` + result.ref + `"`)
+		}
+	}
+
+	parts := strings.SplitN(strings.Trim(spec, "/"), "/", 3)
+	if len(parts) < 2 {
+		return invalid("missing repository name")
+	}
+	result.owner = parts[0]
+	result.repo = strings.TrimSuffix(parts[1], ".git")
+	if !githubOwnerRegex.MatchString(result.owner) || strings.HasSuffix(result.owner, "-") {
+		return invalid(`invalid owner "` + result.owner + `"`)
+	}
+	if !githubRepoNameRegex.MatchString(result.repo) || result.repo == "." || result.repo == ".." {
+		return invalid(`invalid repository "` + result.repo + `"`)
+	}
+
+	if len(parts) == 3 {
+		for _, segment := range strings.Split(parts[2], "/") {
+			if len(segment) == 0 || segment == "." || segment == ".." || strings.Contains(segment, `\`) {
+				return invalid(`invalid path "` + parts[2] + `"`)
+			}
+		}
+		result.path = parts[2]
+	}
+
+	return result, nil
+}
+
+// archiveURL returns URL of zip archive of whole repository at its ref
+func (source githubSource) archiveURL() string {
+	ref := source.ref
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+	return "https://github.com/" + source.owner + "/" + source.repo + "/archive/" + ref + ".zip"
+}
+
+// rawURL returns URL of content of file at its path and ref
+func (source githubSource) rawURL() string {
+	ref := source.ref
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+	return "https://raw.githubusercontent.com/" + source.owner + "/" + source.repo + "/" + ref + "/" + source.path
+}
+
+// baseName returns name of file or folder source points to, or repository
+// name for whole repository
+func (source githubSource) baseName() string {
+	if len(source.path) > 0 {
+		return path.Base(source.path)
+	}
+	return source.repo
+}
+
+// fetchGitHub downloads `url` of `source`, turning "not found", which
+// GitHub also responds for private repositories, into an error that says
+// what may be missing.
+func fetchGitHub(url string, source githubSource) ([]byte, error) {
+	utils.PrintInfo("Downloading " + url)
+	content, err := fetchCached(url)
+
+	var statusErr *utils.HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		return content, err
+	}
+
+	missing := `repository "` + source.owner + "/" + source.repo + `"`
+	if len(source.ref) > 0 {
+		missing += `, ref "` + source.ref + `"`
+	}
+	if len(source.path) > 0 && strings.HasPrefix(url, "https://raw.githubusercontent.com/") {
+		missing += `, file "` + source.path + `"`
+	}
+	return nil, errors.New("not found on GitHub: " + missing + ". Check spelling, or whether repository is private, which cannot be downloaded")
+}
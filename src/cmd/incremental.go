@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// sourceManifest maps an apply stage (or "stage/item") to checksum of the
+// sources it was produced from.
+type sourceManifest map[string]string
+
+func getSourceManifestPath() string {
+	return filepath.Join(stateFolder, "apply-sources.json")
+}
+
+// collectSources computes checksums of every source that contributes to
+// an apply. "config" covers everything that requires a full apply when
+// changed: config file itself (hence backup version, preprocesses, patches,
+// lists of extensions and apps) and spicetify's own helper script.
+func collectSources(extensionList, appList []string) sourceManifest {
+	sources := sourceManifest{"destination": appDestPath}
+	// Spotify update replaces xpui files that full apply modifies
+	sources["spotify"] = utils.GetInstalledSpotifyVersion(prefsPath)
+
+	sources["config"] = hashSources(
+		GetConfigPath(),
+		filepath.Join(utils.GetJsHelperDir(), "spicetifyWrapper.js"))
+
+	cssSources := []string{}
+	for _, folder := range themeFolders {
+		cssSources = append(cssSources,
+			filepath.Join(folder, "user.css"),
+			filepath.Join(folder, "color.ini"))
+		cssSources = append(cssSources, getSassFiles(folder)...)
+	}
+	sources["css"] = hashSources(cssSources...)
+	if usesSystemAccent() {
+		// OS accent color can change without any source file changing
+		accent, _ := utils.GetSystemAccentColor()
+		sources["css"] += ":" + accent
+	}
+	sources["assets"] = hashAssetSources()
+	if len(colorSchemeOverride) > 0 {
+		// Scheme picks colors and "assets/_scheme_<name>" overlay
+		sources["css"] += ":scheme=" + colorSchemeOverride
+		sources["assets"] += ":scheme=" + colorSchemeOverride
+	}
+	sources["splash"] = hashSplashSources()
+	sources["theme-js"] = hashThemeJSSources()
+	// Theme script is linked in index.html, so adding or removing it needs
+	// full apply
+	sources["theme-js-linked"] = strconv.FormatBool(anyThemeHas(themeJSFileName))
+
+	for _, ext := range extensionList {
+		extPath := ext
+		if !filepath.IsAbs(ext) {
+			extPath, _ = getExtensionPath(ext)
+		}
+		cssPath := ""
+		if len(extPath) > 0 {
+			cssPath = getExtensionCSSPath(extPath)
+		}
+		sources["extension/"+ext] = hashSources(extPath, cssPath)
+	}
+
+	// Extension stylesheets are linked in index.html, so adding or removing
+	// one needs full apply
+	sources["extension-css"] = strings.Join(getExtensionsWithCSS(extensionList), "|")
+
+	for _, app := range appList {
+		appPath, _ := getCustomAppPath(app)
+		sources["app/"+app] = hashSources(appPath)
+	}
+
+	// Lists can change without config changing when they are overridden,
+	// and extension scripts are linked in index.html
+	sources["lists"] = strings.Join(extensionList, "|") + "\n" + strings.Join(appList, "|")
+
+	// Chunk ids are registered in xpui.js, so changing them needs full apply
+	sources["chunks"] = strings.Join(getAppChunkIDs(appList), "|")
+
+	// So are app routes
+	sources["routes"] = getAppRoutesSource(appList)
+
+	return sources
+}
+
+// hashAssetSources returns checksum of "assets" folders of current themes
+func hashAssetSources() string {
+	assetSources := []string{}
+	for _, folder := range themeFolders {
+		assetSources = append(assetSources, filepath.Join(folder, "assets"))
+	}
+	return hashSources(assetSources...)
+}
+
+// hashSplashSources returns checksum of splash screens of current themes,
+// which are injected in index.html, so changing them needs full apply
+func hashSplashSources() string {
+	splashSources := []string{}
+	for _, folder := range themeFolders {
+		splashSources = append(splashSources, filepath.Join(folder, splashFileName))
+	}
+	return hashSources(splashSources...)
+}
+
+// hashSources returns a combined checksum of files and folders in `paths`.
+// Folders are walked recursively. Missing paths still contribute to the
+// checksum so that adding or removing a file is detected.
+func hashSources(paths ...string) string {
+	hash := sha256.New()
+
+	for _, root := range paths {
+		if len(root) == 0 {
+			io.WriteString(hash, "\x00missing\n")
+			continue
+		}
+
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			checksum, err := utils.FileChecksum(path)
+			if err != nil {
+				return err
+			}
+
+			io.WriteString(hash, path+"\x00"+checksum+"\n")
+			return nil
+		})
+
+		if err != nil {
+			io.WriteString(hash, root+"\x00missing\n")
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func readSourceManifest() sourceManifest {
+	content, err := os.ReadFile(getSourceManifestPath())
+	if err != nil {
+		return nil
+	}
+
+	var manifest sourceManifest
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return nil
+	}
+
+	return manifest
+}
+
+func writeSourceManifest(manifest sourceManifest) {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		utils.PrintWarning("Cannot record applied sources: " + err.Error())
+		return
+	}
+
+	if err = os.WriteFile(getSourceManifestPath(), content, 0700); err != nil {
+		utils.PrintWarning("Cannot record applied sources: " + err.Error())
+	}
+}
+
+// hashAppliedState returns checksum of xpui files that apply modifies.
+// Spotify replacing them, e.g. when it updates itself, changes the checksum.
+func hashAppliedState() string {
+	xpuiPath := getXpuiPath()
+	return hashSources(
+		filepath.Join(xpuiPath, "index.html"),
+		filepath.Join(xpuiPath, "xpui.js"))
+}
+
+func clearSourceManifest() {
+	os.Remove(getSourceManifestPath())
+}
+
+// canApplyIncrementally reports whether only CSS, extensions or custom apps
+// (but not their chunk ids) changed since last apply. Anything else,
+// including Spotify not being in applied state, requires a full apply.
+func canApplyIncrementally(previous, current sourceManifest, isApplied bool) bool {
+	if flags.Full || previous == nil || !isApplied {
+		return false
+	}
+
+	return previous["destination"] == current["destination"] &&
+		previous["spotify"] == current["spotify"] &&
+		previous["config"] == current["config"] &&
+		previous["assets"] == current["assets"] &&
+		previous["lists"] == current["lists"] &&
+		previous["chunks"] == current["chunks"] &&
+		previous["routes"] == current["routes"] &&
+		previous["extension-css"] == current["extension-css"] &&
+		previous["splash"] == current["splash"] &&
+		previous["theme-js-linked"] == current["theme-js-linked"]
+}
+
+// applyIncrementally updates only the parts whose sources changed.
+func applyIncrementally(previous, current sourceManifest, extensionList, appList []string) {
+	changed := false
+
+	if previous["css"] != current["css"] {
+		utils.PrintStage(`Transferring user.css`)
+		updateCSS()
+		utils.PrintStageDone()
+		changed = true
+	}
+
+	if previous["theme-js"] != current["theme-js"] {
+		updateThemeJS()
+		changed = true
+	}
+
+	changedExtensions := []string{}
+	for _, ext := range extensionList {
+		if previous["extension/"+ext] != current["extension/"+ext] {
+			changedExtensions = append(changedExtensions, ext)
+		}
+	}
+
+	if len(changedExtensions) > 0 {
+		utils.PrintStage(`Transferring extensions`)
+		pushExtensions(changedExtensions...)
+		utils.PrintStageDone()
+		changed = true
+	}
+
+	changedApps := []string{}
+	for _, app := range appList {
+		if previous["app/"+app] != current["app/"+app] {
+			changedApps = append(changedApps, app)
+		}
+	}
+
+	if len(changedApps) > 0 {
+		utils.PrintStage(`Transferring custom apps`)
+		pushApps(changedApps...)
+		utils.PrintStageDone()
+		changed = true
+	}
+
+	if !changed {
+		utils.PrintInfo(`Nothing changed since last apply. Run "spicetify apply --full" to reapply everything.`)
+	}
+}
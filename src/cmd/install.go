@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-ini/ini"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// Main Spotify install is configured in [Setting] and [Backup]. Additional
+// installs, e.g. a beta next to stable, are "[Install <name>]" sections
+// with "spotify_path" and "prefs_path" of their own, which also record
+// their backup like [Backup]. Each additional install keeps backup,
+// extracted assets and apply records in its own state folder, so commands
+// run against one install at a time, selected with UseInstall.
+
+var (
+	// installName is name of install commands run against, blank for main
+	installName string
+	// stateFolder holds backup, extracted assets and apply records of
+	// current install
+	stateFolder = spicetifyFolder
+)
+
+// GetInstallNames returns names of configured Spotify installs, main
+// install (blank) first
+func GetInstallNames() []string {
+	names := []string{""}
+	for _, section := range cfg.GetSectionNames() {
+		if strings.HasPrefix(section, utils.InstallSectionPrefix) {
+			names = append(names, strings.TrimPrefix(section, utils.InstallSectionPrefix))
+		}
+	}
+	return names
+}
+
+// UseInstall makes commands run against Spotify install `name`, blank for
+// main install. Paths of previous install are forgotten, so InitPaths
+// needs to run again.
+func UseInstall(name string) error {
+	if len(name) > 0 && !containsString(GetInstallNames(), name) {
+		return errors.New(`Spotify install "` + name + `" is not found. Declare it in config as "[` + utils.InstallSectionPrefix + name + `]" with "spotify_path" and "prefs_path".`)
+	}
+
+	installName = name
+	spotifyPath, prefsPath, appPath, appDestPath, isAppX = "", "", "", "", false
+	xpuiFolder = ""
+
+	if len(name) == 0 {
+		stateFolder = spicetifyFolder
+		backupSection = cfg.GetSection("Backup")
+		if len(flags.Backup) == 0 {
+			backupFolder = getUserFolder("Backup")
+		}
+		rawFolder, themedFolder = getExtractFolder()
+		return nil
+	}
+
+	stateFolder = filepath.Join(spicetifyFolder, "Installs", name)
+	backupSection = getInstallSection()
+	if len(flags.Backup) == 0 {
+		backupFolder = filepath.Join(stateFolder, "Backup")
+	}
+	rawFolder = filepath.Join(stateFolder, "Extracted", "Raw")
+	themedFolder = filepath.Join(stateFolder, "Extracted", "Themed")
+	for _, folder := range []string{backupFolder, rawFolder, themedFolder} {
+		if err := os.MkdirAll(folder, 0700); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetInstallLabel returns name of current install for messages
+func GetInstallLabel() string {
+	if len(installName) == 0 {
+		return "main"
+	}
+	return installName
+}
+
+// getInstallSection returns config section with location of current
+// install: [Setting] for main install, otherwise its install section
+func getInstallSection() *ini.Section {
+	if len(installName) == 0 {
+		return settingSection
+	}
+	return cfg.GetSection(utils.InstallSectionPrefix + installName)
+}
+
+// getInstallConfigLabel names where location of current install is
+// configured, for messages
+func getInstallConfigLabel() string {
+	if len(installName) == 0 {
+		return "config-xpui.ini"
+	}
+	return `"[` + utils.InstallSectionPrefix + installName + `]" of config-xpui.ini`
+}
+
+// getInstallConfigPaths returns Spotify folder and "prefs" file of current
+// install as configured. Main install falls back to detected ones, which
+// would be main Spotify for additional installs.
+func getInstallConfigPaths() (string, string) {
+	section := getInstallSection()
+	spotify := section.Key("spotify_path").String()
+	prefs := section.Key("prefs_path").String()
+	if len(installName) == 0 {
+		if len(spotify) == 0 {
+			spotify = utils.FindAppPath()
+		}
+		if len(prefs) == 0 {
+			prefs = utils.FindPrefFilePath()
+		}
+	}
+	return spotify, prefs
+}
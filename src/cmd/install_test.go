@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// useConfig loads config `content` into a temporary spicetify folder for
+// the test, starting on main install
+func useConfig(t *testing.T, content string) {
+	t.Helper()
+	savedFolder, savedState, savedCfg := spicetifyFolder, stateFolder, cfg
+	savedSetting, savedBackup := settingSection, backupSection
+	savedBackupFolder, savedRaw, savedThemed := backupFolder, rawFolder, themedFolder
+	t.Cleanup(func() {
+		spicetifyFolder, stateFolder, cfg = savedFolder, savedState, savedCfg
+		settingSection, backupSection = savedSetting, savedBackup
+		backupFolder, rawFolder, themedFolder = savedBackupFolder, savedRaw, savedThemed
+		installName = ""
+		spotifyPath, prefsPath, appPath, appDestPath, isAppX = "", "", "", "", false
+		xpuiFolder = ""
+	})
+
+	spicetifyFolder = t.TempDir()
+	path := filepath.Join(spicetifyFolder, "config-xpui.ini")
+	writeFiles(t, spicetifyFolder, map[string]string{"config-xpui.ini": content})
+	cfg = utils.ParseConfig(path)
+	settingSection = cfg.GetSection("Setting")
+	if err := UseInstall(""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUseInstallResolvesXpuiPathPerInstall(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"stable/prefs":                "",
+		"stable/Apps/xpui/index.html": "",
+		"stable/Apps/xpui/xpui.js":    "",
+		"beta/prefs":                  "",
+		"beta/Apps/custom/ui/xpui.js": "",
+		"beta/Apps/xpui/index.html":   "",
+		"beta/Apps/xpui/xpui.js":      "",
+	})
+	stable, beta := filepath.Join(root, "stable"), filepath.Join(root, "beta")
+	useConfig(t, "[Setting]\n"+
+		"spotify_path = "+stable+"\n"+
+		"prefs_path = "+filepath.Join(stable, "prefs")+"\n"+
+		"\n[Install beta]\n"+
+		"spotify_path = "+beta+"\n"+
+		"prefs_path = "+filepath.Join(beta, "prefs")+"\n"+
+		"xpui_path = custom/ui\n")
+
+	cases := []struct {
+		install string
+		want    string
+	}{
+		{"", filepath.Join(stable, "Apps", "xpui")},
+		{"beta", filepath.Join(beta, "Apps", "custom", "ui")},
+		{"", filepath.Join(stable, "Apps", "xpui")},
+	}
+	for _, c := range cases {
+		if err := UseInstall(c.install); err != nil {
+			t.Fatal(err)
+		}
+		InitPaths()
+		if got := getXpuiPath(); got != c.want {
+			t.Errorf("xpui path of install %q = %s, want %s", c.install, got, c.want)
+		}
+	}
+}
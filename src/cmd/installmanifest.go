@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/khanhas/spicetify-cli/src/apply"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// installManifestVersion is bumped when installManifest schema changes
+// incompatibly
+const installManifestVersion = 1
+
+// installManifest records xpui files that differ from stock Spotify after
+// an apply, for auditing, pruning and uninstalling.
+type installManifest struct {
+	Version        int    `json:"version"`
+	SpotifyVersion string `json:"spotify_version"`
+	AppliedAt      string `json:"applied_at"`
+	// XpuiPath is frontend folder files are relative to
+	XpuiPath string `json:"xpui_path"`
+	// Files is keyed by path relative to XpuiPath, with "/" separators
+	Files map[string]installedFile `json:"files"`
+}
+
+// installedFile is a file apply created or modified
+type installedFile struct {
+	// Kind is "extension", "custom-app", "wrapper", "theme", "splash",
+	// "theme-js", "asset", "patched" (a modified stock file) or "other".
+	Kind string `json:"kind"`
+	// Status is "added" or "modified", compared with stock file, or
+	// "unknown" when there is no stock copy to compare with.
+	Status   string `json:"status"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"sha256"`
+}
+
+func getInstallManifestPath() string {
+	return filepath.Join(stateFolder, "apply-manifest.json")
+}
+
+// readInstallManifest returns manifest of last successful apply, or nil if
+// there is none.
+func readInstallManifest() *installManifest {
+	content, err := os.ReadFile(getInstallManifestPath())
+	if err != nil {
+		return nil
+	}
+
+	manifest := &installManifest{}
+	if err = json.Unmarshal(content, manifest); err != nil || manifest.Version != installManifestVersion {
+		return nil
+	}
+
+	return manifest
+}
+
+// recordInstall writes manifest of files in xpui that differ from stock
+// files extracted in raw folder. Configured extensions and custom apps tell
+// which added files belong to them.
+func recordInstall() {
+	appList := getCustomAppList()
+	xpuiPath := getXpuiPath()
+	stockPath := getStockXpuiPath()
+
+	owners := map[string]string{}
+	for _, fileName := range getExtensionFileNames(getExtensionList()) {
+		owners[fileName] = "extension"
+		owners[getExtensionCSSName(fileName)] = "extension"
+	}
+	for _, app := range appList {
+		for _, ext := range []string{".js", ".json", ".css"} {
+			owners["spicetify-routes-"+app+ext] = "custom-app"
+		}
+	}
+	for _, chunkID := range getAppChunkIDs(appList) {
+		owners[chunkID+".js"] = "custom-app"
+	}
+	owners["spicetifyWrapper.js"] = "wrapper"
+	owners["user.css"] = "theme"
+	owners["colors.css"] = "theme"
+	owners[apply.SplashCSSName] = "splash"
+	owners[apply.ThemeJSName] = "theme-js"
+
+	manifest := installManifest{
+		Version:        installManifestVersion,
+		SpotifyVersion: utils.GetInstalledSpotifyVersion(prefsPath),
+		AppliedAt:      time.Now().UTC().Format(time.RFC3339),
+		XpuiPath:       xpuiPath,
+		Files:          map[string]installedFile{},
+	}
+
+	filepath.Walk(xpuiPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(xpuiPath, path)
+		rel = filepath.ToSlash(rel)
+
+		checksum, err := utils.FileChecksum(path)
+		if err != nil {
+			return nil
+		}
+
+		status := "unknown"
+		if len(stockPath) > 0 {
+			stockChecksum, err := utils.FileChecksum(filepath.Join(stockPath, filepath.FromSlash(rel)))
+			if err == nil && stockChecksum == checksum {
+				return nil
+			}
+			status = "added"
+			if err == nil {
+				status = "modified"
+			}
+		}
+
+		kind, owned := owners[rel]
+		switch {
+		case owned:
+		case strings.HasPrefix(rel, "assets/"):
+			kind = "asset"
+		case status == "modified":
+			kind = "patched"
+		case status == "unknown":
+			// Without stock copy, unowned files are most likely stock ones
+			return nil
+		default:
+			kind = "other"
+		}
+
+		manifest.Files[rel] = installedFile{Kind: kind, Status: status, Size: info.Size(), Checksum: checksum}
+		return nil
+	})
+
+	if err := writeInstallManifest(manifest); err != nil {
+		utils.PrintWarning("Cannot record installed files: " + err.Error())
+	}
+
+	summary := utils.ReportFiles{Total: len(manifest.Files), ByKind: map[string]int{}}
+	for _, file := range manifest.Files {
+		summary.ByKind[file.Kind]++
+		if file.Status == "added" {
+			summary.Added++
+		} else if file.Status == "modified" {
+			summary.Modified++
+		}
+	}
+	utils.ReportInstalledFiles(summary)
+}
+
+// getStockXpuiPath returns stock copy of frontend folder in raw folder, or
+// blank if there is none.
+func getStockXpuiPath() string {
+	rel, err := filepath.Rel(appDestPath, getXpuiPath())
+	if err != nil {
+		return ""
+	}
+
+	stockPath := filepath.Join(rawFolder, rel)
+	if _, err := os.Stat(stockPath); err != nil {
+		return ""
+	}
+	return stockPath
+}
+
+// writeInstallManifest writes `manifest` to a temporary file first, so it
+// is never left half written.
+func writeInstallManifest(manifest installManifest) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dest := getInstallManifestPath()
+	temp, err := ioutil.TempFile(filepath.Dir(dest), "apply-manifest.*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = temp.Write(content); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+
+	if err = temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+
+	return os.Rename(temp.Name(), dest)
+}
+
+// getExtensionFileNames returns names extensions in `extensionList` are
+// pushed to xpui as.
+func getExtensionFileNames(extensionList []string) []string {
+	names := []string{}
+	for _, ext := range extensionList {
+		if fileName, ok := extensionFileName(ext); ok {
+			names = append(names, fileName)
+		}
+	}
+	return names
+}
+
+// extensionFileName returns name extension `ext` of extension list is
+// pushed to xpui as, or false if it is not found.
+func extensionFileName(ext string) (string, bool) {
+	if filepath.IsAbs(ext) {
+		return getExtensionFileName(filepath.Base(ext), ext), true
+	}
+
+	extPath, err := getExtensionPath(ext)
+	if err != nil {
+		return "", false
+	}
+	return getExtensionFileName(ext, extPath), true
+}
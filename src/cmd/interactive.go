@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+	isatty "github.com/mattn/go-isatty"
+)
+
+// selectInteractive lets user pick extensions and custom apps to enable
+// from installed ones, then saves picked ones to config.
+func selectInteractive() {
+	if quiet || !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		utils.PrintError(`"--interactive" requires a terminal and cannot be used with "--quiet".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+
+	for _, feature := range []struct {
+		title string
+		key   string
+		found []string
+	}{
+		{"Extensions", "extensions", getInstalledExtensions()},
+		{"Custom apps", "custom_apps", getInstalledApps()},
+	} {
+		key := featureSection.Key(feature.key)
+		current := utils.ListValues(key)
+		picked := multiSelect(reader, feature.title, feature.found, current)
+		key.SetValue(strings.Join(picked, "|"))
+	}
+
+	cfg.Write()
+	utils.PrintSuccess("Selection is saved to config.")
+}
+
+// multiSelect prints `items` as a checklist with `checked` ones ticked and
+// reads toggles from `reader` until user confirms. Checked items missing
+// from `items` are listed too, so they can be unticked. Returns checked
+// items, keeping order of `checked` first since extensions load in it.
+func multiSelect(reader *bufio.Reader, title string, items, checked []string) []string {
+	isChecked := map[string]bool{}
+	for _, item := range checked {
+		isChecked[item] = true
+	}
+
+	installed := map[string]bool{}
+	for _, item := range items {
+		installed[item] = true
+	}
+
+	list := append([]string{}, items...)
+	for _, item := range checked {
+		if !installed[item] {
+			list = append(list, item)
+		}
+	}
+
+	if len(list) == 0 {
+		utils.PrintInfo(title + ": none installed.")
+		return checked
+	}
+
+	for {
+		utils.PrintBold(title + ":")
+		width := len(strconv.Itoa(len(list)))
+		for i, item := range list {
+			box := "[ ]"
+			if isChecked[item] {
+				box = "[" + utils.Green("x") + "]"
+			}
+			line := fmt.Sprintf("%*d %s %s", width, i+1, box, item)
+			if !installed[item] {
+				line += " " + utils.Red("(not found)")
+			}
+			fmt.Println(line)
+		}
+
+		fmt.Print(`Toggle numbers (e.g. "1 3" or "2-4"), "a" for all, "n" for none, Enter to confirm: `)
+		text, err := reader.ReadString('\n')
+		text = strings.TrimSpace(text)
+		if len(text) == 0 {
+			if err != nil {
+				// Input is closed, keep selection as-is
+				fmt.Println()
+			}
+			break
+		}
+
+		switch text {
+		case "a", "A":
+			for _, item := range list {
+				isChecked[item] = true
+			}
+		case "n", "N":
+			isChecked = map[string]bool{}
+		default:
+			indexes, err := parseSelection(text, len(list))
+			if err != nil {
+				utils.PrintWarning(err.Error())
+				continue
+			}
+			for _, index := range indexes {
+				item := list[index]
+				isChecked[item] = !isChecked[item]
+			}
+		}
+	}
+
+	picked := []string{}
+	for _, item := range checked {
+		if isChecked[item] {
+			picked = append(picked, item)
+			delete(isChecked, item)
+		}
+	}
+	for _, item := range list {
+		if isChecked[item] {
+			picked = append(picked, item)
+		}
+	}
+
+	return picked
+}
+
+// parseSelection parses 1-based numbers and ranges like "1 3,5-7" into
+// 0-based indexes of a list of `count` items.
+func parseSelection(text string, count int) ([]int, error) {
+	indexes := []int{}
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to := field, field
+		if i := strings.Index(field, "-"); i > 0 {
+			from, to = field[:i], field[i+1:]
+		}
+
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || start < 1 || end > count || start > end {
+			return nil, fmt.Errorf(`"%s" is not a number or range between 1 and %d`, field, count)
+		}
+
+		for n := start; n <= end; n++ {
+			indexes = append(indexes, n-1)
+		}
+	}
+
+	return indexes, nil
+}
+
+// getInstalledExtensions returns names of extensions in user and bundled
+// Extensions folders, sorted. A user extension hides bundled one of the same
+// name, same as when resolving extension path.
+func getInstalledExtensions() []string {
+	return listInstalled(userExtensionsFolder, "Extensions", func(path string, isDir bool) bool {
+		if isDir {
+			// Folder extension needs an entry file
+			_, err := os.Stat(getExtensionEntry(path))
+			return err == nil
+		}
+		ext := filepath.Ext(path)
+		return ext == ".js" || ext == ".mjs"
+	})
+}
+
+// getInstalledApps returns names of custom apps in user and bundled
+// CustomApps folders, sorted.
+func getInstalledApps() []string {
+	return listInstalled(userAppsFolder, "CustomApps", func(path string, isDir bool) bool {
+		return isDir
+	})
+}
+
+// listInstalled lists entries of `userFolder` and bundled `bundledName`
+// folders for which `accept` returns true.
+func listInstalled(userFolder, bundledName string, accept func(path string, isDir bool) bool) []string {
+	found := map[string]bool{}
+	names := []string{}
+	for _, dir := range append([]string{userFolder}, utils.GetBundledPaths(bundledName)...) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if found[name] || name == "node_modules" || strings.HasPrefix(name, ".") {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+			isDir := entry.IsDir()
+			if entry.Type()&os.ModeSymlink != 0 {
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				isDir = info.IsDir()
+			}
+
+			if accept(path, isDir) {
+				found[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/backup"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// maxLauncherSize leaves out anything bigger than a desktop entry or a
+// wrapper script, e.g. Spotify binary itself
+const maxLauncherSize = 256 * 1024
+
+// getLauncherFiles returns launcher files of Spotify on Linux, desktop
+// entries and wrapper scripts, for detected install type. Symlinks are
+// resolved and files that do not exist are left out.
+func getLauncherFiles() []string {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	home, _ := os.UserHomeDir()
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if len(dataHome) == 0 {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	desktop := []string{}
+	scripts := []string{}
+	switch getInstallType() {
+	case installFlatpak:
+		entry := filepath.Join("applications", "com.spotify.Client.desktop")
+		desktop = []string{
+			filepath.Join("/var/lib/flatpak/exports/share", entry),
+			filepath.Join(dataHome, "flatpak/exports/share", entry),
+			filepath.Join(dataHome, entry),
+		}
+		scripts = []string{
+			"/var/lib/flatpak/exports/bin/com.spotify.Client",
+			filepath.Join(dataHome, "flatpak/exports/bin/com.spotify.Client"),
+		}
+	case installSnap:
+		desktop = []string{
+			"/var/lib/snapd/desktop/applications/spotify_spotify.desktop",
+			filepath.Join(dataHome, "applications", "spotify_spotify.desktop"),
+		}
+	default:
+		desktop = []string{
+			filepath.Join(spotifyPath, "spotify.desktop"),
+			"/usr/share/applications/spotify.desktop",
+			"/usr/local/share/applications/spotify.desktop",
+			filepath.Join(dataHome, "applications", "spotify.desktop"),
+		}
+		scripts = []string{
+			"/usr/bin/spotify",
+			"/usr/local/bin/spotify",
+			filepath.Join(home, ".local", "bin", "spotify"),
+		}
+	}
+
+	files := []string{}
+	add := func(path string, isScript bool) {
+		realPath, err := filepath.EvalSymlinks(path)
+		if err != nil || containsString(files, realPath) {
+			return
+		}
+		info, err := os.Stat(realPath)
+		if err != nil || info.IsDir() || info.Size() > maxLauncherSize {
+			return
+		}
+		if isScript && !isScriptFile(realPath) {
+			return
+		}
+		files = append(files, realPath)
+	}
+
+	for _, path := range desktop {
+		add(path, false)
+	}
+	for _, path := range scripts {
+		add(path, true)
+	}
+
+	return files
+}
+
+// isScriptFile reports whether file at `path` starts with "#!", unlike a
+// binary executable
+func isScriptFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, 2)
+	n, _ := file.Read(head)
+	return bytes.Equal(head[:n], []byte("#!"))
+}
+
+// backupLauncher backs up launcher files of Spotify on Linux, when config
+// "backup_launcher" is on, so restore brings back how Spotify is launched.
+func backupLauncher() {
+	if !settingSection.Key("backup_launcher").MustBool(false) {
+		return
+	}
+	if runtime.GOOS != "linux" {
+		utils.PrintWarning(`Config "backup_launcher" only works on Linux.`)
+		return
+	}
+
+	utils.PrintBold("Backing up launcher files:")
+	done, err := backup.StartLauncher(backupFolder, getLauncherFiles())
+	if err != nil {
+		utils.PrintError("Cannot back up launcher files: " + err.Error())
+		utils.MarkPartialFailure()
+		return
+	}
+
+	if len(done) == 0 {
+		utils.PrintInfo("No launcher file is found.")
+		return
+	}
+	utils.PrintGreen("OK")
+	utils.PrintInfo("Launcher files: " + strings.Join(done, ", "))
+}
+
+// restoreLauncher writes back launcher files recorded in backup that
+// changed since backing up
+func restoreLauncher() {
+	restored, errs := backup.RestoreLauncher(backupFolder)
+	for _, err := range errs {
+		utils.PrintError("Cannot restore launcher file " + err.Error())
+	}
+	if len(errs) > 0 {
+		utils.PrintInfo("Restore these files manually, or run restore with permission to write them.")
+		utils.MarkPartialFailure()
+	}
+
+	if len(restored) > 0 {
+		utils.PrintInfo("Restored launcher files: " + strings.Join(restored, ", "))
+	}
+}
@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// spicetifyMapRegex is how pushExtensions reads "spicetify_map" comments
+const spicetifyMapRegex = `//\s*spicetify_map\{(?P<from>.+?)\}\{(?P<to>.+?)\}`
+
+var (
+	spicetifyMapLineRegex = regexp.MustCompile(`//\s*spicetify_map`)
+	spicetifyUseRegex     = regexp.MustCompile(`\bSpicetify\s*\??\.`)
+	// Matches common ways to check Spicetify APIs are ready, e.g.
+	// "if (!Spicetify.Platform)" or "typeof Spicetify === 'undefined'"
+	spicetifyGuardRegex = regexp.MustCompile(`!\s*\(?\s*(window\.)?Spicetify\b|typeof\s+\(?\s*(window\.)?Spicetify\b|\bSpicetify(\?\.|\.)[\w.?]*\s*[!=]==?\s*(undefined|null)\b`)
+)
+
+// lintResult collects problems found in an extension
+type lintResult struct {
+	errors, warnings []string
+}
+
+func (r *lintResult) error(line int, message string) {
+	r.errors = append(r.errors, lintPosition(line)+message)
+}
+
+func (r *lintResult) warning(line int, message string) {
+	r.warnings = append(r.warnings, lintPosition(line)+message)
+}
+
+func lintPosition(line int) string {
+	if line <= 0 {
+		return ""
+	}
+	return "line " + strconv.Itoa(line) + ": "
+}
+
+// TestExtension runs static checks on extension `name`, which is a path or
+// name of an installed extension, and reports problems without modifying
+// Spotify: file type, Javascript syntax, readiness guard, "spicetify_map"
+// comments and "spicetify_platform" header.
+func TestExtension(name string) {
+	extPath := name
+	if _, err := os.Stat(extPath); err != nil {
+		if extPath, err = getExtensionPath(name); err != nil {
+			utils.PrintError(`Extension "` + name + `" not found.`)
+			utils.Exit(utils.ExitConfigError)
+		}
+	}
+
+	result := &lintResult{}
+	lintExtension(extPath, result)
+
+	for _, warning := range result.warnings {
+		utils.PrintWarning(warning)
+	}
+	for _, err := range result.errors {
+		utils.PrintError(err)
+	}
+
+	if len(result.errors) > 0 {
+		utils.Exit(utils.ExitFailure)
+	}
+
+	if len(result.warnings) == 0 {
+		utils.PrintSuccess(`Extension "` + name + `" has no problems.`)
+	} else {
+		utils.PrintSuccess(`Extension "` + name + `" has no errors.`)
+	}
+}
+
+func lintExtension(extPath string, result *lintResult) {
+	if info, err := os.Stat(extPath); err == nil && info.IsDir() {
+		if _, err = readExtensionManifest(extPath); err != nil {
+			result.error(0, err.Error())
+			return
+		}
+	}
+
+	entryPath := getExtensionEntry(extPath)
+	if err := checkExtensionFile(entryPath); err != nil {
+		result.error(0, err.Error())
+		return
+	}
+
+	var content []byte
+	var err error
+	if entryPath == extPath {
+		content, err = os.ReadFile(extPath)
+	} else {
+		content, err = buildFolderExtension(extPath)
+	}
+	if err != nil {
+		result.error(0, err.Error())
+		return
+	}
+
+	isModule := filepath.Ext(entryPath) == ".mjs"
+	lintSyntax(string(content), isModule, result)
+	lintSpicetifyMap(string(content), isModule, result)
+
+	if spicetifyUseRegex.Match(content) && !spicetifyGuardRegex.Match(content) {
+		result.warning(0, "no check that Spicetify APIs are ready was found, e.g. "+
+			`"if (!Spicetify.Platform) { setTimeout(main, 300); return; }". `+
+			"Extension can run before they are loaded.")
+	}
+
+	if expr := getExtensionHeader(extPath, "spicetify_platform"); len(expr) > 0 {
+		if _, err := matchPlatform(expr, map[string]bool{}); err != nil {
+			result.error(0, `"spicetify_platform" header: `+err.Error())
+		}
+	}
+}
+
+// lintSyntax checks Javascript syntax with Node.js if it is installed,
+// otherwise only checks that brackets, strings and comments are closed.
+func lintSyntax(content string, isModule bool, result *lintResult) {
+	if node, err := exec.LookPath("node"); err == nil {
+		ext := ".js"
+		if isModule {
+			ext = ".mjs"
+		}
+
+		temp, err := os.CreateTemp("", "spicetify-lint-*"+ext)
+		if err == nil {
+			defer os.Remove(temp.Name())
+			temp.WriteString(content)
+			temp.Close()
+
+			output, err := exec.Command(node, "--check", temp.Name()).CombinedOutput()
+			if err != nil {
+				// Keep error location and message, drop Node.js internals
+				lines := []string{}
+				for _, line := range strings.Split(string(output), "\n") {
+					if strings.HasPrefix(line, "    at ") || strings.HasPrefix(line, "Node.js v") {
+						continue
+					}
+					lines = append(lines, strings.ReplaceAll(line, temp.Name(), "extension"))
+				}
+				result.error(0, "syntax error:\n"+strings.TrimSpace(strings.Join(lines, "\n")))
+			}
+			return
+		}
+	}
+
+	if line, message := scanJavascript(content); len(message) > 0 {
+		result.error(line, message)
+	}
+}
+
+// lintSpicetifyMap checks every "spicetify_map" comment is well-formed and
+// its next line contains the text to replace.
+func lintSpicetifyMap(content string, isModule bool, result *lintResult) {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if !spicetifyMapLineRegex.MatchString(line) {
+			continue
+		}
+
+		if !isModule {
+			result.warning(i+1, `"spicetify_map" is only applied in ".mjs" extensions.`)
+			continue
+		}
+
+		mapping := utils.FindSymbol("", line, []string{spicetifyMapRegex})
+		if len(mapping) == 0 {
+			result.error(i+1, `malformed "spicetify_map", expected "// spicetify_map{from}{to}".`)
+		} else if i+1 >= len(lines) {
+			result.error(i+1, `"spicetify_map" has no line after it to apply to.`)
+		} else if !strings.Contains(lines[i+1], mapping[0]) {
+			result.warning(i+2, `"`+mapping[0]+`" of "spicetify_map" above is not found, nothing is replaced.`)
+		}
+	}
+}
+
+// scanJavascript walks `content` and reports the first unclosed or
+// mismatched bracket, string, template literal or comment. It is no full
+// parser, but catches most broken files. Returns 0 and blank message if no
+// problem is found.
+func scanJavascript(content string) (int, string) {
+	closers := map[byte]byte{')': '(', ']': '[', '}': '{'}
+	stack := []jsOpener{}
+	line := 1
+	// Last significant character, to tell regex literal from division
+	prev := byte(0)
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		if c == '\n' {
+			line++
+		}
+
+		switch {
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			line++
+			continue
+
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			start := line
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				return start, "comment is not closed."
+			}
+			line += strings.Count(content[i:i+2+end], "\n")
+			i += end + 3
+			continue
+
+		case c == '/' && (prev == 0 || strings.IndexByte("(,=:[!&|?{};+-*%<>~^", prev) >= 0):
+			start := line
+			inClass := false
+			for i++; i < len(content); i++ {
+				if content[i] == '\\' {
+					i++
+				} else if content[i] == '[' {
+					inClass = true
+				} else if content[i] == ']' {
+					inClass = false
+				} else if content[i] == '/' && !inClass {
+					break
+				} else if content[i] == '\n' {
+					return start, "regular expression is not closed."
+				}
+			}
+
+		case c == '"' || c == '\'':
+			start := line
+			for i++; i < len(content) && content[i] != c; i++ {
+				if content[i] == '\\' {
+					i++
+				} else if content[i] == '\n' {
+					return start, "string is not closed."
+				}
+			}
+			if i >= len(content) {
+				return start, "string is not closed."
+			}
+
+		case c == '`':
+			stack = append(stack, jsOpener{'`', line})
+			i = scanTemplate(content, i+1, &line, &stack)
+			if i < 0 {
+				return stack[len(stack)-1].line, "template literal is not closed."
+			}
+
+		case c == '(' || c == '[' || c == '{':
+			stack = append(stack, jsOpener{c, line})
+
+		case c == ')' || c == ']' || c == '}':
+			if len(stack) == 0 || stack[len(stack)-1].char != closers[c] {
+				return line, `unexpected "` + string(c) + `".`
+			}
+			stack = stack[:len(stack)-1]
+
+			// Closing a "${" of template literal resumes the literal
+			if c == '}' && len(stack) > 0 && stack[len(stack)-1].char == '`' {
+				i = scanTemplate(content, i+1, &line, &stack)
+				if i < 0 {
+					return stack[len(stack)-1].line, "template literal is not closed."
+				}
+			}
+		}
+
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			prev = c
+			if endsWithKeyword(content[:i+1]) {
+				prev = '('
+			}
+		}
+	}
+
+	if len(stack) > 0 {
+		last := stack[len(stack)-1]
+		if last.char == '`' {
+			return last.line, "template literal is not closed."
+		}
+		return last.line, `"` + string(last.char) + `" is not closed.`
+	}
+
+	return 0, ""
+}
+
+// jsOpener is an opening bracket or backtick and line it is on
+type jsOpener struct {
+	char byte
+	line int
+}
+
+// endsWithKeyword reports whether `content` ends with a keyword that a
+// regex literal can follow
+func endsWithKeyword(content string) bool {
+	for _, keyword := range []string{"return", "typeof", "case", "in", "of", "delete", "void", "throw"} {
+		if !strings.HasSuffix(content, keyword) {
+			continue
+		}
+		before := len(content) - len(keyword) - 1
+		if before < 0 || !isIdentifierChar(content[before]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// scanTemplate skips template literal text from `i` until closing backtick,
+// which pops the literal from `stack`, or "${", which pushes "{". Returns
+// index of that last character, or -1 if literal is not closed.
+func scanTemplate(content string, i int, line *int, stack *[]jsOpener) int {
+	for ; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '\n':
+			*line++
+		case '`':
+			*stack = (*stack)[:len(*stack)-1]
+			return i
+		case '$':
+			if i+1 < len(content) && content[i+1] == '{' {
+				*stack = append(*stack, jsOpener{'{', *line})
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
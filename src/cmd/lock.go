@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// getLockPath returns process lock file. It is not "spicetify.lock", which
+// is lockfile of extensions and custom apps.
+func getLockPath() string {
+	return filepath.Join(spicetifyFolder, "spicetify.pid")
+}
+
+// AcquireLock makes sure no other spicetify process modifies Spotify or
+// backup at the same time. When another one does, it waits for it to finish
+// if `wait` is true, otherwise exits.
+func AcquireLock(wait bool) {
+	waiting := false
+	for {
+		owner, err := utils.TryLock(getLockPath())
+		if err == nil {
+			return
+		}
+
+		if err != utils.ErrLocked {
+			utils.PrintWarning("Cannot create lock file, continuing without it: " + err.Error())
+			return
+		}
+
+		if !wait {
+			utils.PrintError("Another spicetify operation is in progress (process " + strconv.Itoa(owner) + ").")
+			utils.PrintInfo(`Use "--wait" to wait for it to finish.`)
+			utils.Exit(utils.ExitLocked)
+		}
+
+		if !waiting {
+			utils.PrintInfo("Waiting for another spicetify operation (process " + strconv.Itoa(owner) + ") to finish...")
+			waiting = true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// ReleaseLock releases lock taken by AcquireLock
+func ReleaseLock() {
+	utils.Unlock(getLockPath())
+}
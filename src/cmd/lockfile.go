@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// lockfileVersion is bumped when lockfile schema changes incompatibly
+const lockfileVersion = 1
+
+// lockfile pins extensions and custom apps of config to their content, so
+// a setup shared by a team is reproducible.
+type lockfile struct {
+	Version    int         `json:"version"`
+	Extensions []lockEntry `json:"extensions"`
+	CustomApps []lockEntry `json:"custom_apps"`
+}
+
+// lockEntry is an extension or custom app in lockfile
+type lockEntry struct {
+	// Name is as listed in config
+	Name string `json:"name"`
+	// Source is path relative to spicetify folder, absolute path or URL it
+	// is downloaded from when missing. URLs ending with ".zip" are
+	// extracted, for folder extensions and custom apps.
+	Source string `json:"source"`
+	// Checksum is sha256 of file, or of relative paths and checksums of
+	// every file in folder
+	Checksum string `json:"sha256"`
+}
+
+// getLockfilePath returns location of lockfile, next to config so both
+// can be committed together.
+func getLockfilePath() string {
+	return filepath.Join(spicetifyFolder, "spicetify.lock")
+}
+
+func readLockfile() (*lockfile, error) {
+	content, err := ioutil.ReadFile(getLockfilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &lockfile{}
+	if err = json.Unmarshal(content, lock); err != nil {
+		return nil, err
+	}
+	if lock.Version != lockfileVersion {
+		return nil, errors.New("unsupported lockfile version")
+	}
+
+	return lock, nil
+}
+
+// Lock writes lockfile pinning content of extensions and custom apps of
+// current config. URL sources of entries already in lockfile are kept.
+func Lock() {
+	previous, _ := readLockfile()
+	if previous == nil {
+		previous = &lockfile{}
+	}
+
+	lock := lockfile{Version: lockfileVersion, Extensions: []lockEntry{}, CustomApps: []lockEntry{}}
+	failed := false
+
+	for _, ext := range getExtensionList() {
+		extPath := ext
+		if !filepath.IsAbs(ext) {
+			var err error
+			if extPath, err = getExtensionPath(ext); err != nil {
+				utils.PrintError(`Extension "` + ext + `" not found.`)
+				failed = true
+				continue
+			}
+		}
+
+		entry, err := newLockEntry(ext, extPath, previous.Extensions)
+		if err != nil {
+			utils.PrintError(`Extension "` + ext + `": ` + err.Error())
+			failed = true
+			continue
+		}
+		lock.Extensions = append(lock.Extensions, entry)
+	}
+
+	for _, app := range getCustomAppList() {
+		appPath, err := getCustomAppPath(app)
+		if err != nil {
+			utils.PrintError(`Custom app "` + app + `" not found.`)
+			failed = true
+			continue
+		}
+
+		entry, err := newLockEntry(app, appPath, previous.CustomApps)
+		if err != nil {
+			utils.PrintError(`Custom app "` + app + `": ` + err.Error())
+			failed = true
+			continue
+		}
+		lock.CustomApps = append(lock.CustomApps, entry)
+	}
+
+	if failed {
+		utils.PrintError("Lockfile is not written.")
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	content, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		utils.Fatal(err)
+	}
+	if err = ioutil.WriteFile(getLockfilePath(), append(content, '\n'), 0644); err != nil {
+		utils.Fatal(err)
+	}
+
+	for _, entry := range append(lock.Extensions, lock.CustomApps...) {
+		log.Println(entry.Name + "  " + entry.Checksum[:12] + "  " + entry.Source)
+	}
+	utils.PrintSuccess("Lockfile is written to " + getLockfilePath())
+}
+
+// newLockEntry pins item `name` at `path`, keeping URL source of its
+// `previous` entry
+func newLockEntry(name, path string, previous []lockEntry) (lockEntry, error) {
+	checksum, err := hashLockContent(path)
+	if err != nil {
+		return lockEntry{}, err
+	}
+
+	source := path
+	if isWithin(path, spicetifyFolder) {
+		rel, _ := filepath.Rel(spicetifyFolder, path)
+		source = filepath.ToSlash(rel)
+	}
+	for _, entry := range previous {
+		if entry.Name == name && isURL(entry.Source) {
+			source = entry.Source
+		}
+	}
+
+	return lockEntry{Name: name, Source: source, Checksum: checksum}, nil
+}
+
+// verifyLockfile checks configured extensions and custom apps are exactly
+// ones in lockfile, with the same content. Missing ones with URL source are
+// downloaded first. Exits on any drift.
+func verifyLockfile() {
+	lock, err := readLockfile()
+	if err != nil {
+		utils.PrintError(`Cannot read lockfile ` + getLockfilePath() + `: ` + err.Error() + `. Run "spicetify lock" to create it.`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	problems := []string{}
+	problems = append(problems, verifyLockEntries("Extension", getExtensionList(), lock.Extensions, userExtensionsFolder, func(name string) (string, error) {
+		if filepath.IsAbs(name) {
+			return name, nil
+		}
+		return getExtensionPath(name)
+	})...)
+	problems = append(problems, verifyLockEntries("Custom app", getCustomAppList(), lock.CustomApps, userAppsFolder, getCustomAppPath)...)
+
+	if len(problems) > 0 {
+		utils.PrintError("Extensions or custom apps do not match lockfile:")
+		for _, problem := range problems {
+			log.Println("    " + problem)
+		}
+		utils.PrintInfo(`Run "spicetify lock" to accept current ones.`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	utils.PrintInfo("Extensions and custom apps match lockfile.")
+}
+
+// verifyLockEntries compares configured `list` of `kind` items with locked
+// `entries`. Missing items with URL source are installed to `folder`.
+// Returns problems found.
+func verifyLockEntries(kind string, list []string, entries []lockEntry, folder string, resolve func(string) (string, error)) []string {
+	problems := []string{}
+	locked := map[string]lockEntry{}
+	for _, entry := range entries {
+		locked[entry.Name] = entry
+		if !containsString(list, entry.Name) {
+			problems = append(problems, kind+` "`+entry.Name+`" is locked but not in config`)
+		}
+	}
+
+	for _, name := range list {
+		entry, ok := locked[name]
+		if !ok {
+			problems = append(problems, kind+` "`+name+`" is in config but not locked`)
+			continue
+		}
+
+		path, err := resolve(name)
+		if err != nil {
+			if !isURL(entry.Source) || filepath.IsAbs(name) {
+				problems = append(problems, kind+` "`+name+`" not found`)
+				continue
+			}
+			if err = installLockedSource(entry, filepath.Join(folder, name)); err != nil {
+				problems = append(problems, kind+` "`+name+`" cannot be downloaded: `+err.Error())
+				continue
+			}
+			utils.PrintSuccess(kind + ` "` + name + `" is downloaded from ` + entry.Source)
+			continue
+		}
+
+		checksum, err := hashLockContent(path)
+		if err != nil {
+			problems = append(problems, kind+` "`+name+`": `+err.Error())
+		} else if checksum != entry.Checksum {
+			problems = append(problems, kind+` "`+name+`" changed: sha256 `+checksum+`, locked `+entry.Checksum)
+		}
+	}
+
+	return problems
+}
+
+// installLockedSource downloads `entry` to `dest` once its content matches
+// locked checksum
+func installLockedSource(entry lockEntry, dest string) error {
+	content, err := fetchCached(entry.Source)
+	if err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempDir("", "spicetify-lock-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(temp)
+
+	source := filepath.Join(temp, filepath.Base(dest))
+	if strings.HasSuffix(strings.ToLower(entry.Source), ".zip") {
+		archive := filepath.Join(temp, "source.zip")
+		if err = ioutil.WriteFile(archive, content, 0600); err != nil {
+			return err
+		}
+		if err = utils.Unzip(archive, source); err != nil {
+			return err
+		}
+		source = singleFolder(source)
+	} else if err = ioutil.WriteFile(source, content, 0600); err != nil {
+		return err
+	}
+
+	checksum, err := hashLockContent(source)
+	if err != nil {
+		return err
+	}
+	if checksum != entry.Checksum {
+		return errors.New("sha256 " + checksum + " does not match locked " + entry.Checksum)
+	}
+
+	if info, _ := os.Stat(source); info.IsDir() {
+		return utils.Copy(source, dest, true, nil)
+	}
+	return utils.CopyFile(source, filepath.Dir(dest))
+}
+
+// hashLockContent returns sha256 of file at `path`, or for a folder, of
+// relative path and checksum of every file in it, so it does not depend on
+// where the folder is.
+func hashLockContent(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return utils.FileChecksum(path)
+	}
+
+	hash := sha256.New()
+	err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		checksum, err := utils.FileChecksum(filePath)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(path, filePath)
+		io.WriteString(hash, filepath.ToSlash(rel)+"\x00"+checksum+"\n")
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func isURL(source string) bool {
+	parsed, err := url.Parse(source)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http")
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// sizeCategories groups kinds of installed files in size report
+var sizeCategories = map[string]string{
+	"extension":  "extensions",
+	"custom-app": "custom apps",
+	"asset":      "assets",
+	"theme":      "theme",
+	"splash":     "theme",
+	"theme-js":   "theme",
+	"wrapper":    "wrapper",
+	"patched":    "patched stock files",
+	"other":      "other",
+}
+
+var sizeCategoryOrder = []string{"extensions", "custom apps", "assets", "theme", "wrapper", "patched stock files", "other"}
+
+// measureXpuiSize returns total size of frontend folder before apply
+// changes it. Before first apply, frontend is not extracted yet, so its
+// stock copy in raw folder is measured instead.
+func measureXpuiSize() int64 {
+	if _, err := os.Stat(getXpuiPath()); err != nil {
+		if stockPath := getStockXpuiPath(); len(stockPath) > 0 {
+			return getFolderSize(stockPath)
+		}
+	}
+	return getFolderSize(getXpuiPath())
+}
+
+// getFolderSize returns total size of regular files in `folder`
+func getFolderSize(folder string) int64 {
+	var size int64
+	filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// printSizeReport prints size of frontend folder before apply, `before`,
+// and after, then how much files apply added or modified contribute to it
+// by kind, taken from install manifest. Modified files contribute what
+// they grew by compared with stock.
+func printSizeReport(before int64) {
+	after := getFolderSize(getXpuiPath())
+	manifest := readInstallManifest()
+	stockPath := getStockXpuiPath()
+
+	sizes := map[string]int64{}
+	counts := map[string]int{}
+	files := map[string][]string{}
+	if manifest != nil {
+		for rel, file := range manifest.Files {
+			category, ok := sizeCategories[file.Kind]
+			if !ok {
+				category = "other"
+			}
+
+			size := file.Size
+			if file.Status == "modified" && len(stockPath) > 0 {
+				if stock, err := os.Stat(filepath.Join(stockPath, filepath.FromSlash(rel))); err == nil {
+					size -= stock.Size()
+				}
+			}
+
+			sizes[category] += size
+			counts[category]++
+			files[category] = append(files[category], rel+"  "+formatSizeDelta(size))
+		}
+	}
+
+	utils.PrintBold("Size of " + getXpuiPath() + ":")
+	log.Printf("    %-22s%s\n", "before", utils.FormatSize(before))
+	log.Printf("    %-22s%s (%s)\n", "after", utils.FormatSize(after), formatSizeDelta(after-before))
+
+	if manifest == nil {
+		utils.PrintWarning("Installed files are not recorded, size contributed by each kind is unknown.")
+		return
+	}
+
+	for _, category := range sizeCategoryOrder {
+		if counts[category] == 0 {
+			continue
+		}
+		unit := "files"
+		if counts[category] == 1 {
+			unit = "file"
+		}
+		log.Printf("    %-22s%s (%d %s)\n", category, formatSizeDelta(sizes[category]), counts[category], unit)
+
+		if utils.IsVerbose(utils.VerbosityVerbose) {
+			sort.Strings(files[category])
+			for _, file := range files[category] {
+				log.Println("        " + file)
+			}
+		}
+	}
+}
+
+// formatSizeDelta formats size difference with its sign, e.g. "+1.2 KB"
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + utils.FormatSize(-delta)
+	}
+	return "+" + utils.FormatSize(delta)
+}
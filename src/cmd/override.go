@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// Per-run replacements of config lists, set by "--extensions-from",
+// "--apps-from" and "--theme". nil means config value is used.
+var (
+	extensionsOverride []string
+	appsOverride       []string
+	themesOverride     []string
+)
+
+// colorSchemeOverride replaces config "color_scheme" for this run, set by
+// "--color-scheme". Blank means config value is used.
+var colorSchemeOverride string
+
+// initOverrides reads per-run lists from flags and checks every entry
+// exists, so a typo fails before Spotify is touched.
+func initOverrides(f Flag) {
+	if f.ExtensionsFrom == "-" && f.AppsFrom == "-" {
+		utils.PrintError(`Only one of "--extensions-from" and "--apps-from" can read from stdin.`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	if len(f.ExtensionsFrom) > 0 {
+		extensionsOverride = readOverrideList("--extensions-from", f.ExtensionsFrom)
+		for _, name := range extensionsOverride {
+			if _, err := getExtensionPath(name); err != nil {
+				utils.PrintError(`"--extensions-from": extension "` + name + `" not found.`)
+				utils.Exit(utils.ExitConfigError)
+			}
+		}
+	}
+
+	if len(f.AppsFrom) > 0 {
+		appsOverride = readOverrideList("--apps-from", f.AppsFrom)
+		for _, name := range appsOverride {
+			if _, err := getCustomAppPath(name); err != nil {
+				utils.PrintError(`"--apps-from": custom app "` + name + `" not found.`)
+				utils.Exit(utils.ExitConfigError)
+			}
+		}
+	}
+
+	if len(f.Theme) > 0 {
+		themesOverride = []string{}
+		for _, name := range strings.Split(f.Theme, "|") {
+			if name = strings.TrimSpace(name); len(name) > 0 {
+				themesOverride = append(themesOverride, name)
+			}
+		}
+		for _, name := range themesOverride {
+			if _, err := findThemeFolder(name); err != nil {
+				utils.PrintError(`"--theme": ` + err.Error() + ".")
+				utils.Exit(utils.ExitConfigError)
+			}
+		}
+	}
+
+	colorSchemeOverride = strings.TrimSpace(f.ColorScheme)
+
+	if f.Interactive && (extensionsOverride != nil || appsOverride != nil) {
+		utils.PrintError(`"--interactive" cannot be used with "--extensions-from" or "--apps-from".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+}
+
+// readOverrideList reads newline-separated list from file `source`, or
+// stdin if it is "-". Blank lines and lines starting with "#" are skipped.
+func readOverrideList(flag, source string) []string {
+	var reader io.Reader = os.Stdin
+	if source != "-" {
+		file, err := os.Open(source)
+		if err != nil {
+			utils.PrintError(`Cannot read "` + flag + `": ` + err.Error())
+			utils.Exit(utils.ExitConfigError)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	list := []string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			list = append(list, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		utils.PrintError(`Cannot read "` + flag + `": ` + err.Error())
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	return list
+}
+
+// getExtensionList returns extensions to apply: "--extensions-from" list or
+// config "extensions".
+func getExtensionList() []string {
+	if extensionsOverride != nil {
+		return extensionsOverride
+	}
+	return utils.ListValues(featureSection.Key("extensions"))
+}
+
+// getCustomAppList returns custom apps to apply: "--apps-from" list or
+// config "custom_apps", without duplicates.
+func getCustomAppList() []string {
+	if appsOverride != nil {
+		return uniqueApps(appsOverride)
+	}
+	return uniqueApps(utils.ListValues(featureSection.Key("custom_apps")))
+}
+
+// getThemeNames returns theme layers to apply: "--theme" value or config
+// "current_theme".
+func getThemeNames() []string {
+	if themesOverride != nil {
+		return themesOverride
+	}
+	return utils.ListValues(settingSection.Key("current_theme"))
+}
+
+// getColorSchemeName returns color scheme to apply: "--color-scheme" value
+// or config "color_scheme".
+func getColorSchemeName() string {
+	if len(colorSchemeOverride) > 0 {
+		return colorSchemeOverride
+	}
+	return settingSection.Key("color_scheme").String()
+}
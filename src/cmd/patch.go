@@ -1,66 +1,322 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/khanhas/spicetify-cli/src/cache"
+	spotifystatus "github.com/khanhas/spicetify-cli/src/status/spotify"
 	"github.com/khanhas/spicetify-cli/src/utils"
 )
 
-func Patch() {
-	keys := patchSection.Keys()
+// patchKeyRegex matches find RegExp keys of [Patch] and captures target
+// file name and patch index
+var patchKeyRegex = regexp.MustCompile(`^([\w\d\-\.]+)_find_(\d+)$`)
 
-	re := regexp.MustCompile(`^([\w\d\-\.]+)_find_(\d+)$`)
-	for _, key := range keys {
-		keyName := key.Name()
-		matches := re.FindStringSubmatch(keyName)
+// getPrePatchFolder returns folder keeping copies of xpui files as they
+// were before patches were applied, so patches can be re-run on them.
+func getPrePatchFolder() string {
+	return filepath.Join(stateFolder, "PrePatch")
+}
+
+// savePrePatch copies every xpui file that patches in config target to
+// pre-patch folder, replacing previous copies.
+func savePrePatch() {
+	folder := getPrePatchFolder()
+	os.RemoveAll(folder)
+	// Patches are about to run on fresh files
+	os.Remove(getPatchStatePath())
+	utils.CheckExistAndCreate(folder)
+
+	for _, key := range patchSection.Keys() {
+		matches := patchKeyRegex.FindStringSubmatch(key.Name())
 		if len(matches) == 0 {
 			continue
 		}
 
-		name := matches[1]
-		assetPath := filepath.Join(appPath, "xpui", name)
-		index := matches[2]
+		assetPath := filepath.Join(getXpuiPath(), matches[1])
+		if _, err := os.Stat(assetPath); err == nil {
+			utils.CopyFile(assetPath, folder)
+		}
+	}
+}
 
-		if _, err := os.Stat(assetPath); err != nil {
-			utils.PrintError("File name \"" + name + "\" is not found.")
+// ReapplyPatches runs patches in config again on currently applied xpui,
+// without a full apply. Files are restored to their pre-patch state first,
+// so patches are not applied twice and removed patches are undone.
+func ReapplyPatches() {
+	checkStates(requireBackup)
+
+	if !spotifystatus.Get(appDestPath).IsApplied() {
+		utils.PrintError(`Spotify is not applied yet. Run "spicetify apply" first.`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	manifest := readSourceManifest()
+	if manifest == nil || manifest["applied"] != hashAppliedState() {
+		utils.PrintError(`Spotify files changed since last apply. Run "spicetify apply" first.`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	prePatch, err := os.ReadDir(getPrePatchFolder())
+	if err != nil {
+		utils.PrintError(`Pre-patch files are not found. Run "spicetify apply" once first.`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	for _, entry := range prePatch {
+		if err := utils.CopyFile(filepath.Join(getPrePatchFolder(), entry.Name()), getXpuiPath()); err != nil {
+			utils.Fatal(err)
+		}
+	}
+
+	savePrePatch()
+	if len(patchSection.Keys()) == 0 {
+		utils.PrintInfo("There is no patch in config.")
+	} else {
+		Patch()
+	}
+
+	manifest["applied"] = hashAppliedState()
+	writeSourceManifest(manifest)
+	recordInstall()
+	utils.PrintSuccess("Patches are applied.")
+}
+
+// patchDef is a find/replace patch of [Patch] config section
+type patchDef struct {
+	key    string
+	target string
+	// find holds find RegExp then its alternatives, in order tried
+	find []*regexp.Regexp
+	// findAlt holds "_alt_<n>" number of each find RegExp, 0 for main one.
+	// Invalid alternatives are left out of find, so n can skip.
+	findAlt    []int
+	replace    string
+	replaceAll bool
+	// when is "<file>_when_<n>" condition, blank when patch always applies
+	when      string
+	condition patchCondition
+	// err tells why patch cannot be applied, nil if it can
+	err error
+	// hint is printed after err
+	hint []string
+	// warnings are problems that do not stop patch, e.g. invalid
+	// alternative
+	warnings []string
+}
+
+// getPatches parses patches of [Patch] config section, in config order
+func getPatches() []patchDef {
+	patches := []patchDef{}
+	for _, key := range patchSection.Keys() {
+		keyName := key.Name()
+		matches := patchKeyRegex.FindStringSubmatch(keyName)
+		if len(matches) == 0 {
 			continue
 		}
 
+		name := matches[1]
+		index := matches[2]
+		patch := patchDef{key: keyName, target: name}
+		patches = append(patches, patch)
+		current := &patches[len(patches)-1]
+
 		replName := name + "_repl_all_" + index
 		replOnceName := name + "_repl_" + index
 		replKey, errAll := patchSection.GetKey(replName)
 		replOnceKey, errOnce := patchSection.GetKey(replOnceName)
 
 		if errAll != nil && errOnce != nil {
-			utils.PrintError("Cannot find replace string for patch \"" + keyName + "\"")
-			utils.PrintInfo("Correct key name for replace string are")
-			utils.PrintInfo("    \"" + replOnceName + "\"")
-			utils.PrintInfo("    \"" + replName + "\"")
+			current.err = errors.New("Cannot find replace string for patch \"" + keyName + "\"")
+			current.hint = []string{
+				"Correct key name for replace string are",
+				"    \"" + replOnceName + "\"",
+				"    \"" + replName + "\"",
+			}
 			continue
 		}
 
+		// Priotize replace all
+		if errAll == nil {
+			current.replace = replKey.MustString("")
+			current.replaceAll = true
+		} else {
+			current.replace = replOnceKey.MustString("")
+		}
+
 		patchRegexp, errReg := regexp.Compile(key.String())
 		if errReg != nil {
-			utils.PrintError("Cannot compile find RegExp for patch \"" + keyName + "\"")
+			current.err = errors.New("Cannot compile find RegExp for patch \"" + keyName + "\"")
 			continue
 		}
 
+		// Alternatives are tried in order when find RegExp matches nothing,
+		// e.g. after Spotify renamed minified symbols in an update.
+		current.find = []*regexp.Regexp{patchRegexp}
+		current.findAlt = []int{0}
+		for alt := 1; ; alt++ {
+			altName := keyName + "_alt_" + strconv.Itoa(alt)
+			altKey, err := patchSection.GetKey(altName)
+			if err != nil {
+				break
+			}
+
+			altRegexp, err := regexp.Compile(altKey.String())
+			if err != nil {
+				current.warnings = append(current.warnings, "Cannot compile find RegExp for patch \""+altName+"\"")
+				continue
+			}
+			current.find = append(current.find, altRegexp)
+			current.findAlt = append(current.findAlt, alt)
+		}
+
+		whenName := name + "_when_" + index
+		if whenKey, err := patchSection.GetKey(whenName); err == nil && len(strings.TrimSpace(whenKey.String())) > 0 {
+			current.when = strings.TrimSpace(whenKey.String())
+			if current.condition, err = parsePatchCondition(current.when); err != nil {
+				current.err = errors.New("Cannot parse condition \"" + whenName + "\" of patch: " + err.Error())
+			}
+		}
+	}
+
+	return patches
+}
+
+// isSkipped reports whether patch has a condition that is false for
+// current config
+func (patch patchDef) isSkipped() bool {
+	return patch.err == nil && patch.condition != nil && !patch.condition.eval()
+}
+
+// match returns index of first find RegExp of patch that matches
+// `content`, or -1 if none does
+func (patch patchDef) match(content string) int {
+	for i, pattern := range patch.find {
+		if pattern.MatchString(content) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hash identifies patch definition, to tell whether it changed since it
+// was applied
+func (patch patchDef) hash() string {
+	parts := []string{patch.key, patch.replace, strconv.FormatBool(patch.replaceAll)}
+	for _, pattern := range patch.find {
+		parts = append(parts, pattern.String())
+	}
+	return cache.Key(parts...)
+}
+
+// Patch applies find/replace patches of [Patch] config section to xpui
+// files. Returns problems of patches that are not applied.
+func Patch() []string {
+	state := patchState{Patches: map[string]string{}, Files: map[string]string{}}
+	problems := []string{}
+
+	for _, patch := range getPatches() {
+		keyName := patch.key
+		assetPath := filepath.Join(getXpuiPath(), patch.target)
+
+		// Skipped by config rather than failed, so not a problem
+		if patch.isSkipped() {
+			utils.PrintInfo("\"" + keyName + "\" is skipped, its condition \"" + patch.when + "\" is false.")
+			continue
+		}
+
+		if _, err := os.Stat(assetPath); err != nil {
+			utils.PrintError("File name \"" + patch.target + "\" is not found.")
+			problems = append(problems, "\""+keyName+"\": file \""+patch.target+"\" is not found.")
+			continue
+		}
+
+		if patch.err != nil {
+			utils.PrintError(patch.err.Error())
+			problems = append(problems, patch.err.Error()+".")
+			for _, line := range patch.hint {
+				utils.PrintInfo(line)
+			}
+			continue
+		}
+		for _, warning := range patch.warnings {
+			utils.PrintError(warning)
+		}
+
+		matched := -1
 		utils.ModifyFile(assetPath, func(content string) string {
-			if errAll == nil { // Priotize replace all
-				return patchRegexp.ReplaceAllString(content, replKey.MustString(""))
-			} else {
-				match := patchRegexp.FindString(content)
-				if len(match) > 0 {
-					toReplace := patchRegexp.ReplaceAllString(match, replOnceKey.MustString(""))
-					content = strings.Replace(content, match, toReplace, 1)
-				}
+			matched = patch.match(content)
+			if matched < 0 {
 				return content
 			}
+
+			pattern := patch.find[matched]
+			if patch.replaceAll {
+				return pattern.ReplaceAllString(content, patch.replace)
+			}
+
+			match := pattern.FindString(content)
+			toReplace := pattern.ReplaceAllString(match, patch.replace)
+			return strings.Replace(content, match, toReplace, 1)
 		})
 
+		if matched < 0 {
+			problems = append(problems, "\""+keyName+"\" matched nothing.")
+			utils.PrintWarning("\"" + keyName + "\" matched nothing and is not applied. Spotify may have changed, update its find RegExp or add alternatives as \"" + keyName + "_alt_1\", \"" + keyName + "_alt_2\"...")
+			continue
+		}
+
+		state.Patches[keyName] = patch.hash()
+		state.Files[patch.target] = ""
+
+		if alt := patch.findAlt[matched]; alt > 0 {
+			utils.PrintSuccess("\"" + keyName + "\" is patched using \"" + keyName + "_alt_" + strconv.Itoa(alt) + "\"")
+			continue
+		}
+
 		utils.PrintSuccess("\"" + keyName + "\" is patched")
 	}
+
+	for target := range state.Files {
+		state.Files[target], _ = utils.FileChecksum(filepath.Join(getXpuiPath(), target))
+	}
+	writePatchState(state)
+	return problems
+}
+
+// patchState records patches applied by last Patch run, so "patches list"
+// can tell whether they are still in effect
+type patchState struct {
+	// Patches maps key of applied patch to hash of its definition
+	Patches map[string]string `json:"patches"`
+	// Files maps patched file to its checksum after patching
+	Files map[string]string `json:"files"`
+}
+
+func getPatchStatePath() string {
+	return filepath.Join(stateFolder, "patch-state.json")
+}
+
+func readPatchState() patchState {
+	state := patchState{}
+	if content, err := os.ReadFile(getPatchStatePath()); err == nil {
+		json.Unmarshal(content, &state)
+	}
+	return state
+}
+
+func writePatchState(state patchState) {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err == nil {
+		err = os.WriteFile(getPatchStatePath(), content, 0600)
+	}
+	if err != nil {
+		utils.PrintWarning("Cannot record applied patches: " + err.Error())
+	}
 }
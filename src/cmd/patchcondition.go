@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// patchCondition is parsed "<file>_when_<n>" of a patch: terms joined with
+// "&&", in alternatives joined with "||". "&&" binds tighter, there are no
+// parentheses.
+type patchCondition [][]conditionTerm
+
+// conditionTerm is "<Section>.<key>", true when its value is set and not
+// false, or "<Section>.<key> == <value>" or "!=", optionally negated with
+// leading "!"
+type conditionTerm struct {
+	negate  bool
+	section *ini.Section
+	key     string
+	// op is "==", "!=" or blank for truthiness of value
+	op    string
+	value string
+}
+
+// parsePatchCondition parses condition `expr` over config sections, e.g.
+// `AdditionalOptions.custom_apps != "" && !Setting.inject_css`
+func parsePatchCondition(expr string) (patchCondition, error) {
+	condition := patchCondition{}
+	for _, alternative := range strings.Split(expr, "||") {
+		terms := []conditionTerm{}
+		for _, text := range strings.Split(alternative, "&&") {
+			term, err := parseConditionTerm(strings.TrimSpace(text))
+			if err != nil {
+				return nil, err
+			}
+			terms = append(terms, term)
+		}
+		condition = append(condition, terms)
+	}
+	return condition, nil
+}
+
+func parseConditionTerm(text string) (conditionTerm, error) {
+	term := conditionTerm{}
+	if strings.HasPrefix(text, "!") && !strings.HasPrefix(text, "!=") {
+		term.negate = true
+		text = strings.TrimSpace(text[1:])
+	}
+
+	ref := text
+	for _, op := range []string{"==", "!="} {
+		if index := strings.Index(text, op); index > -1 {
+			term.op = op
+			ref = strings.TrimSpace(text[:index])
+			term.value = strings.Trim(strings.TrimSpace(text[index+len(op):]), `"`)
+			break
+		}
+	}
+
+	dot := strings.Index(ref, ".")
+	if dot < 1 {
+		return term, errors.New(`"` + text + `" is not "<Section>.<key>"`)
+	}
+	sectionName := ref[:dot]
+	term.key = ref[dot+1:]
+
+	for _, section := range []*ini.Section{settingSection, preprocSection, featureSection, patchSection} {
+		if strings.EqualFold(section.Name(), sectionName) {
+			term.section = section
+		}
+	}
+	// Backup of additional install is recorded in its install section
+	if strings.EqualFold(sectionName, "Backup") {
+		term.section = backupSection
+	}
+	if term.section == nil {
+		return term, errors.New(`config section "` + sectionName + `" does not exist`)
+	}
+	if !term.section.HasKey(term.key) {
+		return term, errors.New(`config key "` + ref + `" does not exist`)
+	}
+
+	return term, nil
+}
+
+// eval reports whether condition holds for current config
+func (condition patchCondition) eval() bool {
+	for _, terms := range condition {
+		holds := true
+		for _, term := range terms {
+			if !term.eval() {
+				holds = false
+				break
+			}
+		}
+		if holds {
+			return true
+		}
+	}
+	return false
+}
+
+func (term conditionTerm) eval() bool {
+	value := strings.TrimSpace(term.section.Key(term.key).String())
+
+	result := false
+	switch term.op {
+	case "==":
+		result = value == term.value
+	case "!=":
+		result = value != term.value
+	default:
+		switch strings.ToLower(value) {
+		case "", "0", "false", "no", "off":
+		default:
+			result = true
+		}
+	}
+
+	return result != term.negate
+}
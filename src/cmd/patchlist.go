@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+type patchInfo struct {
+	Key string `json:"key"`
+	// Target is file in xpui folder patch modifies
+	Target string `json:"target"`
+	// Find is find RegExp, Alternatives are tried in order after it
+	Find         string   `json:"find"`
+	Alternatives []string `json:"alternatives"`
+	ReplaceAll   bool     `json:"replace_all"`
+	// When is condition of patch, blank when it always applies
+	When string `json:"when,omitempty"`
+	// Match is "find", "alt_<n>" or "none" for what matches installed
+	// code, blank when it cannot be checked
+	Match string `json:"match"`
+	// Status is "applied", "changed" (config changed since applied),
+	// "reverted" (file changed since patched, e.g. by Spotify update),
+	// "skipped" (its condition is false), "not-applied" or "invalid"
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ListPatches prints patches of [Patch] config section with their target
+// file, whether they match installed Spotify code and whether they are
+// applied. Output is JSON when "--json" is used.
+func ListPatches() {
+	state := readPatchState()
+	infos := []patchInfo{}
+	for _, patch := range getPatches() {
+		infos = append(infos, getPatchInfo(patch, state))
+	}
+
+	if flags.JSON {
+		out, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			utils.Fatal(err)
+		}
+		log.Println(string(out))
+		return
+	}
+
+	if len(infos) == 0 {
+		utils.PrintInfo("There is no patch in config.")
+		return
+	}
+
+	for _, info := range infos {
+		status := info.Status
+		switch status {
+		case "applied":
+			status = utils.Green(status)
+		case "invalid", "reverted":
+			status = utils.Red(status)
+		default:
+			status = utils.Yellow(status)
+		}
+
+		log.Println(utils.Bold(info.Key) + " -> " + info.Target + "  " + status)
+		if len(info.Error) > 0 {
+			log.Println("    " + info.Error)
+			continue
+		}
+		if len(info.When) > 0 {
+			log.Println("    Applies when " + info.When)
+		}
+		switch info.Match {
+		case "":
+		case "none":
+			log.Println("    Matches nothing in installed code")
+		default:
+			log.Println("    Matches installed code with " + info.Match)
+		}
+	}
+}
+
+func getPatchInfo(patch patchDef, state patchState) patchInfo {
+	info := patchInfo{
+		Key:          patch.key,
+		Target:       patch.target,
+		Alternatives: []string{},
+		ReplaceAll:   patch.replaceAll,
+		When:         patch.when,
+	}
+	for i, pattern := range patch.find {
+		if i == 0 {
+			info.Find = pattern.String()
+		} else {
+			info.Alternatives = append(info.Alternatives, pattern.String())
+		}
+	}
+
+	if patch.err != nil {
+		info.Status = "invalid"
+		info.Error = patch.err.Error()
+		return info
+	}
+
+	targetPath := filepath.Join(getXpuiPath(), patch.target)
+	current, err := utils.FileChecksum(targetPath)
+	if err != nil {
+		info.Status = "invalid"
+		info.Error = `File name "` + patch.target + `" is not found.`
+		return info
+	}
+
+	if content, err := os.ReadFile(getPatchSource(patch.target, current, state)); err == nil {
+		switch matched := patch.match(string(content)); {
+		case matched < 0:
+			info.Match = "none"
+		case matched == 0:
+			info.Match = "find"
+		default:
+			info.Match = "alt_" + strconv.Itoa(matched)
+		}
+	}
+
+	hash, applied := state.Patches[patch.key]
+	switch {
+	case patch.isSkipped():
+		info.Status = "skipped"
+	case !applied:
+		info.Status = "not-applied"
+	case state.Files[patch.target] != current:
+		info.Status = "reverted"
+	case hash != patch.hash():
+		info.Status = "changed"
+	default:
+		info.Status = "applied"
+	}
+	return info
+}
+
+// getPatchSource returns path of file patches of `target` run on: its
+// pre-patch copy while it still belongs to installed file, otherwise
+// installed file itself. `current` is checksum of installed file.
+func getPatchSource(target, current string, state patchState) string {
+	installed := filepath.Join(getXpuiPath(), target)
+	prePatch := filepath.Join(getPrePatchFolder(), target)
+	prePatchSum, err := utils.FileChecksum(prePatch)
+	if err != nil {
+		return installed
+	}
+
+	if patched, ok := state.Files[target]; ok && patched == current || prePatchSum == current {
+		return prePatch
+	}
+	return installed
+}
@@ -38,10 +38,13 @@ func ThemeAllAssetsPath() (string, error) {
 		return "", errors.New(`Config "current_theme" is blank`)
 	}
 
-	results := []string{
-		filepath.Join(themeFolder, "color.ini"),
-		filepath.Join(themeFolder, "user.css"),
-		filepath.Join(themeFolder, "assets")}
+	results := []string{}
+	for _, folder := range themeFolders {
+		results = append(results,
+			filepath.Join(folder, "color.ini"),
+			filepath.Join(folder, "user.css"),
+			filepath.Join(folder, "assets"))
+	}
 
 	return strings.Join(results, "\n"), nil
 }
@@ -53,7 +56,7 @@ func ExtensionPath(name string) (string, error) {
 
 // ExtensionAllPath returns paths of all extension files
 func ExtensionAllPath() (string, error) {
-	exts := featureSection.Key("extensions").Strings("|")
+	exts := getExtensionList()
 	results := []string{}
 	for _, v := range exts {
 		path, err := getExtensionPath(v)
@@ -73,7 +76,7 @@ func AppPath(name string) (string, error) {
 
 // AppAllPath returns paths of all apps
 func AppAllPath() (string, error) {
-	exts := featureSection.Key("custom_apps").Strings("|")
+	exts := getCustomAppList()
 	results := []string{}
 	for _, v := range exts {
 		path, err := getCustomAppPath(v)
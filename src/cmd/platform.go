@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// getPlatformTags returns terms that "spicetify_platform" constraints are
+// matched against: OS name and Spotify install type.
+func getPlatformTags() map[string]bool {
+	tags := map[string]bool{
+		runtime.GOOS:     true,
+		getInstallType(): true,
+	}
+
+	if runtime.GOOS == "darwin" {
+		tags["macos"] = true
+	}
+
+	return tags
+}
+
+var knownPlatformTerms = map[string]bool{
+	"windows":      true,
+	"linux":        true,
+	"darwin":       true,
+	"macos":        true,
+	installDefault: true,
+	installAppX:    true,
+	installFlatpak: true,
+	installSnap:    true,
+}
+
+// matchPlatform evaluates "spicetify_platform" constraint `expr` against
+// `tags`. Constraint is a list of terms separated by spaces or commas. A
+// term is an OS ("windows", "linux", "darwin" or "macos") or an install type
+// ("default", "appx", "flatpak" or "snap"), negated with "!" prefix.
+// Constraint is met when at least one plain term, if any, matches and no
+// negated term matches. E.g. "!appx", "windows linux", "windows !appx".
+// Unknown terms are reported as an error.
+func matchPlatform(expr string, tags map[string]bool) (bool, error) {
+	terms := strings.FieldsFunc(strings.ToLower(expr), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t'
+	})
+
+	hasPositive := false
+	positiveMatched := false
+	for _, term := range terms {
+		negated := strings.HasPrefix(term, "!")
+		name := strings.TrimPrefix(term, "!")
+
+		if !knownPlatformTerms[name] {
+			return false, errUnknownPlatform(name)
+		}
+
+		if negated {
+			if tags[name] {
+				return false, nil
+			}
+			continue
+		}
+
+		hasPositive = true
+		positiveMatched = positiveMatched || tags[name]
+	}
+
+	return !hasPositive || positiveMatched, nil
+}
+
+type errUnknownPlatform string
+
+func (e errUnknownPlatform) Error() string {
+	return `unknown platform "` + string(e) + `"`
+}
+
+// isExtensionForPlatform reports whether extension at `extPath` can run
+// on current platform, according to its "// spicetify_platform:" header.
+// Extensions without the header run everywhere. `reason` explains why
+// extension is not for this platform.
+func isExtensionForPlatform(extPath string) (ok bool, reason string) {
+	expr := getExtensionHeader(extPath, "spicetify_platform")
+	if len(expr) == 0 {
+		return true, ""
+	}
+
+	ok, err := matchPlatform(expr, getPlatformTags())
+	if err != nil {
+		utils.PrintWarning(`Cannot evaluate "spicetify_platform: ` + expr + `" of ` + extPath + `: ` + err.Error() + `. Loading it anyway.`)
+		return true, ""
+	}
+
+	if !ok {
+		return false, `it is only for platform "` + expr + `"`
+	}
+
+	return true, ""
+}
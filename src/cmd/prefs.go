@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ini/ini"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+const prefsBackupPrefix = "prefs-"
+
+var errMissingPrefsVersion = errors.New(`"app.last-launched-version" is missing`)
+
+func getPrefsBackupFolder() string {
+	return filepath.Join(stateFolder, "PrefsBackup")
+}
+
+// BackupPrefs copies Spotify "prefs" file to spicetify config folder, under
+// a timestamped name, independently of app files backup.
+func BackupPrefs() {
+	if err := validatePrefs(prefsPath); err != nil {
+		utils.PrintError(`Spotify "prefs" file is invalid, not backing it up: ` + err.Error())
+		utils.Exit(utils.ExitSpotifyError)
+	}
+
+	content, err := os.ReadFile(prefsPath)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	folder := getPrefsBackupFolder()
+	if err = os.MkdirAll(folder, 0700); err != nil {
+		utils.Fatal(err)
+	}
+
+	dest := filepath.Join(folder, prefsBackupPrefix+time.Now().Format("20060102-150405"))
+	if err = os.WriteFile(dest, content, 0600); err != nil {
+		utils.Fatal(err)
+	}
+
+	utils.PrintSuccess(`Spotify "prefs" is backed up to ` + dest)
+}
+
+// RestorePrefs overwrites Spotify "prefs" file with backup `name`, or with
+// the latest backup when `name` is blank. Backup is validated first.
+func RestorePrefs(name string) {
+	backups := getPrefsBackups()
+	if len(backups) == 0 {
+		utils.PrintError(`There is no "prefs" backup. Run "spicetify prefs backup" first.`)
+		utils.Exit(utils.ExitNoBackup)
+	}
+
+	if len(name) == 0 {
+		name = backups[len(backups)-1]
+	} else if !containsString(backups, name) {
+		utils.PrintError(`"prefs" backup "` + name + `" not found. Available backups:`)
+		for _, backup := range backups {
+			utils.PrintError("    " + backup)
+		}
+		utils.Exit(utils.ExitNoBackup)
+	}
+
+	backupPath := filepath.Join(getPrefsBackupFolder(), name)
+	if err := validatePrefs(backupPath); err != nil {
+		utils.PrintError(`"prefs" backup "` + name + `" is invalid, not restoring it: ` + err.Error())
+		utils.Exit(utils.ExitFailure)
+	}
+
+	content, err := os.ReadFile(backupPath)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	if err = os.WriteFile(prefsPath, content, 0600); err != nil {
+		utils.Fatal(err)
+	}
+
+	utils.PrintSuccess(`Spotify "prefs" is restored from ` + name)
+	utils.PrintInfo(`Spotify rewrites "prefs" on exit. If it was running, restart it with "spicetify restart".`)
+}
+
+// getPrefsBackups returns names of "prefs" backups, oldest first.
+func getPrefsBackups() []string {
+	entries, err := os.ReadDir(getPrefsBackupFolder())
+	if err != nil {
+		return nil
+	}
+
+	backups := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefsBackupPrefix) {
+			backups = append(backups, entry.Name())
+		}
+	}
+
+	// Timestamp format sorts chronologically
+	sort.Strings(backups)
+	return backups
+}
+
+// validatePrefs checks that "prefs" file at `path` parses and has Spotify
+// version, like a file Spotify wrote would.
+func validatePrefs(path string) error {
+	pref, err := ini.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if len(pref.Section("").Key("app.last-launched-version").String()) == 0 {
+		return errMissingPrefsVersion
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/backup"
+	backupstatus "github.com/khanhas/spicetify-cli/src/status/backup"
+	spotifystatus "github.com/khanhas/spicetify-cli/src/status/spotify"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// RefreshRaw regenerates raw and themed assets from current backup, e.g.
+// when they were extracted from a backup of an older Spotify version, so
+// applying no longer copies stale stock files. Backup must match installed
+// Spotify and be intact.
+func RefreshRaw() {
+	backStat := backupstatus.Get(prefsPath, backupFolder, getBackupVersion())
+	if backStat.IsEmpty() {
+		utils.PrintError(`You haven't backed up. Run "spicetify backup" first.`)
+		utils.Exit(utils.ExitNoBackup)
+	} else if backStat.IsOutdated() {
+		utils.PrintError("Spotify version and backup version are mismatched, raw assets from this backup would not match Spotify either.")
+		utils.PrintInfo(`Restore or re-install Spotify, then run "spicetify backup" to back up current version.`)
+		utils.Exit(utils.ExitBackupOutdated)
+	}
+
+	if damaged := backup.Verify(backupFolder); len(damaged) > 0 {
+		utils.PrintError("Backup is damaged, these files do not match what was backed up: " + strings.Join(damaged, ", "))
+		utils.PrintInfo(`Please re-install Spotify then run "spicetify backup".`)
+		utils.Exit(utils.ExitBackupCorrupt)
+	}
+
+	for _, folder := range []string{rawFolder, themedFolder} {
+		if err := os.RemoveAll(folder); err != nil {
+			utils.Fatal(err)
+		}
+		os.Mkdir(folder, 0700)
+	}
+
+	extractBackup()
+
+	// Applied files were copied from previous raw assets, next apply needs
+	// to replace all of them
+	clearSourceManifest()
+
+	utils.PrintSuccess("Raw assets are regenerated from backup.")
+	if spotifystatus.Get(appDestPath).IsApplied() {
+		utils.PrintInfo(`Run "spicetify restore apply" to reapply on top of them.`)
+	}
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/apply"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// repairApply checks files recorded in install manifest and only brings
+// back ones that are missing or changed, e.g. after Spotify overwrote some
+// of them, by re-pushing what they belong to. Everything else is untouched.
+func repairApply() {
+	InitSetting()
+
+	manifest := readInstallManifest()
+	xpuiPath := getXpuiPath()
+	if manifest == nil || manifest.XpuiPath != xpuiPath {
+		utils.PrintError(`There is no record of applied files. Run "spicetify apply" without "--repair" first.`)
+		utils.Exit(utils.ExitFailure)
+	}
+	if _, err := os.Stat(xpuiPath); err != nil {
+		utils.PrintError(`Spotify frontend folder is gone, probably after Spotify update. Run "spicetify backup apply".`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	missing, modified := getDriftedFiles(manifest, xpuiPath)
+	broken := append(missing, modified...)
+	sort.Strings(broken)
+
+	if len(broken) == 0 {
+		utils.PrintSuccess("Nothing to repair, every applied file is intact.")
+		return
+	}
+
+	utils.PrintBold("Missing or changed files:")
+	for _, name := range broken {
+		log.Println("    " + name)
+	}
+
+	extensionOwners := map[string]string{}
+	for _, ext := range getExtensionList() {
+		if fileName, ok := extensionFileName(ext); ok {
+			extensionOwners[fileName] = ext
+			extensionOwners[getExtensionCSSName(fileName)] = ext
+		}
+	}
+
+	appList := getCustomAppList()
+	appOwners := map[string]string{}
+	for _, app := range appList {
+		for _, ext := range []string{".js", ".json", ".css"} {
+			appOwners["spicetify-routes-"+app+ext] = app
+		}
+	}
+	for app, chunks := range getAppsChunks(appList, false) {
+		for _, chunk := range chunks {
+			appOwners[chunk.ID+".js"] = app
+		}
+	}
+
+	extensions := []string{}
+	apps := []string{}
+	stock := []string{}
+	unknown := []string{}
+	css, assets, wrapper, splash, themeJS := false, false, false, false, false
+
+	for _, name := range broken {
+		switch manifest.Files[name].Kind {
+		case "extension":
+			if ext, ok := extensionOwners[name]; ok {
+				if !containsString(extensions, ext) {
+					extensions = append(extensions, ext)
+				}
+				continue
+			}
+		case "custom-app":
+			if app, ok := appOwners[name]; ok {
+				if !containsString(apps, app) {
+					apps = append(apps, app)
+				}
+				continue
+			}
+		case "wrapper":
+			wrapper = true
+			continue
+		case "splash":
+			if injectSplash {
+				splash = true
+				continue
+			}
+		case "theme-js":
+			themeJS = true
+			continue
+		case "theme":
+			css = true
+			continue
+		case "asset":
+			if overwriteAssets {
+				assets = true
+				continue
+			}
+		case "patched":
+			stock = append(stock, name)
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+
+	if css {
+		utils.PrintStage(`Transferring user.css`)
+		updateCSS()
+		utils.PrintStageDone()
+	}
+
+	if assets {
+		utils.PrintStage(`Overwriting custom assets`)
+		updateAssets()
+		utils.PrintStageDone()
+	}
+
+	if wrapper {
+		pushWrapper()
+	}
+
+	if splash {
+		if err := apply.SplashStyle(xpuiPath, true); err != nil {
+			utils.PrintError(err.Error())
+			unknown = append(unknown, apply.SplashCSSName)
+		}
+	}
+
+	if themeJS {
+		updateThemeJS()
+	}
+
+	if len(extensions) > 0 {
+		utils.PrintStage(`Transferring extensions`)
+		pushExtensions(extensions...)
+		utils.PrintStageDone()
+	}
+
+	if len(stock) > 0 {
+		utils.PrintStage(`Modifying Spotify files`)
+		unknown = append(unknown, repairStockFiles(stock, appList)...)
+		utils.PrintStageDone()
+	}
+
+	if len(apps) > 0 {
+		utils.PrintStage(`Transferring custom apps`)
+		pushApps(apps...)
+		utils.PrintStageDone()
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		utils.PrintWarning(`Cannot repair these files, which no longer belong to current config: ` + strings.Join(unknown, ", ") + `. Run "spicetify apply" without "--repair".`)
+		utils.MarkPartialFailure()
+	}
+
+	if sources := readSourceManifest(); sources != nil {
+		sources["applied"] = hashAppliedState()
+		writeSourceManifest(sources)
+	}
+	recordInstall()
+
+	utils.PrintSuccess("Repaired " + strconv.Itoa(len(broken)-len(unknown)) + " of " + strconv.Itoa(len(broken)) + " files.")
+}
+
+// repairStockFiles brings Spotify files that apply modifies back to their
+// applied state. Patch targets are restored from pre-patch copies and
+// patched again, others are modified again from stock copies. Returns files
+// that cannot be repaired.
+func repairStockFiles(names []string, appList []string) []string {
+	xpuiPath := getXpuiPath()
+	stockPath := getStockXpuiPath()
+	failed := []string{}
+	repatch := false
+
+	extensionFiles := []string{}
+	for _, fileName := range getExtensionFileNames(getExtensionList()) {
+		if _, err := os.Stat(filepath.Join(xpuiPath, fileName)); err == nil {
+			extensionFiles = append(extensionFiles, fileName)
+		}
+	}
+	flag := apply.Flag{
+		Extension:      extensionFiles,
+		CustomApp:      appList,
+		CustomAppChunk: getAppChunkIDs(appList),
+		CustomAppRoute: getAppRoutes(appList, false),
+		Splash:         getThemeSplash(),
+	}
+	if _, err := os.Stat(filepath.Join(xpuiPath, apply.ThemeJSName)); err == nil {
+		flag.ThemeJS = true
+	}
+
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(getPrePatchFolder(), name)); err == nil {
+			repatch = true
+			continue
+		}
+
+		if len(stockPath) == 0 || !apply.ModifiesFile(name) {
+			failed = append(failed, name)
+			continue
+		}
+
+		if err := utils.CopyFile(filepath.Join(stockPath, name), xpuiPath); err != nil {
+			utils.PrintError(err.Error())
+			failed = append(failed, name)
+			continue
+		}
+		apply.AdditionalOption(xpuiPath, name, flag)
+	}
+
+	if repatch {
+		// Every target is restored, so patches touching several files stay
+		// consistent and none is applied twice
+		prePatch, _ := os.ReadDir(getPrePatchFolder())
+		for _, entry := range prePatch {
+			if err := utils.CopyFile(filepath.Join(getPrePatchFolder(), entry.Name()), xpuiPath); err != nil {
+				utils.Fatal(err)
+			}
+		}
+		Patch()
+	}
+
+	return failed
+}
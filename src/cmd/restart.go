@@ -5,32 +5,135 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+const (
+	installDefault = "default"
+	installAppX    = "appx"
+	installFlatpak = "flatpak"
+	installSnap    = "snap"
 )
 
-// RestartSpotify .
+// RestartSpotify terminates all running Spotify processes and launches
+// Spotify again, using correct invocation for detected install type.
 func RestartSpotify(flags ...string) {
-	launchFlag := settingSection.Key("spotify_launch_flags").Strings("|")
+	launchFlag := utils.ListValues(settingSection.Key("spotify_launch_flags"))
 	if len(launchFlag) > 0 {
 		flags = append(flags, launchFlag...)
 	}
 
+	for _, flag := range getAppsRequiredFlags() {
+		if !containsString(flags, flag) {
+			flags = append(flags, flag)
+		}
+	}
+
+	flags = append(flags, appArgs...)
+
+	killSpotify()
+	launchSpotify(flags)
+}
+
+// getInstallType returns how Spotify is installed, judged by its location.
+func getInstallType() string {
+	if isAppX {
+		return installAppX
+	}
+
+	if runtime.GOOS == "linux" {
+		if strings.Contains(spotifyPath, "flatpak") {
+			return installFlatpak
+		}
+
+		if strings.HasPrefix(spotifyPath, "/snap/") {
+			return installSnap
+		}
+	}
+
+	return installDefault
+}
+
+func killSpotify() {
 	switch runtime.GOOS {
 	case "windows":
 		exec.Command("taskkill", "/F", "/IM", "spotify.exe").Run()
+	case "linux":
+		if getInstallType() == installFlatpak {
+			exec.Command("flatpak", "kill", "com.spotify.Client").Run()
+		}
+		exec.Command("pkill", "spotify").Run()
+		waitForExit("spotify")
+	case "darwin":
+		exec.Command("pkill", "Spotify").Run()
+		waitForExit("Spotify")
+	}
+}
+
+// waitForExit waits, up to 5 seconds, until no process named `name` is
+// running. Launching while old instance is still shutting down would only
+// focus the old instance.
+func waitForExit(name string) {
+	for i := 0; i < 25; i++ {
+		if exec.Command("pgrep", name).Run() != nil {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func launchSpotify(flags []string) {
+	switch runtime.GOOS {
+	case "windows":
 		if isAppX {
+			// Store version cannot be launched with modded Apps folder from
+			// its Start menu tile, only with "--app-directory" pointing to it.
+			// PowerShell runs it as script text, so every argument is
+			// quoted to not be interpreted.
 			ps, _ := exec.LookPath("powershell.exe")
 			exe := filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "WindowsApps", "Spotify.exe")
-			flags = append([]string{"-NoProfile", "-NonInteractive", `& "` + exe + `" --app-directory="` + appDestPath + `"`}, flags...)
-			exec.Command(ps, flags...).Start()
+			script := "& " + quotePowerShell(exe) + " " + quotePowerShell("--app-directory="+appDestPath)
+			for _, flag := range flags {
+				script += " " + quotePowerShell(flag)
+			}
+			exec.Command(ps, "-NoProfile", "-NonInteractive", "-Command", script).Start()
 		} else {
 			exec.Command(filepath.Join(spotifyPath, "spotify.exe"), flags...).Start()
 		}
 	case "linux":
-		exec.Command("pkill", "spotify").Run()
-		exec.Command(filepath.Join(spotifyPath, "spotify"), flags...).Start()
+		switch getInstallType() {
+		case installFlatpak:
+			flags = append([]string{"run", "com.spotify.Client"}, flags...)
+			exec.Command("flatpak", flags...).Start()
+		case installSnap:
+			flags = append([]string{"run", "spotify"}, flags...)
+			exec.Command("snap", flags...).Start()
+		default:
+			exec.Command(filepath.Join(spotifyPath, "spotify"), flags...).Start()
+		}
 	case "darwin":
-		exec.Command("pkill", "Spotify").Run()
-		flags = append([]string{"-a", "/Applications/Spotify.app"}, flags...)
+		flags = append([]string{"-a", getDarwinAppBundle(), "--args"}, flags...)
 		exec.Command("open", flags...).Start()
 	}
 }
+
+// quotePowerShell quotes `arg` as PowerShell single-quoted string, in which
+// nothing but doubled single quote is special.
+func quotePowerShell(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+}
+
+// getDarwinAppBundle returns Spotify.app bundle containing spotifyPath,
+// which normally points to its "Contents/Resources" folder.
+func getDarwinAppBundle() string {
+	for dir := spotifyPath; dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if strings.HasSuffix(dir, ".app") {
+			return dir
+		}
+	}
+
+	return "/Applications/Spotify.app"
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// appNavEntry is a sidebar entry of custom app, linking to `Path` under
+// app route
+type appNavEntry struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Icon       string `json:"icon"`
+	ActiveIcon string `json:"active-icon"`
+}
+
+var routeRegex = regexp.MustCompile(`^[\w-]+(?:/[\w-]+)*$`)
+
+// normalizeRoute trims slashes around `route`, e.g. "/stats/" to "stats"
+func normalizeRoute(route string) string {
+	return strings.Trim(strings.TrimSpace(route), "/")
+}
+
+// routesOverlap reports whether Spotify would send URLs of one route to
+// app of the other, which happens when they are equal or one is a parent
+// of the other, since app routes match their sub paths too.
+func routesOverlap(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// getAppRoutes returns URL path of every app in `appList` in Spotify,
+// without leading slash: "route" of its manifest, or app name. Invalid
+// routes and ones that overlap route of an app listed earlier fall back to
+// app name, with a warning when `warn` is true.
+func getAppRoutes(appList []string, warn bool) map[string]string {
+	report := func(app, message string) {
+		if warn {
+			utils.PrintWarning(`Custom app "` + app + `" ` + message)
+		}
+	}
+
+	routes := map[string]string{}
+	taken := []string{}
+	owner := map[string]string{}
+	findOverlap := func(route string) string {
+		for _, other := range taken {
+			if routesOverlap(route, other) {
+				return other
+			}
+		}
+		return ""
+	}
+
+	for _, app := range appList {
+		route := app
+		if customAppPath, err := getCustomAppPath(app); err == nil {
+			_, manifestJson := readAppManifest(customAppPath)
+			if declared := normalizeRoute(manifestJson.Route); len(declared) > 0 {
+				route = declared
+			}
+		}
+
+		if !routeRegex.MatchString(route) {
+			report(app, `route "`+route+`" is invalid, use letters, digits, "-" and "_" separated by "/". "/`+app+`" is used instead.`)
+			route = app
+		}
+		if other := findOverlap(route); len(other) > 0 && route != app {
+			report(app, `route "/`+route+`" conflicts with "/`+other+`" of custom app "`+owner[other]+`". "/`+app+`" is used instead.`)
+			route = app
+		}
+		if other := findOverlap(route); len(other) > 0 {
+			report(app, `route "/`+route+`" conflicts with "/`+other+`" of custom app "`+owner[other]+`", which takes its URLs.`)
+		}
+
+		routes[app] = route
+		taken = append(taken, route)
+		owner[route] = app
+	}
+
+	return routes
+}
+
+// checkAppNav warns about sidebar entries of custom app that cannot be
+// shown: ones with invalid path and ones repeating a path
+func checkAppNav(app string, entries []appNavEntry) {
+	seen := map[string]bool{}
+	for i, entry := range entries {
+		path := normalizeRoute(entry.Path)
+		if len(path) > 0 && !routeRegex.MatchString(path) {
+			utils.PrintWarning(`Custom app "` + app + `" nav entry ` + navEntryName(i, entry) + ` is skipped: path "` + entry.Path + `" is invalid.`)
+			continue
+		}
+		if seen[path] {
+			utils.PrintWarning(`Custom app "` + app + `" nav entry ` + navEntryName(i, entry) + ` repeats path "` + path + `".`)
+		}
+		seen[path] = true
+	}
+}
+
+func navEntryName(index int, entry appNavEntry) string {
+	if len(entry.Name) > 0 {
+		return `"` + entry.Name + `"`
+	}
+	return "#" + strconv.Itoa(index+1)
+}
+
+// getAppRoutesSource lists routes of apps in `appList`, for incremental
+// apply to notice when they change
+func getAppRoutesSource(appList []string) string {
+	routes := getAppRoutes(appList, false)
+	entries := []string{}
+	for app, route := range routes {
+		entries = append(entries, app+"="+route)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "|")
+}
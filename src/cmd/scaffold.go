@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+var nonIdentifierRegex = regexp.MustCompile(`[^\w$]`)
+
+// ScaffoldExtension generates a starter extension `name` in user
+// Extensions folder: Javascript by default, module with "--mjs", or
+// TypeScript source with "--typescript". With "--register", extension is
+// also added to config "extensions".
+func ScaffoldExtension(name string) {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if len(name) == 0 || strings.ContainsAny(name, `/\`) {
+		utils.PrintError(`Invalid extension name "` + name + `".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	ext := ".js"
+	if flags.TypeScript {
+		ext = ".ts"
+	} else if flags.MJS {
+		ext = ".mjs"
+	}
+
+	fileName := name + ext
+	filePath := filepath.Join(userExtensionsFolder, fileName)
+	if _, err := os.Stat(filePath); err == nil {
+		utils.PrintError(filePath + " already exists.")
+		utils.Exit(utils.ExitFailure)
+	}
+
+	if err := os.WriteFile(filePath, []byte(getExtensionTemplate(name, ext)), 0644); err != nil {
+		utils.Fatal(err)
+	}
+	utils.PrintSuccess("Extension is created: " + filePath)
+
+	// TypeScript source needs to be compiled to Javascript to be applied
+	registerName := fileName
+	if flags.TypeScript {
+		registerName = name + ".js"
+		utils.PrintInfo(`Compile it to "` + registerName + `" in the same folder, e.g. "tsc --target es2019 ` + fileName + `".`)
+	}
+
+	if flags.Register {
+		arrayType(featureSection, "extensions", registerName)
+		cfg.Write()
+	} else {
+		utils.PrintInfo(`Run "spicetify config extensions ` + registerName + `" to enable it.`)
+	}
+}
+
+func getExtensionTemplate(name, ext string) string {
+	identifier := nonIdentifierRegex.ReplaceAllString(name, "_")
+	if identifier[0] >= '0' && identifier[0] <= '9' {
+		identifier = "_" + identifier
+	}
+
+	header := fmt.Sprintf(`// NAME: %s
+// AUTHOR:
+// DESCRIPTION:
+`, name)
+
+	example := ""
+	if ext == ".mjs" {
+		header += `
+// On apply, a comment line of form "spicetify_map{from}{to}" makes
+// spicetify replace "from" with "to" in the line right after it, e.g. to
+// follow symbols Spotify renames across versions.
+`
+		example = `
+	// On apply, "getSession" in line after "spicetify_map" below becomes
+	// "getSessionInfo". Uncomment that line to use it.
+	// spicetify_map{getSession}{getSessionInfo}
+	// const session = Spicetify.Platform.Session.getSession();
+`
+	}
+
+	platformType := ""
+	if ext == ".ts" {
+		platformType = `
+declare const Spicetify: any;
+`
+	}
+
+	return header + platformType + fmt.Sprintf(`
+(function %s() {
+	// Wait until Spicetify APIs are ready
+	if (!Spicetify.Platform) {
+		setTimeout(%s, 300);
+		return;
+	}
+%s
+	// Your code here
+})();
+`, identifier, identifier, example)
+}
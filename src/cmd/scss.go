@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/apply"
+	"github.com/khanhas/spicetify-cli/src/cache"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// scssColorModule is name themes `@use` to get color scheme as Sass
+// variables, e.g. `@use "spicetify" as *;` then `$spice-main`.
+const scssColorModule = "spicetify"
+
+var (
+	sassErrorRegex    = regexp.MustCompile(`(?m)^Error: (.*)$`)
+	sassLocationRegex = regexp.MustCompile(`(?m)^\s+(\S+\.s[ac]ss) (\d+):(\d+)`)
+)
+
+// getThemeCSS returns CSS of theme at `themeFolder`, compiled from
+// user.scss when there is one, otherwise read from user.css. When
+// compiling fails, user.css is used if theme has one.
+func getThemeCSS(themeFolder string, scheme map[string]string) string {
+	scssPath := filepath.Join(themeFolder, "user.scss")
+	if _, err := os.Stat(scssPath); err != nil {
+		return apply.ReadUserCSS(themeFolder)
+	}
+
+	css, err := compileSCSS(scssPath, scheme)
+	if err == nil {
+		return css
+	}
+
+	utils.PrintError(`Cannot compile user.scss of theme "` + filepath.Base(themeFolder) + `": ` + err.Error())
+	utils.MarkPartialFailure()
+
+	if _, err := os.Stat(filepath.Join(themeFolder, "user.css")); err == nil {
+		utils.PrintWarning("Using user.css of theme instead.")
+	}
+	return apply.ReadUserCSS(themeFolder)
+}
+
+// compileSCSS compiles `scssPath` with Dart Sass. Colors of `scheme` are
+// available in module "spicetify" as `$spice-<key>` and `$spice-rgb-<key>`.
+// Output is cached until any Sass file of theme or the colors change.
+func compileSCSS(scssPath string, scheme map[string]string) (string, error) {
+	sass, err := exec.LookPath("sass")
+	if err != nil {
+		return "", errors.New(`Sass compiler "sass" is not found. Install Dart Sass (https://sass-lang.com/install) to use user.scss.`)
+	}
+
+	colorModule := getSCSSColorModule(scheme)
+	// Modification times of all Sass files of theme, as user.scss can use
+	// any of them
+	keyParts := []string{"scss", sass, scssPath, colorModule}
+	for _, path := range getSassFiles(filepath.Dir(scssPath)) {
+		if info, err := os.Stat(path); err == nil {
+			keyParts = append(keyParts, path+"@"+info.ModTime().String())
+		}
+	}
+	key := cache.Key(keyParts...)
+	if css, ok := cache.Get(getCacheFolder(), key); ok {
+		return string(css), nil
+	}
+
+	loadPath, err := ioutil.TempDir("", "spicetify-scss-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(loadPath)
+
+	if err = ioutil.WriteFile(filepath.Join(loadPath, "_"+scssColorModule+".scss"), []byte(colorModule), 0600); err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(sass, "--no-source-map", "--style=expanded", "--load-path="+loadPath, scssPath)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err = command.Run(); err != nil {
+		return "", formatSassError(stderr.String(), err)
+	}
+
+	css := stdout.Bytes()
+	if err = cache.Put(getCacheFolder(), key, css); err != nil {
+		utils.PrintWarning("Cannot cache compiled CSS: " + err.Error())
+	}
+	return string(css), nil
+}
+
+// getSCSSColorModule returns content of module "spicetify", declaring
+// colors of `scheme`, with default colors for ones it lacks.
+func getSCSSColorModule(scheme map[string]string) string {
+	colors := apply.SchemeColors(scheme)
+	keys := []string{}
+	for k := range colors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var module strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&module, "$spice-%s: #%s;\n", k, colors[k].Hex())
+	}
+	for _, k := range keys {
+		fmt.Fprintf(&module, "$spice-rgb-%s: %s;\n", k, colors[k].RGB())
+	}
+	return module.String()
+}
+
+// getSassFiles lists Sass files in `folder`, user.scss and partials it may
+// use, sorted.
+func getSassFiles(folder string) []string {
+	files := []string{}
+	filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isSassFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+func isSassFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".scss" || ext == ".sass"
+}
+
+// formatSassError turns Sass output `stderr` into "file:line:column:
+// message", falling back to the whole output when it is not recognized.
+func formatSassError(stderr string, err error) error {
+	stderr = strings.TrimSpace(stderr)
+	message := sassErrorRegex.FindStringSubmatch(stderr)
+	location := sassLocationRegex.FindStringSubmatch(stderr)
+	if message == nil || location == nil {
+		if len(stderr) == 0 {
+			return err
+		}
+		return errors.New(stderr)
+	}
+
+	return errors.New(location[1] + ":" + location[2] + ":" + location[3] + ": " + message[1])
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/khanhas/spicetify-cli/src/apply"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// splashFileName is HTML fragment in theme folder shown while Spotify loads
+const splashFileName = "splash.html"
+
+// maxSplashSize keeps index.html from being bloated, e.g. by inlined images
+// that belong in theme "assets" folder.
+const maxSplashSize = 64 * 1024
+
+var (
+	// splashScriptRegex matches scripts and inline event handlers, as
+	// Javascript belongs in extensions
+	splashScriptRegex = regexp.MustCompile(`(?i)<\s*script\b|\son\w+\s*=|javascript:`)
+	// splashDocumentRegex matches tags that only belong in a whole document
+	splashDocumentRegex = regexp.MustCompile(`(?i)<\s*/?\s*(html|head|body|base|meta|!doctype)\b`)
+)
+
+// updateSplash writes or removes splash screen stylesheet in xpui, as
+// config "inject_splash" and current theme decide. Returns splash screen
+// HTML to inject, blank for none.
+func updateSplash() string {
+	splash := ""
+	if injectSplash {
+		utils.PrintStage(`Injecting splash screen`)
+		splash = getThemeSplash()
+	}
+
+	if err := apply.SplashStyle(getXpuiPath(), len(splash) > 0); err != nil {
+		utils.PrintError(err.Error())
+		utils.MarkPartialFailure()
+		return ""
+	}
+
+	if len(splash) > 0 {
+		utils.PrintStageDone()
+	}
+	return splash
+}
+
+// getThemeSplash returns splash screen HTML of the last theme layer that
+// has one. Blank when config "inject_splash" is off or it is invalid.
+func getThemeSplash() string {
+	if !injectSplash {
+		return ""
+	}
+
+	for i := len(themeFolders) - 1; i >= 0; i-- {
+		splashPath := filepath.Join(themeFolders[i], splashFileName)
+		content, err := os.ReadFile(splashPath)
+		if err != nil {
+			continue
+		}
+
+		if err = validateSplash(content); err != nil {
+			utils.PrintError(`Splash screen of theme "` + filepath.Base(themeFolders[i]) + `" is not injected: ` + err.Error())
+			utils.MarkPartialFailure()
+			return ""
+		}
+		return strings.TrimSpace(string(content))
+	}
+
+	return ""
+}
+
+// validateSplash checks splash screen `content` is a small HTML fragment
+// without Javascript
+func validateSplash(content []byte) error {
+	if len(content) > maxSplashSize {
+		return errors.New(splashFileName + " is larger than " + strconv.Itoa(maxSplashSize/1024) + " KB. Put images in theme \"assets\" folder and link them instead.")
+	}
+	if !utf8.Valid(content) {
+		return errors.New(splashFileName + " is not UTF-8 text")
+	}
+	if len(strings.TrimSpace(string(content))) == 0 {
+		return errors.New(splashFileName + " is empty")
+	}
+	if splashScriptRegex.Match(content) {
+		return errors.New(splashFileName + " contains Javascript, which is not allowed. Use an extension instead.")
+	}
+	if match := splashDocumentRegex.FindSubmatch(content); match != nil {
+		return errors.New(splashFileName + ` must be an HTML fragment, without "<` + strings.ToLower(string(match[1])) + `>" tag`)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// applyStage is a step of full apply. Stages named in `after` must finish
+// before it starts, e.g. custom assets run after themed assets so that they
+// overwrite them, not the other way around. Stages with no dependency path
+// between them are independent and may run in any order, or concurrently.
+type applyStage struct {
+	name  string
+	after []string
+	run   func()
+}
+
+// orderStages returns `stages` so that every stage comes after the ones it
+// depends on. Independent stages keep their declaration order, so output
+// stays the same from run to run.
+func orderStages(stages []applyStage) ([]applyStage, error) {
+	index := map[string]int{}
+	for i, stage := range stages {
+		if _, ok := index[stage.name]; ok {
+			return nil, errors.New(`apply stage "` + stage.name + `" is declared twice`)
+		}
+		index[stage.name] = i
+	}
+
+	for _, stage := range stages {
+		for _, dep := range stage.after {
+			if _, ok := index[dep]; !ok {
+				return nil, errors.New(`apply stage "` + stage.name + `" depends on unknown stage "` + dep + `"`)
+			}
+		}
+	}
+
+	ordered := []applyStage{}
+	done := map[string]bool{}
+	for len(ordered) < len(stages) {
+		progressed := false
+		for _, stage := range stages {
+			if done[stage.name] || !stageReady(stage, done) {
+				continue
+			}
+			ordered = append(ordered, stage)
+			done[stage.name] = true
+			progressed = true
+			// Restart from first stage to keep declaration order
+			break
+		}
+
+		if !progressed {
+			pending := []string{}
+			for _, stage := range stages {
+				if !done[stage.name] {
+					pending = append(pending, stage.name)
+				}
+			}
+			return nil, errors.New("apply stages depend on each other in a cycle: " + strings.Join(pending, ", "))
+		}
+	}
+
+	return ordered, nil
+}
+
+func stageReady(stage applyStage, done map[string]bool) bool {
+	for _, dep := range stage.after {
+		if !done[dep] {
+			return false
+		}
+	}
+	return true
+}
+
+// runStages runs `stages` one by one, honoring their dependencies. With
+// "--keep-going", a stage that fails does not stop the run: stages that
+// depend on it are skipped and the rest still run. Returns problems of
+// failed and skipped stages.
+//
+// There is no option to run independent stages concurrently: stages print
+// progress lines and share state that is not synchronized, like warning
+// count and Fatal catching, so such option could not be safe yet.
+func runStages(stages []applyStage) []string {
+	ordered, err := orderStages(stages)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	failed := map[string]bool{}
+	problems := []string{}
+	for _, stage := range ordered {
+		if !flags.KeepGoing {
+			stage.run()
+			continue
+		}
+
+		if dep := failedDependency(stage, failed); len(dep) > 0 {
+			failed[stage.name] = true
+			problems = append(problems, `Stage "`+stage.name+`" is skipped because stage "`+dep+`" failed.`)
+			continue
+		}
+
+		if err := utils.CatchFatal(stage.run); err != nil {
+			failed[stage.name] = true
+			problems = append(problems, `Stage "`+stage.name+`" failed: `+err.Error())
+		}
+	}
+
+	return problems
+}
+
+// failedDependency returns name of a stage `stage` depends on that failed,
+// or blank if none did
+func failedDependency(stage applyStage, failed map[string]bool) string {
+	for _, dep := range stage.after {
+		if failed[dep] {
+			return dep
+		}
+	}
+	return ""
+}
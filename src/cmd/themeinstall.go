@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// githubRepoRegex matches GitHub repository URL, optionally pointing to a
+// folder of a branch, e.g. "https://github.com/owner/repo/tree/main/Theme"
+var githubRepoRegex = regexp.MustCompile(`^https?://github\.com/([^/]+)/([^/#?]+?)(?:\.git)?(?:/tree/([^/]+)(?:/(.+?))?)?/?$`)
+
+// themeContent summarizes files of a theme to install
+type themeContent struct {
+	css     []string
+	schemes []string
+	assets  int
+	scripts []string
+	other   int
+}
+
+// InstallTheme downloads theme from `source`, shows what it contains and,
+// once user confirms, installs it to user Themes folder as `name`, or a
+// name derived from `source` if blank. `source` is a ".spicetify" package
+// or zip archive (URL or local file), a GitHub repository or folder URL or
+// "gh:owner/repo[/folder][@ref]" shorthand, a git repository URL or a local
+// folder.
+func InstallTheme(source, name string) {
+	temp, err := ioutil.TempDir("", "spicetify-theme-")
+	if err != nil {
+		utils.Fatal(err)
+	}
+	defer os.RemoveAll(temp)
+
+	root, subPath, defaultName, err := fetchTheme(source, temp)
+	if err != nil {
+		utils.PrintError("Cannot get theme: " + err.Error())
+		utils.Exit(utils.ExitFailure)
+	}
+
+	if len(subPath) > 0 {
+		root = filepath.Join(root, filepath.FromSlash(subPath))
+		defaultName = path.Base(subPath)
+	}
+
+	themeRoot, err := findThemeRoot(root)
+	if err != nil {
+		utils.PrintError(err.Error())
+		utils.Exit(utils.ExitFailure)
+	}
+	if themeRoot != root {
+		defaultName = filepath.Base(themeRoot)
+	}
+
+	if len(name) == 0 {
+		name = defaultName
+	}
+	if len(name) == 0 || name == "." || name == ".." || strings.ContainsAny(name, `/\:`) {
+		utils.PrintError(`Invalid theme name "` + name + `".`)
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	dest := filepath.Join(userThemesFolder, name)
+	if _, err := os.Stat(dest); err == nil && !flags.Force {
+		utils.PrintError(`Theme "` + name + `" is already installed. Use "--force" to replace it, or give another name.`)
+		utils.Exit(utils.ExitFailure)
+	}
+
+	content := inspectTheme(themeRoot)
+	printThemeContent(name, content)
+
+	if containsString(content.scripts, themeJSFileName) {
+		utils.PrintWarning("Theme contains " + themeJSFileName + ", which runs automatically inside Spotify, with access to your account, whenever theme is applied. Only install it if you trust its author.")
+	} else if len(content.scripts) > 0 {
+		utils.PrintWarning("Theme contains Javascript. It does not run by itself, but if theme asks you to add it as extension, it runs inside Spotify with access to your account. Only do so if you trust its author.")
+	}
+
+	// Quiet mode only installs themes with Javascript when forced
+	if !ReadAnswer(`Install theme "`+name+`"? [y/N] `, false, len(content.scripts) == 0 || flags.Force) {
+		if quiet {
+			utils.PrintError(`Theme "` + name + `" contains Javascript, which is only installed in quiet mode with "--force".`)
+		} else {
+			utils.PrintInfo("Theme is not installed.")
+		}
+		utils.Exit(utils.ExitFailure)
+	}
+
+	if err = os.RemoveAll(dest); err != nil {
+		utils.Fatal(err)
+	}
+	if err = utils.Copy(themeRoot, dest, true, nil); err != nil {
+		utils.Fatal(err)
+	}
+	os.RemoveAll(filepath.Join(dest, ".git"))
+
+	utils.PrintSuccess(`Theme "` + name + `" is installed to ` + dest)
+	utils.PrintInfo(`Run "spicetify config current_theme ` + name + `" and "spicetify apply" to use it.`)
+}
+
+// fetchTheme downloads or extracts `source` into `temp`. Returns folder
+// holding its content, folder inside it that theme is in (from GitHub
+// folder URL) and a theme name derived from `source`.
+func fetchTheme(source, temp string) (string, string, string, error) {
+	extracted := filepath.Join(temp, "content")
+
+	if info, err := os.Stat(source); err == nil {
+		name := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+		if info.IsDir() {
+			return source, "", filepath.Base(source), nil
+		}
+		return singleFolder(extracted), "", name, utils.Unzip(source, extracted)
+	}
+
+	var repo *githubSource
+	if isGitHubShorthand(source) {
+		parsed, err := parseGitHubShorthand(source)
+		if err != nil {
+			return "", "", "", err
+		}
+		repo = &parsed
+	} else if match := githubRepoRegex.FindStringSubmatch(source); match != nil {
+		repo = &githubSource{owner: match[1], repo: match[2], ref: match[3], path: match[4]}
+	}
+	if repo != nil {
+		content, err := fetchGitHub(repo.archiveURL(), *repo)
+		if err == nil {
+			err = extractArchive(content, temp, extracted)
+		}
+		if err != nil {
+			return "", "", "", err
+		}
+		return singleFolder(extracted), repo.path, repo.repo, nil
+	}
+
+	parsed, err := url.Parse(source)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return "", "", "", errors.New(`"` + source + `" is neither a URL nor an existing file or folder`)
+	}
+
+	ext := path.Ext(parsed.Path)
+	name := strings.TrimSuffix(path.Base(parsed.Path), ext)
+	switch ext {
+	case ".git":
+		git, err := exec.LookPath("git")
+		if err != nil {
+			return "", "", "", errors.New("git is required to install from git repository")
+		}
+		output, err := exec.Command(git, "clone", "--depth", "1", source, extracted).CombinedOutput()
+		if err != nil {
+			return "", "", "", errors.New(strings.TrimSpace(string(output)))
+		}
+		removeSymlinks(extracted)
+		return extracted, "", name, nil
+
+	case ".zip", ".spicetify":
+		if err := downloadArchive(source, temp, extracted); err != nil {
+			return "", "", "", err
+		}
+		return singleFolder(extracted), "", name, nil
+	}
+
+	return "", "", "", errors.New(`unsupported URL, expected a ".spicetify" package, zip archive, GitHub repository, "gh:owner/repo" or git repository`)
+}
+
+// downloadArchive downloads zip archive at `archiveURL` and extracts it to
+// `dest`
+func downloadArchive(archiveURL, temp, dest string) error {
+	utils.PrintInfo("Downloading " + archiveURL)
+	content, err := fetchCached(archiveURL)
+	if err != nil {
+		return err
+	}
+
+	return extractArchive(content, temp, dest)
+}
+
+// extractArchive extracts zip archive `content` to `dest`
+func extractArchive(content []byte, temp, dest string) error {
+	archive := filepath.Join(temp, "theme.zip")
+	if err := ioutil.WriteFile(archive, content, 0600); err != nil {
+		return err
+	}
+
+	return utils.Unzip(archive, dest)
+}
+
+// singleFolder returns the only folder in `folder` if it has nothing else,
+// as archives usually wrap their content in one, otherwise `folder`.
+func singleFolder(folder string) string {
+	entries, err := ioutil.ReadDir(folder)
+	if err == nil && len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(folder, entries[0].Name())
+	}
+	return folder
+}
+
+// isThemeFolder reports whether `folder` has theme files
+func isThemeFolder(folder string) bool {
+	for _, name := range []string{"user.css", "user.scss", "color.ini"} {
+		if _, err := os.Stat(filepath.Join(folder, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findThemeRoot returns `root` if it is a theme, otherwise the only theme
+// folder found inside it.
+func findThemeRoot(root string) (string, error) {
+	if isThemeFolder(root) {
+		return root, nil
+	}
+
+	found := []string{}
+	filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if isThemeFolder(filePath) {
+			found = append(found, filePath)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	switch len(found) {
+	case 0:
+		return "", errors.New(`No theme is found: a theme has "user.css", "user.scss" or "color.ini".`)
+	case 1:
+		return found[0], nil
+	}
+
+	names := []string{}
+	for _, folder := range found {
+		rel, _ := filepath.Rel(root, folder)
+		names = append(names, filepath.ToSlash(rel))
+	}
+	sort.Strings(names)
+	return "", errors.New("Multiple themes are found: " + strings.Join(names, ", ") + ". Use URL or path of one theme folder.")
+}
+
+// removeSymlinks deletes symlinks in cloned repository at `folder`, so
+// installing cannot copy files from outside of it.
+func removeSymlinks(folder string) {
+	filepath.Walk(folder, func(filePath string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode()&os.ModeSymlink != 0 {
+			utils.PrintWarning(`Symlink "` + filepath.Base(filePath) + `" is skipped.`)
+			os.Remove(filePath)
+		}
+		return nil
+	})
+}
+
+// inspectTheme lists what theme at `folder` contains
+func inspectTheme(folder string) themeContent {
+	content := themeContent{}
+
+	filepath.Walk(folder, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, _ := filepath.Rel(folder, filePath)
+		rel = filepath.ToSlash(rel)
+
+		switch ext := strings.ToLower(filepath.Ext(rel)); {
+		case ext == ".js" || ext == ".mjs" || ext == ".ts":
+			content.scripts = append(content.scripts, rel)
+		case strings.HasPrefix(rel, "assets/"):
+			content.assets++
+		case ext == ".css" || isSassFile(rel):
+			content.css = append(content.css, rel)
+		case rel != "color.ini":
+			content.other++
+		}
+		return nil
+	})
+
+	if colors, err := ini.InsensitiveLoad(filepath.Join(folder, "color.ini")); err == nil {
+		for _, section := range colors.Sections() {
+			if !strings.EqualFold(section.Name(), ini.DefaultSection) {
+				content.schemes = append(content.schemes, section.Name())
+			}
+		}
+	}
+
+	return content
+}
+
+func printThemeContent(name string, content themeContent) {
+	list := func(items []string) string {
+		if len(items) == 0 {
+			return "none"
+		}
+		return strings.Join(items, ", ")
+	}
+
+	utils.PrintBold(`Theme "` + name + `" contains:`)
+	log.Println("    CSS:           " + list(content.css))
+	log.Println("    Color schemes: " + list(content.schemes))
+	log.Println("    Assets:        " + strconv.Itoa(content.assets) + " files")
+	if len(content.scripts) > 0 {
+		log.Println("    Javascript:    " + utils.Yellow(list(content.scripts)))
+	} else {
+		log.Println("    Javascript:    none")
+	}
+	log.Println("    Other:         " + strconv.Itoa(content.other) + " files")
+}
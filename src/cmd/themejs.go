@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/apply"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// themeJSFileName is script in theme folder that runs in Spotify while
+// theme is current, for what CSS alone cannot do
+const themeJSFileName = "theme.js"
+
+// getThemeJS returns theme.js of every current theme layer, in layer
+// order, each in its own function scope. Blank when none has one.
+func getThemeJS() string {
+	var script strings.Builder
+	for _, folder := range themeFolders {
+		path := filepath.Join(folder, themeJSFileName)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err == nil {
+			err = checkExtensionFile(path)
+		}
+		if err != nil {
+			utils.PrintWarning(themeJSFileName + ` of theme "` + filepath.Base(folder) + `" is skipped: ` + err.Error())
+			utils.MarkPartialFailure()
+			continue
+		}
+
+		script.WriteString("// " + themeJSFileName + ` of theme "` + filepath.Base(folder) + "\"\n")
+		script.WriteString("(function () {\n" + strings.TrimRight(string(content), "\r\n") + "\n})();\n")
+	}
+
+	return script.String()
+}
+
+// updateThemeJS writes script of current themes to xpui, or removes it when
+// no current theme has one, e.g. after switching themes. Returns whether
+// there is a script to link in index.html.
+func updateThemeJS() bool {
+	script := getThemeJS()
+	if len(script) > 0 {
+		utils.PrintStage(`Transferring ` + themeJSFileName)
+	}
+
+	if err := apply.ThemeScript(getXpuiPath(), script); err != nil {
+		utils.PrintError(err.Error())
+		utils.MarkPartialFailure()
+		return false
+	}
+
+	if len(script) > 0 {
+		utils.PrintStageDone()
+	}
+	return len(script) > 0
+}
+
+// hashThemeJSSources returns checksum of theme.js of current themes
+func hashThemeJSSources() string {
+	sources := []string{}
+	for _, folder := range themeFolders {
+		sources = append(sources, filepath.Join(folder, themeJSFileName))
+	}
+	return hashSources(sources...)
+}
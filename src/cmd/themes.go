@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// themePreviewFiles lists file names a theme preview image can have, in
+// order of preference.
+var themePreviewFiles = []string{"preview.png", "screenshot.png"}
+
+type themeInfo struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Preview string `json:"preview"`
+	Current bool   `json:"current"`
+}
+
+// ListThemes prints installed themes, from user Themes folder and Themes
+// folder next to spicetify executable. Output is JSON when "--json" is used.
+func ListThemes() {
+	themes := getThemes()
+
+	if flags.JSON {
+		out, err := json.MarshalIndent(themes, "", "  ")
+		if err != nil {
+			utils.Fatal(err)
+		}
+		log.Println(string(out))
+		return
+	}
+
+	for _, theme := range themes {
+		name := theme.Name
+		if theme.Current {
+			name = utils.Green(name + " *")
+		}
+		log.Println(name)
+	}
+}
+
+// ThemePreview prints path of preview image of theme `name`, or copies it
+// to `output` when given. `output` can be a folder or a file path.
+func ThemePreview(name, output string) {
+	folder, err := findThemeFolder(name)
+	if err != nil {
+		utils.PrintError(err.Error())
+		utils.Exit(utils.ExitConfigError)
+	}
+
+	preview := getThemePreview(folder)
+	if len(preview) == 0 {
+		utils.PrintError(`Theme "` + name + `" has no preview image. Expected one of: ` + joinQuoted(themePreviewFiles))
+		utils.Exit(utils.ExitFailure)
+	}
+
+	if len(output) == 0 {
+		log.Println(preview)
+		return
+	}
+
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		output = filepath.Join(output, filepath.Base(preview))
+	}
+
+	content, err := os.ReadFile(preview)
+	if err != nil {
+		utils.Fatal(err)
+	}
+
+	if err = os.WriteFile(output, content, 0644); err != nil {
+		utils.Fatal(err)
+	}
+
+	utils.PrintSuccess(`Preview of theme "` + name + `" is copied to ` + output)
+}
+
+// getThemes returns installed themes sorted by name. A user theme hides
+// bundled theme of the same name, same as when resolving "current_theme".
+func getThemes() []themeInfo {
+	current := map[string]bool{}
+	for _, name := range utils.ListValues(settingSection.Key("current_theme")) {
+		current[name] = true
+	}
+
+	found := map[string]bool{}
+	themes := []themeInfo{}
+	for _, dir := range append([]string{userThemesFolder}, utils.GetBundledPaths("Themes")...) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || found[name] {
+				continue
+			}
+			found[name] = true
+
+			folder := filepath.Join(dir, name)
+			themes = append(themes, themeInfo{
+				Name:    name,
+				Path:    folder,
+				Preview: getThemePreview(folder),
+				Current: current[name],
+			})
+		}
+	}
+
+	sort.Slice(themes, func(i, j int) bool {
+		return themes[i].Name < themes[j].Name
+	})
+
+	return themes
+}
+
+// getThemePreview returns path of preview image in theme `folder`, or blank
+// string if there is none.
+func getThemePreview(folder string) string {
+	for _, file := range themePreviewFiles {
+		preview := filepath.Join(folder, file)
+		if info, err := os.Stat(preview); err == nil && !info.IsDir() {
+			return preview
+		}
+	}
+
+	return ""
+}
+
+func joinQuoted(list []string) string {
+	quoted := ""
+	for i, item := range list {
+		if i > 0 {
+			quoted += ", "
+		}
+		quoted += `"` + item + `"`
+	}
+	return quoted
+}
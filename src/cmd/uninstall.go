@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/backup"
+	backupstatus "github.com/khanhas/spicetify-cli/src/status/backup"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// Uninstall brings Spotify back to stock, from backup if it matches current
+// Spotify version, otherwise by removing files apply added (recorded in
+// install manifest). With `purge`, spicetify folder holding config,
+// backup, cache and user Themes, Extensions and CustomApps is deleted too,
+// after confirmation. Spotify user data is never touched.
+func Uninstall(purge bool) {
+	removed := []string{}
+
+	backupVersion := getBackupVersion()
+	backStat := backupstatus.Get(prefsPath, backupFolder, backupVersion)
+	restored := false
+
+	if backStat.IsBackuped() {
+		if damaged := backup.Verify(backupFolder); len(damaged) > 0 {
+			utils.PrintWarning("Backup is damaged, not restoring from it. These files do not match what was backed up: " + strings.Join(damaged, ", "))
+		} else {
+			utils.PrintBold(`Restoring Spotify from backup:`)
+			restoreApps()
+			utils.PrintGreen("OK")
+			restoreLauncher()
+			removed = append(removed, "Spotify Apps folder restored from backup: "+appDestPath)
+			restored = true
+		}
+	} else if backStat.IsOutdated() {
+		utils.PrintWarning("Backup is of another Spotify version, not restoring from it.")
+	}
+
+	if !restored {
+		removed = append(removed, removeInstalledFiles()...)
+	}
+
+	for _, state := range []string{getSourceManifestPath(), getInstallManifestPath(), getPrePatchFolder(), getPatchStatePath()} {
+		if _, err := os.Stat(state); err == nil {
+			if err := os.RemoveAll(state); err != nil {
+				utils.PrintError(err.Error())
+				utils.MarkPartialFailure()
+				continue
+			}
+			removed = append(removed, state)
+		}
+	}
+
+	if purge {
+		if err := checkPurgeFolder(spicetifyFolder); err != nil {
+			utils.PrintError(`Not deleting ` + spicetifyFolder + `: ` + err.Error())
+			utils.MarkPartialFailure()
+		} else if purgeSpicetifyFolder() {
+			removed = append(removed, spicetifyFolder)
+		}
+	}
+
+	if len(removed) == 0 {
+		utils.PrintInfo("Nothing to remove.")
+	} else {
+		utils.PrintBold("Removed:")
+		for _, item := range removed {
+			log.Println("    " + item)
+		}
+	}
+
+	if !purge {
+		utils.PrintInfo(`Config, backup and user Themes, Extensions and CustomApps are kept in ` + spicetifyFolder + `. Use "--purge" to delete them too.`)
+	}
+	utils.PrintSuccess("Spicetify is uninstalled.")
+}
+
+// removeInstalledFiles removes files apply added to xpui, according to
+// install manifest, leaving files that changed since. Stock files apply
+// modified cannot be brought back without backup, so they are only reported.
+func removeInstalledFiles() []string {
+	manifest := readInstallManifest()
+	if manifest == nil {
+		utils.PrintWarning("There is neither a usable backup nor a record of applied files. Reinstall Spotify to bring it back to stock.")
+		utils.MarkPartialFailure()
+		return nil
+	}
+
+	xpuiPath := manifest.XpuiPath
+	if !isWithin(xpuiPath, appDestPath) {
+		utils.PrintWarning("Recorded frontend folder " + xpuiPath + " is not in Spotify Apps folder " + appDestPath + ", leaving it untouched.")
+		utils.MarkPartialFailure()
+		return nil
+	}
+
+	utils.PrintBold(`Removing applied files:`)
+	names := []string{}
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	removed := []string{}
+	modified := []string{}
+	for _, name := range names {
+		file := manifest.Files[name]
+		path := filepath.Join(xpuiPath, filepath.FromSlash(name))
+		if !isWithin(path, xpuiPath) {
+			continue
+		}
+
+		if file.Status != "added" {
+			modified = append(modified, name)
+			continue
+		}
+
+		checksum, err := utils.FileChecksum(path)
+		if err != nil {
+			continue
+		}
+		if checksum != file.Checksum {
+			utils.PrintWarning(`"` + name + `" changed since apply, leaving it in place.`)
+			continue
+		}
+
+		if err = os.Remove(path); err != nil {
+			utils.PrintError(err.Error())
+			utils.MarkPartialFailure()
+			continue
+		}
+		removed = append(removed, path)
+	}
+
+	nodeModules := filepath.Join(xpuiPath, "node_modules")
+	if info, err := os.Lstat(nodeModules); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if os.Remove(nodeModules) == nil {
+			removed = append(removed, nodeModules)
+		}
+	}
+	utils.PrintGreen("OK")
+
+	if len(modified) > 0 {
+		utils.PrintWarning("These Spotify files are still modified, reinstall Spotify to bring them back to stock: " + strings.Join(modified, ", "))
+		utils.MarkPartialFailure()
+	}
+
+	return removed
+}
+
+// checkPurgeFolder refuses to delete `folder` unless it looks like a
+// spicetify folder that holds neither home folder, nor Spotify or its data.
+func checkPurgeFolder(folder string) error {
+	folder = filepath.Clean(folder)
+	if !filepath.IsAbs(folder) || filepath.Dir(folder) == folder {
+		return errors.New("it is not a valid spicetify folder")
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, "config-xpui.ini")); err != nil {
+		return errors.New("it has no config-xpui.ini, so it may not be a spicetify folder")
+	}
+
+	if home, err := os.UserHomeDir(); err == nil && isWithin(home, folder) {
+		return errors.New("it contains home folder")
+	}
+
+	protected := []string{spotifyPath, appDestPath}
+	if len(prefsPath) > 0 {
+		// Spotify keeps user data next to prefs
+		protected = append(protected, filepath.Dir(prefsPath))
+	}
+	for _, path := range protected {
+		if len(path) > 0 && (isWithin(path, folder) || isWithin(folder, path)) {
+			return errors.New("it overlaps with Spotify folder " + path)
+		}
+	}
+
+	return nil
+}
+
+// purgeSpicetifyFolder deletes spicetify folder once user confirms
+func purgeSpicetifyFolder() bool {
+	userItems := 0
+	for _, folder := range []string{userThemesFolder, userExtensionsFolder, userAppsFolder} {
+		entries, _ := os.ReadDir(folder)
+		userItems += len(entries)
+	}
+
+	utils.PrintWarning("This deletes " + spicetifyFolder + ", including config, backup, cache and user Themes, Extensions and CustomApps.")
+	if userItems > 0 {
+		utils.PrintWarning("Your own Themes, Extensions and CustomApps folders are not empty. Copy anything you want to keep first.")
+	}
+
+	if !ReadAnswer("Delete "+spicetifyFolder+"? [y/N] ", false, false) {
+		utils.PrintInfo(spicetifyFolder + " is kept.")
+		return false
+	}
+
+	// Open lock file would keep Windows from deleting the folder
+	ReleaseLock()
+	if err := os.RemoveAll(spicetifyFolder); err != nil {
+		utils.PrintError(err.Error())
+		utils.MarkPartialFailure()
+		return false
+	}
+
+	return true
+}
+
+// isWithin reports whether `path` is `folder` or inside it
+func isWithin(path, folder string) bool {
+	rel, err := filepath.Rel(filepath.Clean(folder), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
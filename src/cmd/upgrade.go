@@ -3,8 +3,6 @@ package cmd
 import (
 	"encoding/json"
 	"io"
-	"io/ioutil"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -27,6 +25,8 @@ func Upgrade(currentVersion string) {
 	}
 	utils.PrintGreen("OK")
 
+	refreshCompatibilityIndex()
+
 	utils.PrintInfo("Current version: " + currentVersion)
 	utils.PrintInfo("Latest release: " + tagName)
 	if currentVersion == tagName {
@@ -56,14 +56,15 @@ func Upgrade(currentVersion string) {
 	}
 	defer out.Close()
 
-	resp2, err := http.Get(assetURL)
+	resp2, err := utils.HTTPGet(assetURL)
 	if err != nil {
 		utils.Fatal(err)
 	}
+	defer resp2.Body.Close()
 
 	_, err = io.Copy(out, resp2.Body)
 	if err != nil {
-		utils.Fatal(err)
+		utils.Fatal(utils.WrapTimeout(err))
 	}
 	utils.PrintGreen("OK")
 
@@ -106,12 +107,7 @@ func permissionError(err error) {
 }
 
 func FetchLatestTag() (string, error) {
-	res, err := http.Get("https://api.github.com/repos/khanhas/spicetify-cli/releases/latest")
-	if err != nil {
-		return "", err
-	}
-
-	body, err := ioutil.ReadAll(res.Body)
+	body, err := utils.FetchURL("https://api.github.com/repos/khanhas/spicetify-cli/releases/latest")
 	if err != nil {
 		return "", err
 	}
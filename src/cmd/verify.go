@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/apply"
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// verifyApply checks that files of every extension and custom app in
+// `extensionList` and `appList` exist in xpui with non-zero size and that
+// user.css is the one spicetify generated. It catches files that were
+// misplaced or clobbered by later stages, e.g. theme assets overwriting.
+func verifyApply(extensionList, appList []string) {
+	utils.PrintBold(`Verifying:`)
+	xpuiPath := getXpuiPath()
+	problems := 0
+
+	report := func(path, reason string) {
+		utils.PrintWarning(path + ": " + reason)
+		problems++
+	}
+
+	checkFile := func(path string) {
+		info, err := os.Stat(path)
+		if err != nil {
+			report(path, "file is missing")
+		} else if info.IsDir() {
+			report(path, "expected a file, found a folder")
+		} else if info.Size() == 0 {
+			report(path, "file is empty")
+		}
+	}
+
+	for _, ext := range extensionList {
+		extPath := ext
+		if !filepath.IsAbs(ext) {
+			extPath, _ = getExtensionPath(ext)
+		}
+		if ok, _ := isExtensionForPlatform(extPath); !ok {
+			continue
+		}
+		checkFile(filepath.Join(xpuiPath, getExtensionFileName(filepath.Base(ext), extPath)))
+	}
+
+	for _, app := range appList {
+		checkFile(filepath.Join(xpuiPath, "spicetify-routes-"+app+".js"))
+		checkFile(filepath.Join(xpuiPath, "spicetify-routes-"+app+".json"))
+	}
+
+	for _, chunkID := range getAppChunkIDs(appList) {
+		checkFile(filepath.Join(xpuiPath, chunkID+".js"))
+	}
+
+	cssPath := filepath.Join(xpuiPath, "user.css")
+	if content, err := os.ReadFile(cssPath); err != nil {
+		report(cssPath, "file is missing")
+	} else if !strings.HasPrefix(string(content), apply.UserCSSMarker) {
+		report(cssPath, "file was not generated by spicetify or was overwritten")
+	}
+
+	if problems > 0 {
+		utils.MarkPartialFailure()
+		return
+	}
+
+	utils.PrintGreen("OK")
+}
+
+// Verify checks, without changing anything, that every file recorded in
+// install manifest of last apply is still in xpui with the same checksum.
+// Missing or modified files are listed and make it exit with
+// ExitReverted, so "apply --repair" can bring them back.
+func Verify() {
+	manifest := readInstallManifest()
+	xpuiPath := getXpuiPath()
+	if manifest == nil || manifest.XpuiPath != xpuiPath {
+		utils.PrintError(`There is no record of applied files. Run "spicetify apply" first.`)
+		utils.Exit(utils.ExitFailure)
+	}
+	if _, err := os.Stat(xpuiPath); err != nil {
+		utils.PrintError(`Spotify frontend folder is gone, probably after Spotify update. Run "spicetify backup apply".`)
+		utils.Exit(utils.ExitReverted)
+	}
+
+	if version := utils.GetInstalledSpotifyVersion(prefsPath); len(manifest.SpotifyVersion) > 0 && version != manifest.SpotifyVersion {
+		utils.PrintWarning("Spotify version is " + version + ", last apply was to " + manifest.SpotifyVersion + ".")
+	}
+
+	missing, modified := getDriftedFiles(manifest, xpuiPath)
+	total := strconv.Itoa(len(manifest.Files))
+	if len(missing) == 0 && len(modified) == 0 {
+		utils.PrintSuccess("All " + total + " applied files match last apply.")
+		return
+	}
+
+	for _, group := range []struct {
+		title string
+		names []string
+	}{{"Missing files:", missing}, {"Modified files:", modified}} {
+		if len(group.names) == 0 {
+			continue
+		}
+		utils.PrintBold(group.title)
+		for _, name := range group.names {
+			log.Println("    " + name + " (" + manifest.Files[name].Kind + ")")
+		}
+	}
+
+	utils.PrintError(strconv.Itoa(len(missing)+len(modified)) + " of " + total + " applied files are missing or modified.")
+	utils.PrintInfo(`Run "spicetify apply --repair" to bring them back.`)
+	utils.Exit(utils.ExitReverted)
+}
+
+// getDriftedFiles returns files of install `manifest` that are missing from
+// `xpuiPath` or whose checksum changed since, both sorted
+func getDriftedFiles(manifest *installManifest, xpuiPath string) ([]string, []string) {
+	missing := []string{}
+	modified := []string{}
+	for name, file := range manifest.Files {
+		path := filepath.Join(xpuiPath, filepath.FromSlash(name))
+		if _, err := os.Stat(path); err != nil {
+			missing = append(missing, name)
+		} else if checksum, err := utils.FileChecksum(path); err != nil || checksum != file.Checksum {
+			modified = append(modified, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(modified)
+	return missing, modified
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"os"
 	"path/filepath"
 	"time"
@@ -18,7 +17,7 @@ var (
 // Watch .
 func Watch(liveUpdate bool) {
 	if !isValidForWatching() {
-		os.Exit(1)
+		utils.Exit(1)
 	}
 
 	InitSetting()
@@ -29,33 +28,39 @@ func Watch(liveUpdate bool) {
 
 	if len(themeFolder) == 0 {
 		utils.PrintError(`Config "current_theme" is blank. No theme asset to watch.`)
-		os.Exit(1)
+		utils.Exit(1)
 	}
 
-	colorPath := filepath.Join(themeFolder, "color.ini")
-	cssPath := filepath.Join(themeFolder, "user.css")
-
 	fileList := []string{}
-	if replaceColors {
-		fileList = append(fileList, colorPath)
-	}
+	for _, folder := range themeFolders {
+		colorPath := filepath.Join(folder, "color.ini")
+		cssPath := filepath.Join(folder, "user.css")
 
-	if injectCSS {
-		fileList = append(fileList, cssPath)
-	}
+		if _, err := os.Stat(colorPath); err == nil && replaceColors {
+			fileList = append(fileList, colorPath)
+		}
+
+		if _, err := os.Stat(cssPath); err == nil && injectCSS {
+			fileList = append(fileList, cssPath)
+		}
+
+		if injectCSS {
+			fileList = append(fileList, getSassFiles(folder)...)
+		}
 
-	if overwriteAssets {
-		assetPath := filepath.Join(themeFolder, "assets")
+		if overwriteAssets {
+			assetPath := filepath.Join(folder, "assets")
 
-		if _, err := os.Stat(assetPath); err == nil {
-			go utils.WatchRecursive(assetPath, func(_ string, err error) {
-				if err != nil {
-					utils.Fatal(err)
-				}
-				
-				updateAssets()
-				utils.PrintSuccess(utils.PrependTime("Custom assets are updated"))
-			}, autoReloadFunc)
+			if _, err := os.Stat(assetPath); err == nil {
+				go utils.WatchRecursive(assetPath, func(_ string, err error) {
+					if err != nil {
+						utils.Fatal(err)
+					}
+
+					updateAssets()
+					utils.PrintSuccess(utils.PrependTime("Custom assets are updated"))
+				}, autoReloadFunc)
+			}
 		}
 	}
 
@@ -73,7 +78,7 @@ func Watch(liveUpdate bool) {
 // WatchExtensions .
 func WatchExtensions(extName []string, liveUpdate bool) {
 	if !isValidForWatching() {
-		os.Exit(1)
+		utils.Exit(1)
 	}
 
 	if liveUpdate {
@@ -84,10 +89,13 @@ func WatchExtensions(extName []string, liveUpdate bool) {
 	if len(extName) > 0 {
 		extNameList = extName
 	} else {
-		extNameList = featureSection.Key("extensions").Strings("|")
+		extNameList = getExtensionList()
 	}
 
 	var extPathList []string
+	// Changing extension stylesheet, or entry or subfile of folder
+	// extension, re-pushes its extension
+	owners := map[string]string{}
 
 	for _, v := range extNameList {
 		extPath, err := getExtensionPath(v)
@@ -95,18 +103,36 @@ func WatchExtensions(extName []string, liveUpdate bool) {
 			utils.PrintError(`Extension "` + v + `" not found.`)
 			continue
 		}
-		extPathList = append(extPathList, extPath)
+		sources, err := getExtensionSources(extPath)
+		if err != nil {
+			utils.PrintError(`Extension "` + v + `": ` + err.Error())
+			continue
+		}
+		for _, source := range sources {
+			extPathList = append(extPathList, source)
+			owners[source] = extPath
+		}
+
+		cssPath := getExtensionCSSPath(extPath)
+		if _, err := os.Stat(cssPath); err == nil {
+			extPathList = append(extPathList, cssPath)
+			owners[cssPath] = extPath
+		}
 	}
 
 	if len(extPathList) == 0 {
 		utils.PrintError("No extension to watch.")
-		os.Exit(1)
+		utils.Exit(1)
 	}
 
 	utils.Watch(extPathList, func(filePath string, err error) {
 		if err != nil {
 			utils.PrintError(err.Error())
-			os.Exit(1)
+			utils.Exit(1)
+		}
+
+		if extPath, ok := owners[filePath]; ok {
+			filePath = extPath
 		}
 
 		pushExtensions(filePath)
@@ -118,7 +144,7 @@ func WatchExtensions(extName []string, liveUpdate bool) {
 // WatchCustomApp .
 func WatchCustomApp(appName []string, liveUpdate bool) {
 	if !isValidForWatching() {
-		os.Exit(1)
+		utils.Exit(1)
 	}
 
 	if liveUpdate {
@@ -129,7 +155,7 @@ func WatchCustomApp(appName []string, liveUpdate bool) {
 	if len(appName) > 0 {
 		appNameList = appName
 	} else {
-		appNameList = featureSection.Key("custom_apps").Strings("|")
+		appNameList = getCustomAppList()
 	}
 
 	threadCount := 0
@@ -152,16 +178,10 @@ func WatchCustomApp(appName []string, liveUpdate bool) {
 			appFileList = append(appFileList, cssFilePath)
 		}
 
-		manifestPath := filepath.Join(appPath, "manifest.json")
-		manifestFileContent, err := os.ReadFile(manifestPath)
-		if err == nil {
-			var manifestJson appManifest
-			if err = json.Unmarshal(manifestFileContent, &manifestJson); err == nil {
-				for _, subfile := range(manifestJson.Files) {
-					subfilePath := filepath.Join(appPath, subfile)
-					appFileList = append(appFileList, subfilePath)
-				}
-			}
+		_, manifestJson := readAppManifest(appPath)
+		appFileList = append(appFileList, resolveSubfiles(appPath, manifestJson.Files)...)
+		for _, chunkFile := range manifestJson.Chunks {
+			appFileList = append(appFileList, filepath.Join(appPath, chunkFile))
 		}
 
 		threadCount += 1
@@ -169,7 +189,7 @@ func WatchCustomApp(appName []string, liveUpdate bool) {
 		go utils.Watch(appFileList, func(filePath string, err error) {
 			if err != nil {
 				utils.PrintError(err.Error())
-				os.Exit(1)
+				utils.Exit(1)
 			}
 	
 			pushApps(appName)
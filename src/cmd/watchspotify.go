@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// spotifyWatchInterval is how often "--watch-spotify" checks Spotify
+const spotifyWatchInterval = 30 * time.Second
+
+// maxReapplyAttempts is how many times "--watch-spotify" reapplies the same
+// Spotify install. Past that, Spotify keeps reverting changes or apply does
+// not stick, so reapplying again would only loop.
+const maxReapplyAttempts = 3
+
+// WatchSpotify keeps running and, whenever Spotify overwrites spicetify
+// changes, e.g. by updating itself, backs up if needed and applies again,
+// same as "auto". `onReapply` runs after every reapply. Errors that need
+// user action, like Spotify that cannot be backed up, stop it.
+func WatchSpotify(onReapply func()) {
+	// Runs unattended, every prompt falls back to its quiet mode answer
+	quiet = true
+
+	utils.PrintInfo(utils.PrependTime("Watching Spotify for updates that revert spicetify changes. Press Ctrl+C to stop."))
+
+	pending := ""
+	reapplied := ""
+	attempts := 0
+	for {
+		time.Sleep(spotifyWatchInterval)
+
+		if !isReverted() {
+			pending = ""
+			continue
+		}
+
+		// Spotify may still be writing files of an update. Wait until
+		// nothing changes between two checks.
+		signature := getSpotifySignature()
+		if signature != pending {
+			if len(pending) == 0 {
+				utils.PrintWarning(utils.PrependTime("Spotify has overwritten spicetify changes. Waiting for it to finish updating."))
+			}
+			pending = signature
+			continue
+		}
+
+		if signature != reapplied {
+			reapplied = signature
+			attempts = 0
+		}
+		if attempts >= maxReapplyAttempts {
+			if attempts == maxReapplyAttempts {
+				utils.PrintError(utils.PrependTime("Spotify keeps reverting spicetify changes, not reapplying until Spotify changes again."))
+				attempts++
+			}
+			continue
+		}
+		attempts++
+
+		utils.PrintInfo(utils.PrependTime("Reapplying."))
+		reapplySpotify()
+		onReapply()
+		pending = ""
+	}
+}
+
+// reapplySpotify runs "auto" while holding process lock, which is only
+// taken for as long as Spotify is modified
+func reapplySpotify() {
+	AcquireLock(true)
+	defer ReleaseLock()
+
+	Auto()
+	if !isReverted() {
+		utils.PrintSuccess(utils.PrependTime("Spotify is spiced up again!"))
+	}
+}
+
+// getSpotifySignature identifies current Spotify install by content of its
+// Apps folder and version
+func getSpotifySignature() string {
+	return hashSources(appPath) + ":" + utils.GetInstalledSpotifyVersion(prefsPath)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// defaultXpuiFolder is where regular Spotify builds keep their frontend in
+// Apps folder
+const defaultXpuiFolder = "xpui"
+
+// xpuiFolder caches resolved frontend folder, relative to Apps folder
+var xpuiFolder string
+
+// getXpuiPath returns folder in Spotify Apps folder that holds Spotify
+// frontend, which extensions, custom apps and user.css are injected into.
+// It is config "xpui_path" of current install if set, otherwise detected.
+func getXpuiPath() string {
+	if len(xpuiFolder) > 0 {
+		return filepath.Join(appDestPath, xpuiFolder)
+	}
+
+	if configured := getInstallSection().Key("xpui_path").String(); len(configured) > 0 {
+		xpuiFolder = filepath.FromSlash(configured)
+	} else if detected, ok := detectXpuiFolder(appDestPath); ok {
+		xpuiFolder = detected
+	} else if _, err := os.Stat(filepath.Join(appDestPath, defaultXpuiFolder+".spa")); err == nil {
+		// Frontend is still packed, so there is nothing to detect yet
+		return filepath.Join(appDestPath, defaultXpuiFolder)
+	} else {
+		utils.PrintWarning(`Cannot find Spotify frontend folder in ` + appDestPath + `. Using "` + defaultXpuiFolder + `". Set config "xpui_path" if this Spotify build keeps it elsewhere.`)
+		xpuiFolder = defaultXpuiFolder
+	}
+
+	return filepath.Join(appDestPath, xpuiFolder)
+}
+
+// isXpuiFolder reports whether `folder` looks like Spotify frontend
+func isXpuiFolder(folder string) bool {
+	for _, name := range []string{"index.html", "xpui.js"} {
+		if _, err := os.Stat(filepath.Join(folder, name)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// detectXpuiFolder looks for Spotify frontend in Apps folder `appsPath`:
+// "xpui" first, then any folder up to two levels deep that has its files.
+// Returns path relative to `appsPath`.
+func detectXpuiFolder(appsPath string) (string, bool) {
+	if isXpuiFolder(filepath.Join(appsPath, defaultXpuiFolder)) {
+		return defaultXpuiFolder, true
+	}
+
+	candidates := []string{}
+	for _, pattern := range []string{"*", filepath.Join("*", "*")} {
+		matches, _ := filepath.Glob(filepath.Join(appsPath, pattern))
+		for _, match := range matches {
+			if isXpuiFolder(match) {
+				rel, _ := filepath.Rel(appsPath, match)
+				candidates = append(candidates, rel)
+			}
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.Strings(candidates)
+	return candidates[0], true
+}
@@ -0,0 +1,106 @@
+package compatibility
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
+)
+
+// IndexURL is where latest index is fetched from.
+const IndexURL = "https://raw.githubusercontent.com/khanhas/spicetify-cli/master/src/compatibility/index.json"
+
+//go:embed index.json
+var builtinIndex []byte
+
+// Entry marks an extension or custom app as broken on a range of Spotify
+// versions. Blank MinVersion or MaxVersion leaves that side open.
+type Entry struct {
+	// Name is matched against extension file name, custom app folder name
+	// or name declared by extension header/app manifest.
+	Name       string `json:"name"`
+	MinVersion string `json:"min_version"`
+	MaxVersion string `json:"max_version"`
+	Reason     string `json:"reason"`
+}
+
+// Index lists known incompatible extensions and custom apps.
+type Index struct {
+	Extensions []Entry `json:"extensions"`
+	CustomApps []Entry `json:"custom_apps"`
+}
+
+// Load returns index parsed from previously fetched `content` if it is
+// valid, otherwise the one shipped with spicetify.
+func Load(content []byte) Index {
+	var index Index
+
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &index); err == nil {
+			return index
+		}
+	}
+
+	json.Unmarshal(builtinIndex, &index)
+	return index
+}
+
+// Fetch downloads latest index and returns its content once it is valid,
+// for caller to cache.
+func Fetch() ([]byte, error) {
+	content, err := utils.FetchURL(IndexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var index Index
+	if err = json.Unmarshal(content, &index); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// FindExtension returns entry matching any of `names` on Spotify `version`,
+// or nil if none matches.
+func (i Index) FindExtension(version string, names ...string) *Entry {
+	return find(i.Extensions, version, names)
+}
+
+// FindCustomApp returns entry matching any of `names` on Spotify `version`,
+// or nil if none matches.
+func (i Index) FindCustomApp(version string, names ...string) *Entry {
+	return find(i.CustomApps, version, names)
+}
+
+func find(entries []Entry, version string, names []string) *Entry {
+	for index, entry := range entries {
+		for _, name := range names {
+			if len(name) == 0 || entry.Name != name {
+				continue
+			}
+
+			if entry.covers(version) {
+				return &entries[index]
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e Entry) covers(version string) bool {
+	if len(version) == 0 {
+		return false
+	}
+
+	if len(e.MinVersion) > 0 && utils.CompareVersion(version, e.MinVersion) < 0 {
+		return false
+	}
+
+	if len(e.MaxVersion) > 0 && utils.CompareVersion(version, e.MaxVersion) > 0 {
+		return false
+	}
+
+	return true
+}
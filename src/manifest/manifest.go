@@ -0,0 +1,125 @@
+package manifest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Schema is JSON Schema of custom app manifest.json
+//
+//go:embed schema.json
+var Schema []byte
+
+// Validate checks custom app manifest `content` against Schema and returns
+// a list of field-level problems. Only the subset of JSON Schema used by
+// Schema is supported: "type", "required", "properties",
+// "additionalProperties" and "items".
+func Validate(content []byte) []error {
+	var value interface{}
+	if err := json.Unmarshal(content, &value); err != nil {
+		return []error{fmt.Errorf("invalid JSON: %s", err)}
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		return []error{fmt.Errorf("invalid schema: %s", err)}
+	}
+
+	return validate(value, schema, "")
+}
+
+func validate(value interface{}, schema map[string]interface{}, path string) []error {
+	name := path
+	if len(name) == 0 {
+		name = "manifest"
+	}
+
+	if expected, ok := schema["type"].(string); ok {
+		// Integers are numbers too
+		if actual := typeOf(value); actual != expected && !(expected == "number" && actual == "integer") {
+			return []error{fmt.Errorf(`%s: expected %s, got %s`, name, expected, actual)}
+		}
+	}
+
+	var errs []error
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, field := range required {
+				fieldName, _ := field.(string)
+				if _, found := v[fieldName]; !found {
+					errs = append(errs, fmt.Errorf(`%s: missing required field "%s"`, name, fieldName))
+				}
+			}
+		}
+
+		// Sorted for stable output
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fieldPath := joinPath(path, key)
+			if fieldSchema, ok := properties[key].(map[string]interface{}); ok {
+				errs = append(errs, validate(v[key], fieldSchema, fieldPath)...)
+				continue
+			}
+
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Errorf(`%s: unknown field "%s"`, name, key))
+				}
+			case map[string]interface{}:
+				errs = append(errs, validate(v[key], additional, fieldPath)...)
+			}
+		}
+
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				errs = append(errs, validate(item, items, fmt.Sprintf("%s[%d]", name, i))...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func joinPath(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// typeOf returns JSON Schema type name of decoded JSON `value`
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return "unknown"
+}
@@ -3,7 +3,6 @@ package preprocess
 import (
 	"encoding/json"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -175,7 +174,7 @@ func StartCSS(extractedAppsPath string, callback func(appName string)) {
 	appList, err := ioutil.ReadDir(extractedAppsPath)
 
 	if err != nil {
-		log.Fatal(err)
+		utils.Fatal(err)
 	}
 
 	var wg sync.WaitGroup
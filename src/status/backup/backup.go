@@ -2,7 +2,6 @@ package backupstatus
 
 import (
 	"io/ioutil"
-	"log"
 	"strings"
 
 	"github.com/khanhas/spicetify-cli/src/utils"
@@ -32,7 +31,7 @@ const (
 func Get(prefsPath, backupPath, backupVersion string) Status {
 	fileList, err := ioutil.ReadDir(backupPath)
 	if err != nil {
-		log.Fatal(err)
+		utils.Fatal(err)
 	}
 
 	cur := EMPTY
@@ -46,7 +45,7 @@ func Get(prefsPath, backupPath, backupVersion string) Status {
 		}
 
 		if spaCount > 0 {
-			spotifyVersion := utils.GetSpotifyVersion(prefsPath)
+			spotifyVersion := utils.GetInstalledSpotifyVersion(prefsPath)
 
 			if backupVersion != spotifyVersion {
 				cur = OUTDATED
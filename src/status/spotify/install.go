@@ -0,0 +1,74 @@
+package spotifystatus
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// frontendFiles must be in Spotify frontend, packed in "xpui.spa" or
+// extracted to a folder
+var frontendFiles = []string{"index.html", "xpui.js"}
+
+// executablePath returns location of Spotify executable relative to
+// Spotify folder on current OS
+func executablePath() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Spotify.exe"
+	case "darwin":
+		return filepath.Join("..", "MacOS", "Spotify")
+	default:
+		return "spotify"
+	}
+}
+
+// FindMissingFiles checks that core files of Spotify install exist and
+// returns the ones that are missing or unreadable, which happens after an
+// interrupted update or antivirus quarantine. `spotifyPath` is Spotify
+// folder, whose executable is not checked when blank. `frontendFolder` is
+// where extracted frontend is, used when "xpui.spa" in `appsFolder` is gone.
+func FindMissingFiles(spotifyPath, appsFolder, frontendFolder string) []string {
+	missing := []string{}
+
+	if len(spotifyPath) > 0 {
+		exe := filepath.Join(spotifyPath, executablePath())
+		if _, err := os.Stat(exe); err != nil {
+			missing = append(missing, exe)
+		}
+	}
+
+	if _, err := os.Stat(appsFolder); err != nil {
+		return append(missing, appsFolder)
+	}
+
+	pkg := filepath.Join(appsFolder, "xpui.spa")
+	if _, err := os.Stat(pkg); err != nil {
+		for _, name := range frontendFiles {
+			file := filepath.Join(frontendFolder, name)
+			if _, err := os.Stat(file); err != nil {
+				missing = append(missing, file)
+			}
+		}
+		return missing
+	}
+
+	reader, err := zip.OpenReader(pkg)
+	if err != nil {
+		return append(missing, pkg+" (corrupted)")
+	}
+	defer reader.Close()
+
+	found := map[string]bool{}
+	for _, file := range reader.File {
+		found[file.Name] = true
+	}
+	for _, name := range frontendFiles {
+		if !found[name] {
+			missing = append(missing, pkg+": "+name)
+		}
+	}
+
+	return missing
+}
@@ -2,8 +2,9 @@ package spotifystatus
 
 import (
 	"io/ioutil"
-	"log"
 	"strings"
+
+	"github.com/khanhas/spicetify-cli/src/utils"
 )
 
 type status struct {
@@ -35,7 +36,7 @@ const (
 func Get(appsFolder string) Status {
 	fileList, err := ioutil.ReadDir(appsFolder)
 	if err != nil {
-		log.Fatal(err)
+		utils.Fatal(err)
 	}
 
 	spaCount := 0
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// DefaultAccentColor is used for "${accent}" when OS accent color cannot be
+// read and no fallback is given. It is Spotify's own accent color.
+const DefaultAccentColor = "1db954"
+
+var (
+	accentColor     string
+	accentErr       error
+	accentRead      = false
+	accentWarned    = false
+	errAccentNotSet = errors.New("accent color is not set")
+)
+
+// macOS "AppleAccentColor" values. Key is absent for default multicolor,
+// which shows blue.
+var macAccentColors = map[string]string{
+	"-1": "8e8e93",
+	"0":  "ff3b30",
+	"1":  "ff9500",
+	"2":  "ffcc00",
+	"3":  "28cd41",
+	"4":  "007aff",
+	"5":  "af52de",
+	"6":  "ff2d55",
+}
+
+// GNOME "accent-color" values, as libadwaita renders them
+var gnomeAccentColors = map[string]string{
+	"blue":   "3584e4",
+	"teal":   "2190a4",
+	"green":  "3a944a",
+	"yellow": "c88800",
+	"orange": "ed5b00",
+	"red":    "e62d42",
+	"pink":   "d56199",
+	"purple": "9141ac",
+	"slate":  "6f8396",
+}
+
+// GetSystemAccentColor returns OS accent color as hex "rrggbb". It is read
+// once: on Windows from registry, on macOS from global defaults and on Linux
+// from KDE or GNOME settings.
+func GetSystemAccentColor() (string, error) {
+	if accentRead {
+		return accentColor, accentErr
+	}
+	accentRead = true
+
+	switch runtime.GOOS {
+	case "windows":
+		accentColor, accentErr = winAccentColor()
+	case "darwin":
+		accentColor, accentErr = darwinAccentColor()
+	case "linux":
+		accentColor, accentErr = linuxAccentColor()
+	default:
+		accentErr = errors.New("not supported on " + runtime.GOOS)
+	}
+
+	return accentColor, accentErr
+}
+
+func winAccentColor() (string, error) {
+	output, err := exec.Command("reg", "query", `HKCU\Software\Microsoft\Windows\DWM`, "/v", "AccentColor").Output()
+	if err != nil {
+		return "", errAccentNotSet
+	}
+
+	match := regexp.MustCompile(`AccentColor\s+REG_DWORD\s+0x([0-9a-fA-F]+)`).FindSubmatch(output)
+	if match == nil {
+		return "", errAccentNotSet
+	}
+
+	// Stored as 0xAABBGGRR
+	value, err := strconv.ParseUint(string(match[1]), 16, 32)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%02x%02x%02x", value&0xff, value>>8&0xff, value>>16&0xff), nil
+}
+
+func darwinAccentColor() (string, error) {
+	output, err := exec.Command("defaults", "read", "-g", "AppleAccentColor").CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "does not exist") {
+			return macAccentColors["4"], nil
+		}
+		return "", err
+	}
+
+	value, ok := macAccentColors[strings.TrimSpace(string(output))]
+	if !ok {
+		return "", errors.New(`unknown "AppleAccentColor" value ` + strings.TrimSpace(string(output)))
+	}
+
+	return value, nil
+}
+
+func linuxAccentColor() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if len(configHome) == 0 {
+		configHome = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+
+	if kde, err := ini.Load(filepath.Join(configHome, "kdeglobals")); err == nil {
+		if value := kde.Section("General").Key("AccentColor").String(); len(value) > 0 {
+			return ParseColor(value).Hex(), nil
+		}
+	}
+
+	output, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "accent-color").Output()
+	if err != nil {
+		return "", errAccentNotSet
+	}
+
+	name := strings.Trim(strings.TrimSpace(string(output)), "'")
+	value, ok := gnomeAccentColors[name]
+	if !ok {
+		return "", errors.New(`unknown GNOME accent color "` + name + `"`)
+	}
+
+	return value, nil
+}
+
+// fromSystemAccent resolves "accent" or "accent:<fallback>" color lookup.
+// When OS accent color cannot be read, <fallback>, or DefaultAccentColor if
+// there is none, is used.
+func fromSystemAccent(input string) string {
+	color, err := GetSystemAccentColor()
+	if err == nil {
+		return color
+	}
+
+	if i := strings.Index(input, ":"); i > -1 && len(input[i+1:]) > 0 {
+		return input[i+1:]
+	}
+
+	if !accentWarned {
+		accentWarned = true
+		PrintWarning("Cannot read system accent color: " + err.Error() + ". Using " + DefaultAccentColor + " instead.")
+	}
+
+	return DefaultAccentColor
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -53,6 +54,52 @@ var (
 	}
 )
 
+var (
+	hexColorRegex     = regexp.MustCompile(`^#?([0-9a-f]{3}|[0-9a-f]{6})$`)
+	rgbColorRegex     = regexp.MustCompile(`^rgb\(\s*(\d+)\s*,?\s*(\d+)\s*,?\s*(\d+)\s*\)$`)
+	tripletColorRegex = regexp.MustCompile(`^(\d+)\s*,\s*(\d+)\s*,\s*(\d+)$`)
+)
+
+// NormalizeColor converts color value `raw` to lowercase "rrggbb". It
+// accepts "#rrggbb", "#rgb", both without "#" too, "r,g,b", "rgb(r, g, b)"
+// and CSS color names.
+func NormalizeColor(raw string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if len(value) == 0 {
+		return "", errors.New("value is blank")
+	}
+
+	if hex, ok := cssColorNames[value]; ok {
+		return hex, nil
+	}
+
+	if match := hexColorRegex.FindStringSubmatch(value); match != nil {
+		hex := match[1]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		return hex, nil
+	}
+
+	match := rgbColorRegex.FindStringSubmatch(value)
+	if match == nil {
+		match = tripletColorRegex.FindStringSubmatch(value)
+	}
+	if match == nil {
+		return "", errors.New(`it is not a color. Use hex "rrggbb" or "rgb", "r,g,b", "rgb(r, g, b)" or a CSS color name`)
+	}
+
+	hex := ""
+	for _, channel := range match[1:] {
+		number, err := strconv.Atoi(channel)
+		if err != nil || number > 255 {
+			return "", errors.New(`"` + channel + `" is out of range 0-255`)
+		}
+		hex += fmt.Sprintf("%02x", number)
+	}
+	return hex, nil
+}
+
 type color struct {
 	red, green, blue int64
 }
@@ -64,8 +111,8 @@ type Color interface {
 	TerminalRGB() string
 }
 
-// ParseColor parses a string in both hex or rgb
-// or from XResources or env variable
+// ParseColor parses a string in any form NormalizeColor accepts
+// or from XResources, OS accent color or env variable
 // and converts to both rgb and hex value
 func ParseColor(raw string) Color {
 	var red, green, blue int64
@@ -78,12 +125,20 @@ func ParseColor(raw string) Color {
 		if strings.HasPrefix(raw, "xrdb:") {
 			raw = fromXResources(raw)
 
+			// From OS accent color
+		} else if raw == "accent" || strings.HasPrefix(raw, "accent:") {
+			raw = fromSystemAccent(raw)
+
 			// From environment variable
 		} else if env := os.Getenv(raw); len(env) > 0 {
 			raw = env
 		}
 	}
 
+	if hex, err := NormalizeColor(raw); err == nil {
+		raw = hex
+	}
+
 	// rrr,bbb,ggg
 	if strings.Contains(raw, ",") {
 		list := strings.SplitN(raw, ",", 3)
@@ -182,7 +237,7 @@ func fromXResources(input string) string {
 	queries := strings.Split(input, ":")
 	if len(queries[1]) == 0 {
 		PrintError(`"` + input + `": Wrong XResources lookup syntax`)
-		os.Exit(0)
+		Exit(0)
 	}
 
 	if err := getXRDB(); err != nil {
@@ -191,7 +246,7 @@ func fromXResources(input string) string {
 
 	if len(xrdb) < 1 {
 		PrintError("XResources is not available")
-		os.Exit(0)
+		Exit(0)
 	}
 
 	value, ok := xrdb[queries[1]]
@@ -202,7 +257,7 @@ func fromXResources(input string) string {
 			value = queries[2]
 		} else {
 			PrintError("Variable is not available in XResources")
-			os.Exit(0)
+			Exit(0)
 		}
 	}
 
@@ -1,11 +1,17 @@
 package utils
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-ini/ini"
 )
@@ -13,15 +19,23 @@ import (
 var (
 	configLayout = map[string]map[string]string{
 		"Setting": {
-			"spotify_path":            "",
-			"prefs_path":              "",
-			"current_theme":           "SpicetifyDefault",
-			"color_scheme":            "",
-			"inject_css":              "1",
-			"replace_colors":          "1",
-			"overwrite_assets":        "0",
-			"spotify_launch_flags":    "",
-			"check_spicetify_upgrade": "0",
+			"spotify_path":               "",
+			"prefs_path":                 "",
+			"current_theme":              "SpicetifyDefault",
+			"color_scheme":               "",
+			"inject_css":                 "1",
+			"replace_colors":             "1",
+			"overwrite_assets":           "0",
+			"inject_splash":              "0",
+			"accent_follow_system":       "0",
+			"spotify_launch_flags":       "",
+			"check_spicetify_upgrade":    "0",
+			"reapply_on_revert":          "0",
+			"xpui_path":                  "",
+			"css_history":                "0",
+			"backup_launcher":            "0",
+			"node_modules_copy_fallback": "0",
+			"normalize_line_endings":     "1",
 		},
 		"Preprocesses": {
 			"disable_sentry":        "1",
@@ -38,9 +52,25 @@ var (
 	}
 )
 
+// InstallSectionPrefix starts name of config sections declaring additional
+// Spotify installs, e.g. "[Install beta]"
+const InstallSectionPrefix = "Install "
+
+// installKeys are keys of install sections: location of install, then
+// backup record like [Backup]
+var installKeys = map[string]bool{"spotify_path": true, "prefs_path": true, "xpui_path": true, "version": true, "excluded": true}
+
+// installNameRegex matches names of installs, which name their state folder
+var installNameRegex = regexp.MustCompile(`^[\w-]+$`)
+
 type config struct {
 	path    string
 	content *ini.File
+	// user and system hold config file and system config it is layered on,
+	// when there is a system config. content is then merged from them.
+	user    *ini.File
+	system  *ini.File
+	sources map[string]string
 }
 
 // Config .
@@ -48,11 +78,31 @@ type Config interface {
 	Write()
 	GetSection(string) *ini.Section
 	GetPath() string
+	GetSource(section, key string) string
+	GetSectionNames() []string
+}
+
+// newConfig layers config file content `user` on system config, if any
+func newConfig(path string, user, system *ini.File) config {
+	if system == nil {
+		return config{path: path, content: user}
+	}
+
+	content, sources := layerConfig(system, user)
+	return config{
+		path:    path,
+		content: content,
+		user:    user,
+		system:  system,
+		sources: sources,
+	}
 }
 
 // ParseConfig read config file content, return default config
 // if file doesn't exist.
 func ParseConfig(configPath string) Config {
+	system := loadSystemConfig()
+
 	cfg, err := ini.LoadSources(
 		ini.LoadOptions{
 			IgnoreContinuation: true,
@@ -60,10 +110,7 @@ func ParseConfig(configPath string) Config {
 		configPath)
 
 	if err != nil {
-		defaultConfig := config{
-			path:    configPath,
-			content: getDefaultConfig(),
-		}
+		defaultConfig := newConfig(configPath, getDefaultConfig(system), system)
 		defaultConfig.Write()
 		PrintSuccess("Default config-xpui.ini generated.")
 		return defaultConfig
@@ -78,6 +125,10 @@ func ParseConfig(configPath string) Config {
 		}
 		for keyName, defaultValue := range keyList {
 			if _, err := section.GetKey(keyName); err != nil {
+				if systemKey(system, sectionName, keyName) != nil {
+					// Blank inherits system value
+					defaultValue = ""
+				}
 				section.NewKey(keyName, defaultValue)
 				needRewrite = true
 			}
@@ -86,18 +137,175 @@ func ParseConfig(configPath string) Config {
 
 	if needRewrite {
 		PrintSuccess("Config is updated.")
-		cfg.SaveTo(configPath)
+		config{path: configPath, content: cfg}.Write()
 	}
 
-	return config{
-		path:    configPath,
-		content: cfg,
+	return newConfig(configPath, cfg, system)
+}
+
+// ValidateConfig parses config file at `configPath` and returns a list of
+// problems: INI syntax errors, unrecognized sections or keys and invalid
+// boolean values. Keys in "Patch" section are free-form.
+func ValidateConfig(configPath string) []error {
+	cfg, err := ini.LoadSources(
+		ini.LoadOptions{
+			IgnoreContinuation: true,
+		},
+		configPath)
+
+	if err != nil {
+		return []error{err}
 	}
+
+	var errs []error
+	for _, section := range cfg.Sections() {
+		sectionName := section.Name()
+		if sectionName == ini.DefaultSection {
+			if len(section.Keys()) > 0 {
+				errs = append(errs, fmt.Errorf(`keys outside of any section: %s`, strings.Join(section.KeyStrings(), ", ")))
+			}
+			continue
+		}
+
+		if sectionName == "Backup" || sectionName == "Patch" {
+			continue
+		}
+
+		if strings.HasPrefix(sectionName, InstallSectionPrefix) {
+			if name := strings.TrimPrefix(sectionName, InstallSectionPrefix); !installNameRegex.MatchString(name) {
+				errs = append(errs, fmt.Errorf(`[%s] install name can only contain letters, digits, "_" and "-"`, sectionName))
+			}
+			for _, key := range section.Keys() {
+				if !installKeys[key.Name()] {
+					errs = append(errs, fmt.Errorf(`[%s] unrecognized key "%s"`, sectionName, key.Name()))
+				}
+			}
+			continue
+		}
+
+		keyList, ok := configLayout[sectionName]
+		if !ok {
+			errs = append(errs, fmt.Errorf(`unrecognized section "[%s]"`, sectionName))
+			continue
+		}
+
+		for _, key := range section.Keys() {
+			if _, ok := keyList[key.Name()]; !ok {
+				errs = append(errs, fmt.Errorf(`[%s] unrecognized key "%s"`, sectionName, key.Name()))
+				continue
+			}
+
+			if isBoolKey(sectionName, key.Name()) && len(key.Value()) > 0 {
+				if _, err := key.Bool(); err != nil {
+					errs = append(errs, fmt.Errorf(`[%s] "%s" must be 0 or 1, got "%s"`, sectionName, key.Name(), key.Value()))
+				}
+			}
+
+			if sectionName == "Setting" && key.Name() == "css_history" && len(key.Value()) > 0 {
+				if count, err := key.Int(); err != nil || count < 0 {
+					errs = append(errs, fmt.Errorf(`[%s] "%s" must be 0 or a positive number, got "%s"`, sectionName, key.Name(), key.Value()))
+				}
+			}
+		}
+	}
+
+	return errs
 }
 
-// Write writes content to config file.
+func isBoolKey(section, key string) bool {
+	switch section {
+	case "Preprocesses":
+		return true
+	case "Setting":
+		switch key {
+		case "inject_css", "replace_colors", "overwrite_assets", "inject_splash", "check_spicetify_upgrade", "reapply_on_revert", "accent_follow_system", "backup_launcher", "node_modules_copy_fallback", "normalize_line_endings":
+			return true
+		}
+	}
+
+	return false
+}
+
+// Write writes content to config file. Previous content is kept in config
+// history first, so that the change can be undone.
 func (c config) Write() {
-	c.content.SaveTo(c.path)
+	file := c.content
+	if c.system != nil {
+		c.unlayer()
+		file = c.user
+	}
+
+	var content bytes.Buffer
+	if _, err := file.WriteTo(&content); err != nil {
+		PrintError("Cannot write config: " + err.Error())
+		return
+	}
+
+	if err := snapshotConfig(c.path, content.Bytes()); err != nil {
+		PrintWarning("Cannot keep previous config in history: " + err.Error())
+	}
+
+	if err := ioutil.WriteFile(c.path, content.Bytes(), 0666); err != nil {
+		PrintError("Cannot write config: " + err.Error())
+	}
+}
+
+// configHistoryLimit is how many previous config versions are kept
+const configHistoryLimit = 10
+
+// GetConfigHistoryFolder returns folder previous versions of config file at
+// `configPath` are kept in.
+func GetConfigHistoryFolder(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "ConfigHistory")
+}
+
+// GetConfigSnapshots returns paths of previous versions of config file at
+// `configPath`, oldest first.
+func GetConfigSnapshots(configPath string) []string {
+	folder := GetConfigHistoryFolder(configPath)
+	entries, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil
+	}
+
+	snapshots := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".ini") {
+			snapshots = append(snapshots, filepath.Join(folder, entry.Name()))
+		}
+	}
+
+	// Names are timestamps, so they sort chronologically
+	sort.Strings(snapshots)
+	return snapshots
+}
+
+// snapshotConfig copies config file at `configPath` to config history
+// before it is replaced with `newContent`, unless it does not exist yet or
+// is unchanged. Only configHistoryLimit latest versions are kept.
+func snapshotConfig(configPath string, newContent []byte) error {
+	current, err := ioutil.ReadFile(configPath)
+	if err != nil || bytes.Equal(current, newContent) {
+		return nil
+	}
+
+	folder := GetConfigHistoryFolder(configPath)
+	if err = os.MkdirAll(folder, 0700); err != nil {
+		return err
+	}
+
+	name := time.Now().Format("20060102-150405.000000000") + ".ini"
+	if err = ioutil.WriteFile(filepath.Join(folder, name), current, 0600); err != nil {
+		return err
+	}
+
+	snapshots := GetConfigSnapshots(configPath)
+	for len(snapshots) > configHistoryLimit {
+		os.Remove(snapshots[0])
+		snapshots = snapshots[1:]
+	}
+
+	return nil
 }
 
 func (c config) GetSection(name string) *ini.Section {
@@ -113,11 +321,38 @@ func (c config) GetSection(name string) *ini.Section {
 	return sec
 }
 
+// ListValues returns entries of "|" separated list `key`, with surrounding
+// whitespace trimmed and empty entries, e.g. from "a||b" or a trailing "|",
+// skipped.
+func ListValues(key *ini.Key) []string {
+	list := []string{}
+	for _, entry := range key.Strings("|") {
+		if entry = strings.TrimSpace(entry); len(entry) > 0 {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// GetSectionNames returns names of sections in config, in file order
+func (c config) GetSectionNames() []string {
+	return c.content.SectionStrings()
+}
+
 func (c config) GetPath() string {
 	return c.path
 }
 
-func getDefaultConfig() *ini.File {
+// GetSource returns where value of `key` in `section` comes from: "user",
+// "system" or "system+user" for concatenated lists.
+func (c config) GetSource(section, key string) string {
+	if source, ok := c.sources[section+"."+key]; ok {
+		return source
+	}
+	return "user"
+}
+
+func getDefaultConfig(system *ini.File) *ini.File {
 	var cfg = ini.Empty()
 
 	spotifyPath := FindAppPath()
@@ -141,6 +376,10 @@ func getDefaultConfig() *ini.File {
 			panic(err)
 		}
 		for keyName, defaultValue := range keyList {
+			if systemKey(system, sectionName, keyName) != nil {
+				// Blank inherits system value
+				defaultValue = ""
+			}
 			section.NewKey(keyName, defaultValue)
 		}
 	}
@@ -232,6 +471,18 @@ func winXApp() string {
 }
 
 func winXPrefs() string {
+	localState := winXLocalState()
+	if len(localState) == 0 {
+		return ""
+	}
+
+	return filepath.Join(localState, "Spotify", "prefs")
+}
+
+// winXLocalState returns per-user, writable "LocalState" folder of Spotify
+// Windows Store package. Unlike package install location, it is not
+// protected and survives package updates.
+func winXLocalState() string {
 	ps, _ := exec.LookPath("powershell.exe")
 	cmd := exec.Command(ps,
 		"-NoProfile",
@@ -239,17 +490,40 @@ func winXPrefs() string {
 		`(Get-AppxPackage | Where-Object -Property Name -Match "^SpotifyAB").PackageFamilyName`)
 
 	stdOut, err := cmd.CombinedOutput()
-	if err == nil {
-		return filepath.Join(
-			os.Getenv("LOCALAPPDATA"),
-			"Packages",
-			strings.TrimSpace(string(stdOut)),
-			"LocalState",
-			"Spotify",
-			"prefs")
+	if err != nil {
+		return ""
 	}
 
-	return ""
+	familyName := strings.TrimSpace(string(stdOut))
+	if len(familyName) == 0 {
+		return ""
+	}
+
+	return filepath.Join(
+		os.Getenv("LOCALAPPDATA"),
+		"Packages",
+		familyName,
+		"LocalState")
+}
+
+// FindAppXDataPath returns a writable location inside Spotify Windows Store
+// package data to hold modded Apps folder.
+// Returns blank string if package data folder cannot be resolved.
+func FindAppXDataPath() string {
+	if runtime.GOOS != "windows" {
+		return ""
+	}
+
+	localState := winXLocalState()
+	if len(localState) == 0 {
+		return ""
+	}
+
+	if _, err := os.Stat(localState); err != nil {
+		return ""
+	}
+
+	return filepath.Join(localState, "Spicetify", "Apps")
 }
 
 func linuxApp() string {
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-ini/ini"
+)
+
+func TestListValues(t *testing.T) {
+	cases := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{}},
+		{"|", []string{}},
+		{" | | ", []string{}},
+		{"a.js|b.js", []string{"a.js", "b.js"}},
+		{" a.js | |b.js|", []string{"a.js", "b.js"}},
+		{"a.js||b.js", []string{"a.js", "b.js"}},
+		{"\ta.js\t|  b.js  ", []string{"a.js", "b.js"}},
+		{"My Extension.js | other app", []string{"My Extension.js", "other app"}},
+	}
+
+	section := ini.Empty().Section("AdditionalOptions")
+	for _, c := range cases {
+		key, err := section.NewKey("extensions", c.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ListValues(key); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ListValues(%q) = %q, want %q", c.value, got, c.want)
+		}
+	}
+}
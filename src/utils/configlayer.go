@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// System config is a base that user config is layered on, for managed
+// deployments on shared machines:
+//   - A user value wins, unless it is blank, then system value is used.
+//   - Lists ("extensions", "custom_apps", "spotify_launch_flags") are
+//     concatenated, system entries first. A user entry ending with "-"
+//     removes that entry of system list instead.
+//   - [Backup] and install sections are never taken from system config.
+
+// GetSystemConfigPath returns location of system-wide config. It can be
+// changed with "SPICETIFY_SYSTEM_CONFIG" environment variable.
+func GetSystemConfigPath() string {
+	if path := os.Getenv("SPICETIFY_SYSTEM_CONFIG"); len(path) > 0 {
+		return path
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("PROGRAMDATA"), "spicetify", "config-xpui.ini")
+	case "darwin":
+		return filepath.Join("/Library", "Application Support", "spicetify", "config-xpui.ini")
+	default:
+		return filepath.Join("/etc", "spicetify", "config-xpui.ini")
+	}
+}
+
+// loadSystemConfig returns system config, or nil if there is none
+func loadSystemConfig() *ini.File {
+	path := GetSystemConfigPath()
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	system, err := ini.LoadSources(ini.LoadOptions{IgnoreContinuation: true}, path)
+	if err != nil {
+		PrintWarning("Cannot read system config " + path + ", ignoring it: " + err.Error())
+		return nil
+	}
+
+	return system
+}
+
+// isListKey reports whether config key holds a "|" separated list that is
+// concatenated when layered
+func isListKey(section, key string) bool {
+	return section == "AdditionalOptions" && (key == "extensions" || key == "custom_apps") ||
+		section == "Setting" && key == "spotify_launch_flags"
+}
+
+// systemKey returns key of `system` config that is layered under `key` of
+// `section`, or nil if there is none.
+func systemKey(system *ini.File, section, key string) *ini.Key {
+	if system == nil || section == "Backup" || section == ini.DefaultSection || strings.HasPrefix(section, InstallSectionPrefix) {
+		return nil
+	}
+
+	systemSection, err := system.GetSection(section)
+	if err != nil {
+		return nil
+	}
+
+	value, err := systemSection.GetKey(key)
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
+
+// layerConfig overlays `user` config on `system` config. Returns merged
+// config and source of values that are not only from user config, keyed by
+// "<section>.<key>": "system" or "system+user".
+func layerConfig(system, user *ini.File) (*ini.File, map[string]string) {
+	merged := ini.Empty()
+	sources := map[string]string{}
+
+	for _, section := range user.Sections() {
+		target := merged.Section(section.Name())
+		for _, key := range section.Keys() {
+			target.NewKey(key.Name(), key.Value())
+		}
+	}
+
+	for _, section := range system.Sections() {
+		name := section.Name()
+		if name == "Backup" || name == ini.DefaultSection || strings.HasPrefix(name, InstallSectionPrefix) {
+			continue
+		}
+
+		target := merged.Section(name)
+		for _, key := range section.Keys() {
+			id := name + "." + key.Name()
+			existing, err := target.GetKey(key.Name())
+			if err != nil {
+				target.NewKey(key.Name(), key.Value())
+				sources[id] = "system"
+			} else if isListKey(name, key.Name()) {
+				if len(existing.Value()) == 0 {
+					sources[id] = "system"
+				} else {
+					sources[id] = "system+user"
+				}
+				existing.SetValue(strings.Join(mergeList(ListValues(key), ListValues(existing)), "|"))
+			} else if len(existing.Value()) == 0 {
+				existing.SetValue(key.Value())
+				sources[id] = "system"
+			}
+		}
+	}
+
+	return merged, sources
+}
+
+// mergeList appends `user` entries to `system` ones, without duplicates.
+// User entries ending with "-" remove system entries instead.
+func mergeList(system, user []string) []string {
+	removed := map[string]bool{}
+	for _, entry := range user {
+		if strings.HasSuffix(entry, "-") {
+			removed[strings.TrimSuffix(entry, "-")] = true
+		}
+	}
+
+	list := []string{}
+	for _, entry := range append(append([]string{}, system...), user...) {
+		if !removed[entry] && !strings.HasSuffix(entry, "-") && !containsString(list, entry) {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
+
+// unmergeList reverses mergeList: returns user entries that make `merged`
+// out of `system` list.
+func unmergeList(system, merged []string) []string {
+	list := []string{}
+	for _, entry := range merged {
+		if !containsString(system, entry) {
+			list = append(list, entry)
+		}
+	}
+	for _, entry := range system {
+		if !containsString(merged, entry) {
+			list = append(list, entry+"-")
+		}
+	}
+
+	return list
+}
+
+// unlayer copies changes made to merged config back to user config, leaving
+// out values that are still inherited from system config.
+func (c config) unlayer() {
+	for _, section := range c.content.Sections() {
+		name := section.Name()
+		if name == ini.DefaultSection {
+			continue
+		}
+
+		userSection := c.user.Section(name)
+		for _, key := range section.Keys() {
+			value := key.Value()
+			userKey, userErr := userSection.GetKey(key.Name())
+
+			if inherited := systemKey(c.system, name, key.Name()); inherited != nil {
+				if isListKey(name, key.Name()) {
+					value = strings.Join(unmergeList(ListValues(inherited), ListValues(key)), "|")
+				} else if c.sources[name+"."+key.Name()] == "system" && value == inherited.Value() {
+					// Still inherited, keep user value as it was
+					if userErr != nil {
+						continue
+					}
+					value = userKey.Value()
+				}
+			}
+
+			if userErr == nil {
+				userKey.SetValue(value)
+			} else {
+				userSection.NewKey(key.Name(), value)
+			}
+		}
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package utils
+
+import "os"
+
+// Exit codes returned by spicetify. Scripts rely on them, so existing
+// values must never be renumbered.
+const (
+	// ExitSuccess means every command finished successfully
+	ExitSuccess = 0
+	// ExitFailure is for any error not covered below, including user
+	// declining a confirmation prompt
+	ExitFailure = 1
+	// ExitConfigError means config file, flags or command is invalid
+	ExitConfigError = 2
+	// ExitNoBackup means command requires a backup but there is none
+	ExitNoBackup = 3
+	// ExitSpotifyError means Spotify cannot be found or is not in a state
+	// that command can be applied to
+	ExitSpotifyError = 4
+	// ExitPartialFailure means command finished but some items (e.g.
+	// extensions, custom apps) failed
+	ExitPartialFailure = 5
+	// ExitReverted means Spotify overwrote applied changes, e.g. by
+	// updating itself, and spicetify needs to be applied again
+	ExitReverted = 6
+	// ExitWarning means command finished but printed warnings, and
+	// "--fail-on-warning" is used
+	ExitWarning = 7
+	// ExitLocked means another spicetify process is modifying Spotify or
+	// backup, and "--wait" is not used
+	ExitLocked = 8
+	// ExitBackupOutdated means backup is of another Spotify version
+	ExitBackupOutdated = 9
+	// ExitBackupCorrupt means backup files are missing or do not match
+	// backup manifest
+	ExitBackupCorrupt = 10
+)
+
+var (
+	partialFailure = false
+	failOnWarning  = false
+)
+
+// SetFailOnWarning makes ExitCode report ExitWarning if any warning was
+// printed
+func SetFailOnWarning(enabled bool) {
+	failOnWarning = enabled
+}
+
+// MarkPartialFailure records that some items of a command failed, while
+// the rest of command could still proceed.
+func MarkPartialFailure() {
+	partialFailure = true
+}
+
+// ExitCode returns exit code spicetify should end with after all commands
+// returned normally.
+func ExitCode() int {
+	if partialFailure {
+		return ExitPartialFailure
+	}
+
+	if failOnWarning && warningCount > 0 {
+		return ExitWarning
+	}
+
+	return ExitSuccess
+}
+
+// fatalError is what Fatal panics with inside CatchFatal
+type fatalError struct {
+	err error
+}
+
+// catchingFatal counts CatchFatal calls in progress
+var catchingFatal = 0
+
+// CatchFatal runs `run` and returns error it called Fatal with, instead of
+// exiting. Exit called directly still ends process.
+func CatchFatal(run func()) (err error) {
+	catchingFatal++
+	defer func() {
+		catchingFatal--
+		if r := recover(); r != nil {
+			fatal, ok := r.(fatalError)
+			if !ok {
+				panic(r)
+			}
+			err = fatal.err
+		}
+	}()
+
+	run()
+	return nil
+}
+
+// exitRequest is what Exit panics with inside CatchExit
+type exitRequest struct {
+	code int
+}
+
+// catchingExit counts CatchExit calls in progress
+var catchingExit = 0
+
+// CatchExit runs `run` and returns code it called Exit (or Fatal) with,
+// instead of exiting, or ExitCode of what it did if it returned. Exit
+// hooks do not run. Partial failure and warnings of `run` still count
+// towards ExitCode of the whole process.
+func CatchExit(run func()) (code int) {
+	savedPartialFailure, savedWarningCount := partialFailure, warningCount
+	partialFailure, warningCount = false, 0
+
+	catchingExit++
+	defer func() {
+		catchingExit--
+		if r := recover(); r != nil {
+			request, ok := r.(exitRequest)
+			if !ok {
+				panic(r)
+			}
+			code = request.code
+		}
+		partialFailure = partialFailure || savedPartialFailure
+		warningCount += savedWarningCount
+	}()
+
+	run()
+	return ExitCode()
+}
+
+// exitHooks run, in order they are registered, right before process exits
+var exitHooks = []func(code int){}
+
+// OnExit registers `hook` to run with exit code when spicetify exits
+// through Exit, whether command succeeded or not.
+func OnExit(hook func(code int)) {
+	exitHooks = append(exitHooks, hook)
+}
+
+// Exit runs exit hooks then ends process with `code`. Use it instead of
+// os.Exit.
+func Exit(code int) {
+	if catchingExit > 0 {
+		panic(exitRequest{code})
+	}
+
+	hooks := exitHooks
+	// A hook calling Exit must not run hooks again
+	exitHooks = nil
+	for _, hook := range hooks {
+		hook(code)
+	}
+	os.Exit(code)
+}
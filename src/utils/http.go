@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPTimeout is how long a network operation may take, including
+// reading response, unless changed with "--timeout".
+const DefaultHTTPTimeout = 60 * time.Second
+
+// ErrTimeout is wrapped by errors of network operations that did not
+// finish in time.
+var ErrTimeout = errors.New("timed out")
+
+var httpClient = &http.Client{Timeout: DefaultHTTPTimeout}
+
+// SetHTTPTimeout changes timeout of every following network operation
+func SetHTTPTimeout(timeout time.Duration) {
+	httpClient.Timeout = timeout
+}
+
+// HTTPGet is http.Get limited by configured timeout. Errors returned here,
+// or while reading response body, that are caused by timeout can be turned
+// into a descriptive one with WrapTimeout.
+func HTTPGet(url string) (*http.Response, error) {
+	res, err := httpClient.Get(url)
+	return res, WrapTimeout(err)
+}
+
+// HTTPStatusError is returned by FetchURL when server responds with an
+// unsuccessful HTTP status
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (err *HTTPStatusError) Error() string {
+	return err.URL + " responded " + err.Status
+}
+
+// FetchURL downloads whole content at `url`. Unsuccessful HTTP status is an
+// error.
+func FetchURL(url string) ([]byte, error) {
+	res, err := HTTPGet(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &HTTPStatusError{URL: url, StatusCode: res.StatusCode, Status: res.Status}
+	}
+
+	content, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, WrapTimeout(err)
+	}
+
+	return content, nil
+}
+
+// WrapTimeout replaces `err` with one wrapping ErrTimeout and explaining
+// how to wait longer, if `err` is caused by timeout. Other errors are
+// returned as-is.
+func WrapTimeout(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf(`%w after %s, use "--timeout" to wait longer`, ErrTimeout, httpClient.Timeout)
+	}
+
+	return err
+}
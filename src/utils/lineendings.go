@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+)
+
+var normalizeLineEndings = true
+
+// SetNormalizeLineEndings sets whether WriteTextFile converts CRLF line
+// endings to LF
+func SetNormalizeLineEndings(enabled bool) {
+	normalizeLineEndings = enabled
+}
+
+// NormalizeLineEndings converts CRLF line endings of `content` to LF
+func NormalizeLineEndings(content []byte) []byte {
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+// WriteTextFile writes text file spicetify generates, e.g. user.css or
+// wrapped custom app JS, with LF line endings unless turned off with
+// SetNormalizeLineEndings, so output does not mix line endings of sources
+// it is made from. Copied files are written as-is elsewhere.
+func WriteTextFile(path string, content []byte, perm os.FileMode) error {
+	if normalizeLineEndings {
+		content = NormalizeLineEndings(content)
+	}
+	return ioutil.WriteFile(path, content, perm)
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned by TryLock when lock is held by a running process
+var ErrLocked = errors.New("lock is held by another process")
+
+// heldLocks are lock files this process holds, kept open as long as the
+// lock is held
+var heldLocks = map[string]*os.File{}
+
+// TryLock takes lock file at `path` and writes this process id into it. If
+// another process holds it, ErrLocked is returned along with that process
+// id. Lock is an OS advisory lock on the file, which is released when its
+// process ends, so lock of a process that is no longer running is free to
+// take without a stale check two processes could both pass.
+func TryLock(path string) (owner int, err error) {
+	if _, ok := heldLocks[path]; ok {
+		return 0, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = lockFile(file); err != nil {
+		file.Close()
+		if err == ErrLocked {
+			content, _ := ioutil.ReadFile(path)
+			owner, _ = strconv.Atoi(strings.TrimSpace(string(content)))
+		}
+		return owner, err
+	}
+
+	// Process id left by a process that ended is only overwritten
+	if err = file.Truncate(0); err == nil {
+		_, err = file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	if err != nil {
+		unlockFile(file)
+		file.Close()
+		return 0, err
+	}
+
+	heldLocks[path] = file
+	return 0, nil
+}
+
+// Unlock releases lock file at `path` if it is held by this process. File
+// is kept: removing it would let a process waiting on the removed file and
+// one creating a new file both hold the lock.
+func Unlock(path string) {
+	file, ok := heldLocks[path]
+	if !ok {
+		return
+	}
+	delete(heldLocks, path)
+
+	file.Truncate(0)
+	unlockFile(file)
+	file.Close()
+}
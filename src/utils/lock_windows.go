@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockRange is locked byte of lock file, far past process id in it, since
+// Windows locks are mandatory and would stop others reading the id
+var lockRange = windows.Overlapped{OffsetHigh: 1}
+
+// lockFile takes exclusive lock on `file` without waiting
+func lockFile(file *os.File) error {
+	overlapped := lockRange
+	err := windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &overlapped)
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return ErrLocked
+	}
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	overlapped := lockRange
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, &overlapped)
+}
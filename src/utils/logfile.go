@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logFileMaxSize is how much output of past runs log file keeps. Older
+// output is dropped when a run starts.
+const logFileMaxSize = 256 * 1024
+
+var ansiRegex = regexp.MustCompile(`\x1B\[[0-9;]*m`)
+
+// logFileWriter writes output to log file without color codes
+type logFileWriter struct {
+	file *os.File
+}
+
+func (w logFileWriter) Write(p []byte) (int, error) {
+	if _, err := w.file.Write(ansiRegex.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// StartLogFile copies output of this run, without colors, to log file at
+// `path`, after a line with time and `args` of run. Output is only printed
+// if log file cannot be opened.
+func StartLogFile(path string, args []string) {
+	trimLogFile(path)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		PrintWarning("Cannot open log file: " + err.Error())
+		return
+	}
+
+	fmt.Fprintf(file, "=== %s spicetify %s\n", time.Now().Format(time.RFC3339), strings.Join(args, " "))
+	log.SetOutput(io.MultiWriter(log.Writer(), logFileWriter{file}))
+
+	OnExit(func(code int) {
+		fmt.Fprintf(file, "=== exit code %d\n\n", code)
+		file.Close()
+	})
+}
+
+// ReadLogTail returns last `size` bytes of log file at `path`, starting at
+// a line, or blank if there is no log file.
+func ReadLogTail(path string, size int64) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return ""
+	}
+
+	offset := int64(0)
+	if stat.Size() > size {
+		offset = stat.Size() - size
+	}
+	content := make([]byte, stat.Size()-offset)
+	if _, err := file.ReadAt(content, offset); err != nil && err != io.EOF {
+		return ""
+	}
+
+	tail := string(content)
+	if offset > 0 {
+		if index := strings.Index(tail, "\n"); index > -1 {
+			tail = tail[index+1:]
+		}
+	}
+	return tail
+}
+
+// trimLogFile drops output of older runs so log file at `path` stays under
+// logFileMaxSize
+func trimLogFile(path string) {
+	stat, err := os.Stat(path)
+	if err != nil || stat.Size() <= logFileMaxSize {
+		return
+	}
+
+	tail := ReadLogTail(path, logFileMaxSize/2)
+	os.WriteFile(path, []byte(tail), 0600)
+}
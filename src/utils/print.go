@@ -3,55 +3,126 @@ package utils
 import (
 	"log"
 	"os"
+
+	isatty "github.com/mattn/go-isatty"
+)
+
+// Verbosity levels
+const (
+	// VerbosityQuiet only prints errors, warnings and success messages
+	VerbosityQuiet = iota
+	// VerbosityNormal also prints progress and info messages
+	VerbosityNormal
+	// VerbosityVerbose also prints details, e.g. every file of a list
+	VerbosityVerbose
 )
 
+var verbosity = VerbosityNormal
+
+// SetVerbosity sets how much print helpers output
+func SetVerbosity(level int) {
+	verbosity = level
+}
+
+// IsVerbose reports whether verbosity is at least `level`
+func IsVerbose(level int) bool {
+	return verbosity >= level
+}
+
+// colorEnabled toggles ANSI color codes in all print helpers. It is on only
+// when stdout is a terminal and NO_COLOR is not set, unless overridden with
+// SetColor.
+var colorEnabled = isColorTerminal()
+
+func isColorTerminal() bool {
+	if len(os.Getenv("NO_COLOR")) > 0 {
+		return false
+	}
+
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// SetColor forces colored output on or off, regardless of detection
+func SetColor(enabled bool) {
+	colorEnabled = enabled
+}
+
+// Colorize wraps text in ANSI SGR sequence `code` if color is enabled
+func Colorize(code, text string) string {
+	if !colorEnabled {
+		return text
+	}
+	return "\x1B[" + code + "m" + text + "\x1B[0m"
+}
+
 // Bold .
 func Bold(text string) string {
-	return "\x1B[1m" + text + "\033[0m"
+	return Colorize("1", text)
+}
+
+// Underline .
+func Underline(text string) string {
+	return Colorize("4", text)
 }
 
 // Red .
 func Red(text string) string {
-	return "\x1B[31m" + text + "\x1B[0m"
+	return Colorize("31", text)
 }
 
 // Green .
 func Green(text string) string {
-	return "\x1B[32m" + text + "\x1B[0m"
+	return Colorize("32", text)
 }
 
 // Yellow .
 func Yellow(text string) string {
-	return "\x1B[33m" + text + "\x1B[0m"
+	return Colorize("33", text)
 }
 
 // Blue .
 func Blue(text string) string {
-	return "\x1B[34m" + text + "\x1B[0m"
+	return Colorize("34", text)
 }
 
 // PrintBold prints a bold message
 func PrintBold(text string) {
+	if !IsVerbose(VerbosityNormal) {
+		return
+	}
 	log.Println(Bold(text))
 }
 
 // PrintRed prints a message in red color
 func PrintRed(text string) {
+	if !IsVerbose(VerbosityNormal) {
+		return
+	}
 	log.Println(Red(text))
 }
 
 // PrintGreen prints a message in green color
 func PrintGreen(text string) {
+	if !IsVerbose(VerbosityNormal) {
+		return
+	}
 	log.Println(Green(text))
 }
 
+// warningCount is how many warnings were printed in this run
+var warningCount = 0
+
 // PrintWarning prints a warning message
 func PrintWarning(text string) {
+	warningCount++
+	reportWarning(text)
 	log.Println(Yellow("warning"), text)
 }
 
 // PrintError prints an error message
 func PrintError(text string) {
+	reportError(text)
 	log.Println(Red("error"), text)
 }
 
@@ -62,11 +133,21 @@ func PrintSuccess(text string) {
 
 // PrintInfo prints an info message
 func PrintInfo(text string) {
+	if !IsVerbose(VerbosityNormal) {
+		return
+	}
 	log.Println(Blue("info"), text)
 }
 
-// Fatal prints fatal message and exits process
+// Fatal prints fatal message and exits process, or only stops function
+// run by CatchFatal
 func Fatal(err error) {
+	if catchingFatal > 0 {
+		PrintError(err.Error())
+		panic(fatalError{err})
+	}
+
+	reportError(err.Error())
 	log.Println(Red("fatal"), err)
-	os.Exit(1)
+	Exit(1)
 }
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+)
+
+// reportVersion is bumped when Report schema changes incompatibly
+const reportVersion = 1
+
+// Report summarizes a run, written as JSON at exit by "--json-report".
+type Report struct {
+	Version int `json:"version"`
+	// Commands are commands run, e.g. ["backup", "apply"]
+	Commands []string `json:"commands"`
+	// Status is "success", "partial-failure", "warning" (only with
+	// "--fail-on-warning") or "failure", decided by ExitCode
+	Status    string `json:"status"`
+	ExitCode  int    `json:"exit_code"`
+	StartedAt string `json:"started_at"`
+	// DurationMs is elapsed time of the whole run
+	DurationMs int64         `json:"duration_ms"`
+	Stages     []ReportStage `json:"stages"`
+	// Pushed lists items transferred to Spotify by kind, "extensions" or
+	// "custom_apps"
+	Pushed map[string][]string `json:"pushed"`
+	// Files summarizes install manifest of apply, nil when not applied
+	Files    *ReportFiles `json:"files"`
+	Warnings []string     `json:"warnings"`
+	Errors   []string     `json:"errors"`
+}
+
+// ReportStage is a stage of a command, e.g. "Transferring extensions"
+type ReportStage struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+	// Status is "ok", or "failed" when run ended before stage finished
+	Status string `json:"status"`
+}
+
+// ReportFiles counts files in Spotify frontend folder that differ from
+// stock after apply
+type ReportFiles struct {
+	Total    int            `json:"total"`
+	Added    int            `json:"added"`
+	Modified int            `json:"modified"`
+	ByKind   map[string]int `json:"by_kind"`
+}
+
+var (
+	report      *Report
+	reportStart time.Time
+	stageStart  time.Time
+)
+
+// StartReport starts recording report of running `commands`, written to
+// `path` when process exits through Exit.
+func StartReport(path string, commands []string) {
+	reportStart = time.Now()
+	report = &Report{
+		Version:   reportVersion,
+		Commands:  commands,
+		StartedAt: reportStart.UTC().Format(time.RFC3339),
+		Stages:    []ReportStage{},
+		Pushed:    map[string][]string{},
+		Warnings:  []string{},
+		Errors:    []string{},
+	}
+
+	OnExit(func(code int) {
+		if err := writeReport(path, code); err != nil {
+			PrintError("Cannot write report: " + err.Error())
+		}
+	})
+}
+
+// PrintStage prints title of a stage and records its start in report.
+// Finish it with PrintStageDone.
+func PrintStage(title string) {
+	PrintBold(title + ":")
+	if report == nil {
+		return
+	}
+
+	stageStart = time.Now()
+	report.Stages = append(report.Stages, ReportStage{Name: title, Status: "failed"})
+}
+
+// PrintStageDone prints "OK" and records current stage as finished
+func PrintStageDone() {
+	PrintGreen("OK")
+	if report == nil || len(report.Stages) == 0 {
+		return
+	}
+
+	stage := &report.Stages[len(report.Stages)-1]
+	stage.Status = "ok"
+	stage.DurationMs = time.Since(stageStart).Milliseconds()
+}
+
+// ReportPushed records item `name` of `kind` as transferred to Spotify
+func ReportPushed(kind, name string) {
+	if report != nil {
+		report.Pushed[kind] = append(report.Pushed[kind], name)
+	}
+}
+
+// ReportInstalledFiles records summary of applied files
+func ReportInstalledFiles(files ReportFiles) {
+	if report != nil {
+		report.Files = &files
+	}
+}
+
+func reportWarning(text string) {
+	if report != nil {
+		report.Warnings = append(report.Warnings, text)
+	}
+}
+
+func reportError(text string) {
+	if report != nil {
+		report.Errors = append(report.Errors, text)
+	}
+}
+
+func writeReport(path string, code int) error {
+	report.ExitCode = code
+	report.DurationMs = time.Since(reportStart).Milliseconds()
+	switch code {
+	case ExitSuccess:
+		report.Status = "success"
+	case ExitPartialFailure:
+		report.Status = "partial-failure"
+	case ExitWarning:
+		report.Status = "warning"
+	default:
+		report.Status = "failure"
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
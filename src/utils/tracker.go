@@ -22,6 +22,9 @@ func NewTracker(total int) *Tracker {
 // Update increases progress count and prints current progress.
 func (t *Tracker) Update(name string) {
 	t.current++
+	if !IsVerbose(VerbosityNormal) {
+		return
+	}
 	line := fmt.Sprintf("\r[ %d / %d ] %s", t.current, t.total, name)
 	lineLen := len(line)
 	spaceLen := 0
@@ -37,7 +40,10 @@ func (t *Tracker) Update(name string) {
 
 // Finish prints success message
 func (t *Tracker) Finish() {
-	log.Println("\r\x1B[32mOK\033[0m" + strings.Repeat(" ", t.maxLen-2))
+	if !IsVerbose(VerbosityNormal) {
+		return
+	}
+	log.Println("\r" + Green("OK") + strings.Repeat(" ", t.maxLen-2))
 }
 
 // Reset .
@@ -2,6 +2,9 @@ package utils
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +14,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,6 +55,10 @@ func Unzip(src, dest string) error {
 		defer rc.Close()
 
 		fpath := filepath.Join(dest, f.Name)
+		// Reject entries like "../file" that would be written outside `dest`
+		if fpath != filepath.Clean(dest) && !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return errors.New("illegal file path in archive: " + f.Name)
+		}
 		if f.FileInfo().IsDir() {
 			os.MkdirAll(fpath, 0700)
 		} else {
@@ -61,7 +69,7 @@ func Unzip(src, dest string) error {
 
 			err = os.MkdirAll(fdir, 0700)
 			if err != nil {
-				log.Fatal(err)
+				Fatal(err)
 				return err
 			}
 			f, err := os.OpenFile(
@@ -80,8 +88,30 @@ func Unzip(src, dest string) error {
 	return nil
 }
 
-// Copy .
+// Copy copies files in folder `src` to folder `dest`, and its sub-folders
+// too when `recursive` is true. Only files whose name contains one of
+// `filters` are copied, unless `filters` is empty.
+//
+// Symlinks in `src`, and `src` itself, are followed: content of linked
+// files and folders is copied, never the links. A link pointing back to a
+// folder being copied is skipped so that it does not recurse forever.
 func Copy(src, dest string, recursive bool, filters []string) error {
+	return copyDir(src, dest, recursive, filters, map[string]bool{})
+}
+
+// copyDir implements Copy. `visited` holds resolved paths of folders on
+// current recursion branch.
+func copyDir(src, dest string, recursive bool, filters []string, visited map[string]bool) error {
+	realSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return err
+	}
+	if visited[realSrc] {
+		return nil
+	}
+	visited[realSrc] = true
+	defer delete(visited, realSrc)
+
 	dir, err := ioutil.ReadDir(src)
 	if err != nil {
 		return err
@@ -93,10 +123,18 @@ func Copy(src, dest string, recursive bool, filters []string) error {
 		fileName := file.Name()
 		fSrcPath := filepath.Join(src, fileName)
 
+		if file.Mode()&os.ModeSymlink != 0 {
+			if file, err = os.Stat(fSrcPath); err != nil {
+				return fmt.Errorf("cannot follow symlink %s: %w", fSrcPath, err)
+			}
+		}
+
 		fDestPath := filepath.Join(dest, fileName)
-		if file.IsDir() && recursive {
-			os.MkdirAll(fDestPath, 0700)
-			if err = Copy(fSrcPath, fDestPath, true, filters); err != nil {
+		if file.IsDir() {
+			if !recursive {
+				continue
+			}
+			if err = copyDir(fSrcPath, fDestPath, true, filters, visited); err != nil {
 				return err
 			}
 		} else {
@@ -115,21 +153,7 @@ func Copy(src, dest string, recursive bool, filters []string) error {
 				}
 			}
 
-			fSrc, err := os.Open(fSrcPath)
-			if err != nil {
-				return err
-			}
-			defer fSrc.Close()
-
-			fDest, err := os.OpenFile(
-				fDestPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0700)
-			if err != nil {
-				return err
-			}
-			defer fDest.Close()
-
-			_, err = io.Copy(fDest, fSrc)
-			if err != nil {
+			if err = copyFileTo(fSrcPath, fDestPath); err != nil {
 				return err
 			}
 		}
@@ -137,28 +161,127 @@ func Copy(src, dest string, recursive bool, filters []string) error {
 	return nil
 }
 
-// CopyFile .
+// CopyFile copies file at `srcPath` into folder `dest`, creating `dest`
+// and its parents if they do not exist.
 func CopyFile(srcPath, dest string) error {
+	if err := os.MkdirAll(dest, 0700); err != nil {
+		return err
+	}
+
+	return copyFileTo(srcPath, filepath.Join(dest, filepath.Base(srcPath)))
+}
+
+// copyFileTo streams file at `srcPath` to `destPath`, so memory use does not
+// grow with file size. Both files are closed before returning, rather than
+// deferred to caller, to not exhaust file handles when copying many files.
+func copyFileTo(srcPath, destPath string) error {
 	fSrc, err := os.Open(srcPath)
 	if err != nil {
 		return err
 	}
 	defer fSrc.Close()
 
-	destPath := filepath.Join(dest, filepath.Base(srcPath))
 	fDest, err := os.OpenFile(
 		destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0700)
 	if err != nil {
 		return err
 	}
-	defer fDest.Close()
 
-	_, err = io.Copy(fDest, fSrc)
-	if err != nil {
+	if _, err = io.Copy(fDest, fSrc); err != nil {
+		fDest.Close()
 		return err
 	}
 
-	return nil
+	// Write errors may only surface on close
+	return fDest.Close()
+}
+
+// SplitArgs splits command-line string `s` into arguments at whitespace.
+// Single or double quotes group text containing whitespace. Backslash
+// escapes a quote or another backslash; elsewhere it is kept literally so
+// Windows paths need no escaping.
+func SplitArgs(s string) ([]string, error) {
+	args := []string{}
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\'' || runes[i+1] == '\\') {
+			// Single quotes keep everything literally
+			if quote != '\'' {
+				i++
+				r = runes[i]
+			}
+			current.WriteRune(r)
+			inArg = true
+			continue
+		}
+
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// FormatSize formats `size` in bytes to human readable string, e.g. "12.3 MB"
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// FileChecksum returns hex encoded SHA-256 checksum of file content.
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
 // Replace uses Regexp to find any matched from `input` with `regexpTerm`
@@ -191,27 +314,94 @@ func ModifyFile(path string, repl func(string) string) {
 	ioutil.WriteFile(path, []byte(content), 0700)
 }
 
-// GetSpotifyVersion .
+// spotifyVersionOverride replaces detected Spotify version in compatibility
+// checks when set, to test them without installing that version.
+var spotifyVersionOverride = os.Getenv("SPICETIFY_SPOTIFY_VERSION")
+
+// SetSpotifyVersionOverride makes GetSpotifyVersion return `version` instead
+// of detected one. Blank `version` removes override.
+func SetSpotifyVersionOverride(version string) {
+	spotifyVersionOverride = version
+}
+
+// GetSpotifyVersionOverride returns Spotify version override, or blank
+// string if there is none.
+func GetSpotifyVersionOverride() string {
+	return spotifyVersionOverride
+}
+
+// GetSpotifyVersion returns Spotify version to check compatibility against:
+// version override if one is set, otherwise installed version.
 func GetSpotifyVersion(prefsPath string) string {
+	if len(spotifyVersionOverride) > 0 {
+		return spotifyVersionOverride
+	}
+	return GetInstalledSpotifyVersion(prefsPath)
+}
+
+// GetInstalledSpotifyVersion returns Spotify version recorded in "prefs"
+// file at `prefsPath`, ignoring version override. Backups and apply records
+// use it, as they describe what is actually installed.
+func GetInstalledSpotifyVersion(prefsPath string) string {
 	pref, err := ini.Load(prefsPath)
 	if err != nil {
-		log.Fatal(err)
+		Fatal(err)
 	}
 
 	rootSection, err := pref.GetSection("")
 	if err != nil {
-		log.Fatal(err)
+		Fatal(err)
 	}
 
 	version := rootSection.Key("app.last-launched-version")
 	return version.MustString("")
 }
 
+// CompareVersion compares two dot-separated version strings numerically,
+// e.g. Spotify's "1.1.62.583.gf7fd0b3f". Comparison stops at the first
+// non-numeric segment. Returns -1, 0 or 1 when `a` is lower than, equal to
+// or higher than `b`.
+func CompareVersion(a, b string) int {
+	partsA := versionNumbers(a)
+	partsB := versionNumbers(b)
+
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var numA, numB int
+		if i < len(partsA) {
+			numA = partsA[i]
+		}
+		if i < len(partsB) {
+			numB = partsB[i]
+		}
+
+		if numA < numB {
+			return -1
+		} else if numA > numB {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func versionNumbers(version string) []int {
+	numbers := []int{}
+	for _, part := range strings.Split(strings.TrimPrefix(version, "v"), ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+
+	return numbers
+}
+
 // GetExecutableDir returns directory of current process
 func GetExecutableDir() string {
 	exe, err := os.Executable()
 	if err != nil {
-		log.Fatal(err)
+		Fatal(err)
 	}
 
 	exeDir := filepath.Dir(exe)
@@ -223,9 +413,67 @@ func GetExecutableDir() string {
 	return exeDir
 }
 
-// GetJsHelperDir returns jsHelper directory in executable directory
+// GetDataDirs returns folders that bundled Themes, Extensions, CustomApps
+// and jsHelper are looked for in, by precedence: executable directory,
+// "share/spicetify" next to its parent "bin" folder, as package managers
+// install it, then system data folders of current OS.
+func GetDataDirs() []string {
+	exeDir := GetExecutableDir()
+	dirs := []string{exeDir, filepath.Join(filepath.Dir(exeDir), "share", "spicetify")}
+
+	if runtime.GOOS == "linux" {
+		dataDirs := os.Getenv("XDG_DATA_DIRS")
+		if len(dataDirs) == 0 {
+			dataDirs = "/usr/local/share:/usr/share"
+		}
+		for _, dir := range filepath.SplitList(dataDirs) {
+			// Relative entries are invalid per XDG spec
+			if filepath.IsAbs(dir) {
+				dirs = append(dirs, filepath.Join(dir, "spicetify"))
+			}
+		}
+
+	} else if runtime.GOOS == "darwin" {
+		dirs = append(dirs, "/opt/homebrew/share/spicetify", "/usr/local/share/spicetify")
+
+	} else if runtime.GOOS == "windows" {
+		if programData := os.Getenv("ProgramData"); len(programData) > 0 {
+			dirs = append(dirs, filepath.Join(programData, "spicetify"))
+		}
+	}
+
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			unique = append(unique, dir)
+		}
+	}
+	return unique
+}
+
+// GetBundledPaths returns `name` in every folder of GetDataDirs, by
+// precedence
+func GetBundledPaths(name string) []string {
+	paths := []string{}
+	for _, dir := range GetDataDirs() {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	return paths
+}
+
+// GetJsHelperDir returns the first jsHelper directory found in folders of
+// GetDataDirs, or the one in executable directory
 func GetJsHelperDir() string {
-	return filepath.Join(GetExecutableDir(), "jsHelper")
+	paths := GetBundledPaths("jsHelper")
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return paths[0]
 }
 
 // PrependTime prepends current time string to text and returns new string
@@ -252,6 +500,47 @@ func FindSymbol(debugInfo, content string, clues []string) []string {
 	return nil
 }
 
+// SymbolMatch is one match found by FindSymbols
+type SymbolMatch struct {
+	// Groups holds capture groups in order, like FindSymbol result
+	Groups []string
+	// Named holds named capture groups, e.g. "(?P<name>...)", by name
+	Named map[string]string
+}
+
+// FindSymbols is like FindSymbol but returns up to `n` matches of the first
+// clue that matches, or all of them if `n` is negative, each with its named
+// capture groups.
+func FindSymbols(debugInfo, content string, clues []string, n int) []SymbolMatch {
+	for _, v := range clues {
+		re := regexp.MustCompile(v)
+		found := re.FindAllStringSubmatch(content, n)
+		if len(found) == 0 {
+			continue
+		}
+
+		names := re.SubexpNames()
+		matches := []SymbolMatch{}
+		for _, groups := range found {
+			match := SymbolMatch{Groups: groups[1:], Named: map[string]string{}}
+			for i, name := range names {
+				if len(name) > 0 {
+					match.Named[name] = groups[i]
+				}
+			}
+			matches = append(matches, match)
+		}
+
+		return matches
+	}
+
+	if len(debugInfo) > 0 {
+		PrintError("Cannot find symbol for " + debugInfo)
+	}
+
+	return nil
+}
+
 // CreateJunction creates a junction in Windows or a symlink in Linux/Mac.
 func CreateJunction(location, destination string) error {
 	CheckExistAndDelete(destination)
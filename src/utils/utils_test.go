@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestCopyFileCreatesNestedFolders(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "user.css")
+	writeTestFile(t, src, "body {}")
+
+	dest := filepath.Join(root, "a", "b", "c")
+	if err := CopyFile(src, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, folder := range []string{"a", "a/b", "a/b/c"} {
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(folder)))
+		if err != nil || !info.IsDir() {
+			t.Errorf("folder %s is not created: %v", folder, err)
+		}
+	}
+	if got := readTestFile(t, filepath.Join(dest, "user.css")); got != "body {}" {
+		t.Errorf("copied content = %q, want %q", got, "body {}")
+	}
+}
+
+// symlink links `link` to `target`, skipping test where symlinks cannot be
+// created, e.g. on Windows without developer mode
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("cannot create symlinks: ", err)
+	}
+}
+
+func TestCopyFollowsSymlinks(t *testing.T) {
+	root := t.TempDir()
+	theme := filepath.Join(root, "dev", "theme")
+	writeTestFile(t, filepath.Join(theme, "color.ini"), "[base]")
+	writeTestFile(t, filepath.Join(theme, "assets", "logo.svg"), "<svg/>")
+	writeTestFile(t, filepath.Join(root, "shared.css"), "shared {}")
+
+	symlink(t, filepath.Join(root, "shared.css"), filepath.Join(theme, "user.css"))
+	// Link back to a parent folder must not make Copy recurse forever
+	symlink(t, theme, filepath.Join(theme, "assets", "parent"))
+	themes := filepath.Join(root, "Themes")
+	if err := os.MkdirAll(themes, 0700); err != nil {
+		t.Fatal(err)
+	}
+	symlink(t, theme, filepath.Join(themes, "Linked"))
+
+	dest := filepath.Join(root, "dest")
+	if err := Copy(filepath.Join(themes, "Linked"), dest, true, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"color.ini":       "[base]",
+		"user.css":        "shared {}",
+		"assets/logo.svg": "<svg/>",
+	} {
+		path := filepath.Join(dest, filepath.FromSlash(name))
+		if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+			t.Errorf("%s is not copied as regular file: %v", name, err)
+			continue
+		}
+		if got := readTestFile(t, path); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, "assets", "parent")); !os.IsNotExist(err) {
+		t.Errorf("link to parent folder is copied: %v", err)
+	}
+}
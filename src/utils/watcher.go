@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"io/fs"
 	"io/ioutil"
-	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
@@ -93,12 +92,7 @@ type debugger struct {
 // GetDebuggerPath fetches opening debugger list from localhost and returns
 // the Spotify one.
 func GetDebuggerPath() string {
-	res, err := http.Get("http://localhost:9222/json/list")
-	if err != nil {
-		return ""
-	}
-
-	body, err := ioutil.ReadAll(res.Body)
+	body, err := FetchURL("http://localhost:9222/json/list")
 	if err != nil {
 		return ""
 	}